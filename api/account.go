@@ -2,45 +2,115 @@ package api
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+	"github.com/qwerqy/mock_bank/util"
 )
 
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
-	Currency string `json:"currency" binding:"required,oneof=USD EUR MYR"`
+	Currency       string `json:"currency" binding:"required,currency"`
+	InitialDeposit int64  `json:"initial_deposit" binding:"min=0"`
+	// Metadata is any JSON object the client wants kept with the account.
+	Metadata json.RawMessage `json:"metadata,omitempty"`
 }
 
+// @Summary     Create an account for the authenticated user
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       request body api.createAccountRequest true "Account to create"
+// @Success     201 {object} db.Account "Account created"
+// @Header      201 {string} Location "Path of the new account"
+// @Success     200 {object} db.Account "Account already exists"
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     422 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts [post]
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	if !validMetadata(ctx, req.Metadata) {
 		return
 	}
 
-	arg := db.CreateAccountParams{
-		Owner:    req.Owner,
-		Currency: req.Currency,
-		Balance:  0,
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.OpenAccountTxParams{
+		Owner:          authPayload.Username,
+		Currency:       req.Currency,
+		InitialDeposit: req.InitialDeposit,
+		Metadata:       req.Metadata,
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	result, err := server.store.OpenAccountTx(ctx.Request.Context(), arg)
 	if err != nil {
+		if db.ErrorCode(err) == db.ErrUniqueViolation {
+			// the owner already holds an account in this currency, so
+			// creating it again hands back the one that exists, without
+			// the deposit
+			server.getExistingAccount(ctx, arg.Owner, arg.Currency)
+			return
+		}
+		if errors.Is(err, db.ErrBalanceCapExceeded) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
 
+	setLocation(ctx, server.accountPath(result.Account))
+	ctx.JSON(http.StatusCreated, result.Account)
+}
+
+func (server *Server) getExistingAccount(ctx *gin.Context, owner, currency string) {
+	account, err := server.store.GetAccountByOwnerCurrency(ctx.Request.Context(), db.GetAccountByOwnerCurrencyParams{
+		Owner:    owner,
+		Currency: currency,
+	})
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, account)
+	ctx.JSON(http.StatusOK, account)
 }
 
 type getAccountRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+// @Summary     Get an account
+// @Description With include=entries the account also carries an entries array of its most recent entries, newest first.
+// @Tags        accounts
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       include query string false "Set to entries to include the account's entries"
+// @Param       limit query integer false "Number of entries included, 1 to 100, 10 by default"
+// @Param       If-None-Match header string false "ETag of the copy the client already has"
+// @Success     200 {object} db.Account
+// @Header      200 {string} ETag "Version of the returned account"
+// @Success     304 "Account unchanged since the given ETag"
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id} [get]
 func (server *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 
@@ -49,26 +119,71 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
+	var query getAccountQueryRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if query.Include == includeEntries {
+		server.getAccountWithEntries(ctx, req.ID, query.Limit)
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx.Request.Context(), req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	if !authorizedForAccount(ctx, account) {
+		return
+	}
+
+	etag, err := etagFor(account)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("ETag", etag)
+	if etagMatches(ctx, etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
 	ctx.JSON(http.StatusOK, account)
 }
 
 type listAccountsRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	PageID        int32     `form:"page_id" binding:"min=1"`
+	PageSize      int32     `form:"page_size" binding:"min=1,max=100"`
+	CreatedAfter  time.Time `form:"created_after"`
+	CreatedBefore time.Time `form:"created_before"`
+	Label         string    `form:"label" binding:"omitempty,label"`
 }
 
+// @Summary     List accounts
+// @Tags        accounts
+// @Produce     json
+// @Param       page_id query integer false "Page number, starting at 1, the first by default"
+// @Param       page_size query integer false "Accounts per page, 1 to 100, the server default when left out"
+// @Param       created_after query string false "Only accounts created at or after this time (RFC 3339)"
+// @Param       created_before query string false "Only accounts created before this time (RFC 3339)"
+// @Param       label query string false "Only accounts carrying this label"
+// @Success     200 {array} db.Account
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts [get]
 func (server *Server) listAccounts(ctx *gin.Context) {
 	var req listAccountsRequest
+	req.PageID, req.PageSize = server.defaultPage()
 
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		fmt.Print(err)
@@ -76,17 +191,34 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 		return
 	}
 
+	if !req.CreatedAfter.IsZero() && !req.CreatedBefore.IsZero() && !req.CreatedAfter.Before(req.CreatedBefore) {
+		err := errors.New("created_after must be before created_before")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	arg := db.ListAccountsParams{
 		Limit:  req.PageSize,
 		Offset: (req.PageID - 1) * req.PageSize,
 	}
+	// admins see every account, everybody else only their own
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.AdminRole {
+		arg.Owner = sql.NullString{String: authPayload.Username, Valid: true}
+	}
+	if !req.CreatedAfter.IsZero() {
+		arg.CreatedAfter = util.NewNullTime(req.CreatedAfter)
+	}
+	if !req.CreatedBefore.IsZero() {
+		arg.CreatedBefore = util.NewNullTime(req.CreatedBefore)
+	}
+	if req.Label != "" {
+		arg.Label = sql.NullString{String: req.Label, Valid: true}
+	}
 
-	accounts, err := server.store.ListAccounts(ctx, arg)
+	// an empty page is a valid answer, ListAccounts never returns ErrNoRows
+	accounts, err := server.store.ListAccounts(ctx.Request.Context(), arg)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -94,6 +226,57 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, accounts)
 }
 
+type searchAccountsRequest struct {
+	Query    string `form:"q" binding:"required"`
+	PageID   int32  `form:"page_id" binding:"min=1"`
+	PageSize int32  `form:"page_size" binding:"min=1,max=100"`
+}
+
+// @Summary     Search accounts by part of the owner's username (admin only)
+// @Tags        accounts
+// @Produce     json
+// @Param       q query string true "Text the owner's username contains, case insensitive"
+// @Param       page_id query integer false "Page number, starting at 1, the first by default"
+// @Param       page_size query integer false "Accounts per page, 1 to 100, the server default when left out"
+// @Success     200 {array} db.Account
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/accounts/search [get]
+func (server *Server) searchAccounts(ctx *gin.Context) {
+	var req searchAccountsRequest
+	req.PageID, req.PageSize = server.defaultPage()
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.SearchAccountsByOwnerParams{
+		Query:  escapeLike(req.Query),
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	accounts, err := server.store.SearchAccountsByOwner(ctx.Request.Context(), arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accounts)
+}
+
+// likeEscaper makes % and _ match themselves in a LIKE pattern, using the
+// backslash that postgres treats as the escape character by default.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
 type updateAccountUriRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
@@ -102,6 +285,20 @@ type updateAccountJsonRequest struct {
 	Balance int64 `json:"balance" binding:"required"`
 }
 
+// @Summary     Set an account balance (admin only)
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       request body api.updateAccountJsonRequest true "New balance"
+// @Success     200 {object} db.Account
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id} [put]
 func (server *Server) updateAccount(ctx *gin.Context) {
 	var paramReq updateAccountUriRequest
 	var jsonReq updateAccountJsonRequest
@@ -113,7 +310,7 @@ func (server *Server) updateAccount(ctx *gin.Context) {
 
 	if err := ctx.ShouldBindJSON(&jsonReq); err != nil {
 		fmt.Print(err)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
 		return
 	}
 
@@ -122,9 +319,9 @@ func (server *Server) updateAccount(ctx *gin.Context) {
 		Balance: jsonReq.Balance,
 	}
 
-	account, err := server.store.UpdateAccount(ctx, arg)
+	account, err := server.store.UpdateAccount(ctx.Request.Context(), arg)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			fmt.Print(err)
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
@@ -136,10 +333,98 @@ func (server *Server) updateAccount(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, account)
 }
 
+type patchAccountUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// patchAccountJsonRequest only lists the fields an owner may change. Balances
+// are deliberately absent, they only ever change through transfers.
+type patchAccountJsonRequest struct {
+	Nickname *string `json:"nickname" binding:"omitempty,max=64"`
+	// Metadata replaces the account's metadata as a whole.
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// @Summary     Update account details
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       request body api.patchAccountJsonRequest true "Fields to change"
+// @Success     200 {object} db.Account
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id} [patch]
+func (server *Server) patchAccount(ctx *gin.Context) {
+	var uriReq patchAccountUriRequest
+	var jsonReq patchAccountJsonRequest
+
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&jsonReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	if jsonReq.Nickname == nil && jsonReq.Metadata == nil {
+		err := errors.New("no updatable fields provided")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !validMetadata(ctx, jsonReq.Metadata) {
+		return
+	}
+
+	if !server.accessibleAccount(ctx, uriReq.ID) {
+		return
+	}
+
+	arg := db.UpdateAccountDetailsParams{
+		ID:       uriReq.ID,
+		Metadata: jsonReq.Metadata,
+	}
+	if jsonReq.Nickname != nil {
+		arg.Nickname = sql.NullString{
+			String: *jsonReq.Nickname,
+			Valid:  true,
+		}
+	}
+
+	account, err := server.store.UpdateAccountDetails(ctx.Request.Context(), arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
 type deleteAccountRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+// @Summary     Delete an account
+// @Tags        accounts
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Success     200
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id} [delete]
 func (server *Server) deleteAccount(ctx *gin.Context) {
 	var req deleteAccountRequest
 
@@ -148,9 +433,13 @@ func (server *Server) deleteAccount(ctx *gin.Context) {
 		return
 	}
 
-	err := server.store.DeleteAccount(ctx, req.ID)
+	if !server.accessibleAccount(ctx, req.ID) {
+		return
+	}
+
+	err := server.store.DeleteAccount(ctx.Request.Context(), req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
@@ -160,3 +449,333 @@ func (server *Server) deleteAccount(ctx *gin.Context) {
 
 	ctx.Status(http.StatusOK)
 }
+
+type accountStatusRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type accountStatusBody struct {
+	Reason string `json:"reason" binding:"required,max=140"`
+}
+
+// @Summary     Freeze an account (admin only)
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       request body api.accountStatusBody true "Why the account is frozen"
+// @Success     200 {object} db.Account
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/freeze [post]
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.setAccountStatus(ctx, db.AccountStatusFrozen)
+}
+
+// @Summary     Unfreeze an account (admin only)
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       request body api.accountStatusBody true "Why the account is unfrozen"
+// @Success     200 {object} db.Account
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/unfreeze [post]
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.setAccountStatus(ctx, db.AccountStatusActive)
+}
+
+// setAccountStatus moves an account between active and frozen, recording the
+// reason and the admin in its status history. Closed accounts stay closed.
+func (server *Server) setAccountStatus(ctx *gin.Context, status string) {
+	var req accountStatusRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var body accountStatusBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.UpdateAccountStatusTxParams{
+		AccountID: req.ID,
+		Status:    status,
+		Reason:    body.Reason,
+		ChangedBy: authPayload.Username,
+	}
+
+	account, err := server.store.UpdateAccountStatusTx(ctx.Request.Context(), arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrAccountClosed):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+// @Summary     List the status changes of an account, oldest first
+// @Tags        accounts
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       tz query string false "IANA time zone to render timestamps in, UTC by default"
+// @Success     200 {array} db.AccountStatusHistory
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/status-history [get]
+func (server *Server) listAccountStatusHistory(ctx *gin.Context) {
+	var req accountStatusRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	loc, ok := responseLocation(ctx)
+	if !ok {
+		return
+	}
+
+	if !server.accessibleAccount(ctx, req.ID) {
+		return
+	}
+
+	history, err := server.store.ListAccountStatusHistory(ctx.Request.Context(), req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	for i := range history {
+		history[i].CreatedAt = history[i].CreatedAt.In(loc)
+	}
+	ctx.JSON(http.StatusOK, history)
+}
+
+type transferOwnershipRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+}
+
+// @Summary     Reassign an account to another user (admin only)
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       request body api.transferOwnershipRequest true "User who becomes the owner"
+// @Success     200 {object} db.Account
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/accounts/{id}/transfer-ownership [post]
+func (server *Server) transferAccountOwnership(ctx *gin.Context) {
+	var uri accountStatusRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req transferOwnershipRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	account, err := server.store.ReassignAccountOwnerTx(ctx.Request.Context(), db.ReassignAccountOwnerTxParams{
+		AccountID: uri.ID,
+		NewOwner:  req.Username,
+		ChangedBy: authPayload.Username,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+		case errors.Is(err, db.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case db.ErrorCode(err) == db.ErrUniqueViolation:
+			// the new owner already holds an account in this currency
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+// @Summary     Check an account's balance against its ledger entries (admin only)
+// @Tags        accounts
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Success     200 {object} db.AccountReconciliation
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/accounts/{id}/reconcile [get]
+func (server *Server) reconcileAccount(ctx *gin.Context) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	reconciliation, err := server.store.ReconcileAccountTx(ctx.Request.Context(), req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reconciliation)
+}
+
+type closeAccountRequest struct {
+	SweepToAccountID int64 `json:"sweep_to_account_id" binding:"omitempty,min=1"`
+}
+
+// @Summary     Close an account, moving its balance to another of the owner's accounts
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       request body api.closeAccountRequest false "Where the remaining balance goes"
+// @Success     200 {object} db.CloseAccountTxResult
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     422 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/close [post]
+func (server *Server) closeAccount(ctx *gin.Context) {
+	var uri accountStatusRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the body is optional, an empty account needs nowhere to sweep to
+	var req closeAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx.Request.Context(), uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !authorizedForAccount(ctx, account) {
+		return
+	}
+
+	if req.SweepToAccountID != 0 {
+		if req.SweepToAccountID == account.ID {
+			err := errors.New("an account cannot be swept into itself")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		destination, valid := server.validAccount(ctx, req.SweepToAccountID, account.Currency)
+		if !valid {
+			return
+		}
+		if destination.Owner != account.Owner {
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.CloseAccountTx(ctx.Request.Context(), db.CloseAccountTxParams{
+		AccountID:        account.ID,
+		SweepToAccountID: req.SweepToAccountID,
+		ClosedBy:         authPayload.Username,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrAccountClosed):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrAccountNotEmpty), errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrBalanceCapExceeded):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// accessibleAccount loads the account and checks the authenticated user may
+// act on it, writing the error response itself when not.
+func (server *Server) accessibleAccount(ctx *gin.Context, accountID int64) bool {
+	account, err := server.store.GetAccount(ctx.Request.Context(), accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	return authorizedForAccount(ctx, account)
+}
+
+// errAccountNotFound is returned both for missing accounts and for accounts
+// of other users, so a caller cannot probe which account IDs exist.
+var errAccountNotFound = errors.New("account not found")
+
+// authorizedForAccount reports whether the authenticated user owns the
+// account or is an admin, writing a 404 response when neither holds.
+func authorizedForAccount(ctx *gin.Context, account db.Account) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == util.AdminRole || account.Owner == authPayload.Username {
+		return true
+	}
+
+	ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+	return false
+}
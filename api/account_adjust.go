@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+)
+
+type adjustBalanceRequest struct {
+	// Amount is credited when positive and debited when negative.
+	Amount int64  `json:"amount" binding:"required"`
+	Reason string `json:"reason" binding:"required,max=200"`
+	// AllowNegative lets a debit overdraw the account.
+	AllowNegative bool `json:"allow_negative"`
+}
+
+// @Summary     Credit or debit an account by hand (admin only)
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       request body api.adjustBalanceRequest true "Signed amount and the reason for it"
+// @Success     200 {object} db.AdjustBalanceTxResult
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     422 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/accounts/{id}/adjust [post]
+func (server *Server) adjustBalance(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req adjustBalanceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	req.Reason = strings.TrimSpace(cleanDescription(req.Reason))
+	if req.Reason == "" {
+		err := errors.New("reason must not be blank")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.AdjustBalanceTx(ctx.Request.Context(), db.AdjustBalanceTxParams{
+		AccountID:     uri.ID,
+		Amount:        req.Amount,
+		Reason:        req.Reason,
+		AllowNegative: req.AllowNegative,
+		AdjustedBy:    authPayload.Username,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+		case errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrAccountClosed), errors.Is(err, db.ErrBalanceCapExceeded):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
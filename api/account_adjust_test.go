@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdjustBalanceAPI(t *testing.T) {
+	admin := util.RandomOwner()
+	account := randomAccount(util.RandomOwner())
+
+	adjusted := func(amount int64) db.AdjustBalanceTxResult {
+		result := db.AdjustBalanceTxResult{
+			Account: account,
+			Entry:   randomEntry(account.ID, db.EntryTypeAdjustment),
+		}
+		result.Account.Balance += amount
+		result.Entry.Amount = amount
+		return result
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Credit",
+			body: gin.H{"amount": 50, "reason": "goodwill credit"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.AdjustBalanceTxParams{
+					AccountID:  account.ID,
+					Amount:     50,
+					Reason:     "goodwill credit",
+					AdjustedBy: admin,
+				}
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(adjusted(50), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.AdjustBalanceTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, adjusted(50).Account, got.Account)
+				require.Equal(t, db.EntryTypeAdjustment, got.Entry.Type)
+			},
+		},
+		{
+			name: "OverdrawingDebit",
+			body: gin.H{"amount": -(account.Balance + 1), "reason": "chargeback"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AdjustBalanceTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "AllowNegativeDebit",
+			body: gin.H{"amount": -(account.Balance + 1), "reason": "chargeback", "allow_negative": true},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.AdjustBalanceTxParams{
+					AccountID:     account.ID,
+					Amount:        -(account.Balance + 1),
+					Reason:        "chargeback",
+					AllowNegative: true,
+					AdjustedBy:    admin,
+				}
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(adjusted(-(account.Balance + 1)), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.AdjustBalanceTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(-1), got.Account.Balance)
+			},
+		},
+		{
+			name: "MissingReason",
+			body: gin.H{"amount": 50},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "BlankReason",
+			body: gin.H{"amount": 50, "reason": " \n "},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ZeroAmount",
+			body: gin.H{"amount": 0, "reason": "nothing"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			body: gin.H{"amount": 50, "reason": "goodwill credit"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AdjustBalanceTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			body: gin.H{"amount": 50, "reason": "goodwill credit"},
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"amount": 50, "reason": "goodwill credit"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AdjustBalanceTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			path := fmt.Sprintf("/admin/accounts/%d/adjust", account.ID)
+			request, err := http.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+type accountFeesQueryRequest struct {
+	StartTime time.Time `form:"start_time"`
+	EndTime   time.Time `form:"end_time"`
+}
+
+// accountFeesResponse is the total of the transfer fees an account was
+// charged between StartTime and EndTime.
+type accountFeesResponse struct {
+	AccountID int64     `json:"account_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Total     int64     `json:"total"`
+}
+
+// @Summary     Total the transfer fees charged to an account
+// @Tags        accounts
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       start_time query string false "Start of the time range (RFC 3339), the beginning of time by default"
+// @Param       end_time query string false "End of the time range (RFC 3339), now by default"
+// @Success     200 {object} api.accountFeesResponse
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/fees [get]
+func (server *Server) getAccountFees(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query accountFeesQueryRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if query.EndTime.IsZero() {
+		query.EndTime = time.Now()
+	}
+	if !query.StartTime.Before(query.EndTime) {
+		err := fmt.Errorf("start_time must be before end_time")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.accessibleAccount(ctx, uri.ID) {
+		return
+	}
+
+	total, err := server.store.SumFeesCharged(ctx.Request.Context(), db.SumFeesChargedParams{
+		AccountID: uri.ID,
+		StartTime: query.StartTime,
+		EndTime:   query.EndTime,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accountFeesResponse{
+		AccountID: uri.ID,
+		StartTime: query.StartTime,
+		EndTime:   query.EndTime,
+		Total:     total,
+	})
+}
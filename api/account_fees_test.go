@@ -0,0 +1,210 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountFeesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	rangeQuery := url.Values{
+		"start_time": []string{start.Format(time.RFC3339)},
+		"end_time":   []string{end.Format(time.RFC3339)},
+	}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		query         url.Values
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			query:     rangeQuery,
+			username:  user.Username,
+			role:      user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.SumFeesChargedParams{
+					AccountID: account.ID,
+					StartTime: start,
+					EndTime:   end,
+				}
+				store.EXPECT().SumFeesCharged(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(42), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got accountFeesResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, account.ID, got.AccountID)
+				require.True(t, start.Equal(got.StartTime))
+				require.True(t, end.Equal(got.EndTime))
+				require.Equal(t, int64(42), got.Total)
+			},
+		},
+		{
+			name:      "DefaultRange",
+			accountID: account.ID,
+			query:     url.Values{},
+			username:  user.Username,
+			role:      user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SumFeesCharged(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.SumFeesChargedParams) (int64, error) {
+						require.True(t, arg.StartTime.IsZero())
+						require.WithinDuration(t, time.Now(), arg.EndTime, time.Minute)
+						return 0, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "AdminSeesAnyAccount",
+			accountID: account.ID,
+			query:     rangeQuery,
+			username:  util.RandomOwner(),
+			role:      util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SumFeesCharged(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "OtherUsersAccount",
+			accountID: account.ID,
+			query:     rangeQuery,
+			username:  util.RandomOwner(),
+			role:      util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SumFeesCharged(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorBody(t, recorder, errAccountNotFound.Error())
+			},
+		},
+		{
+			name:      "StartNotBeforeEnd",
+			accountID: account.ID,
+			query: url.Values{
+				"start_time": []string{end.Format(time.RFC3339)},
+				"end_time":   []string{start.Format(time.RFC3339)},
+			},
+			username: user.Username,
+			role:     user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().SumFeesCharged(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorBody(t, recorder, "start_time must be before end_time")
+			},
+		},
+		{
+			name:      "InvalidTime",
+			accountID: account.ID,
+			query:     url.Values{"start_time": []string{"yesterday"}},
+			username:  user.Username,
+			role:      user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SumFeesCharged(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidID",
+			accountID: 0,
+			query:     rangeQuery,
+			username:  user.Username,
+			role:      user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: account.ID,
+			query:     rangeQuery,
+			username:  user.Username,
+			role:      user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().SumFeesCharged(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			accountID: account.ID,
+			query:     rangeQuery,
+			username:  user.Username,
+			role:      user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SumFeesCharged(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/accounts/%d/fees?%s", tc.accountID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,525 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+const (
+	// accountIDModeSerial addresses accounts by their serial ID
+	accountIDModeSerial = "serial"
+	// accountIDModeUUID addresses accounts by their public UUID
+	accountIDModeUUID = "uuid"
+)
+
+func validAccountIDMode(mode string) error {
+	switch mode {
+	case accountIDModeSerial, accountIDModeUUID, "":
+		return nil
+	default:
+		return fmt.Errorf("unsupported account ID mode %q: must be %q or %q", mode, accountIDModeSerial, accountIDModeUUID)
+	}
+}
+
+var errAccountIDNotUUID = errors.New("account ID must be a UUID")
+
+// accountIDKeys are the JSON fields and query parameters, in requests and
+// responses alike, that hold an account ID.
+var accountIDKeys = map[string]bool{
+	"account_id":          true,
+	"from_account_id":     true,
+	"to_account_id":       true,
+	"sweep_to_account_id": true,
+}
+
+const accountIDsKey = "account_ids"
+
+// accountIDs maps between the serial IDs the handlers and the store work
+// with and the public_ids clients see in UUID mode. Every request gets its
+// own, so an account is looked up at most once per request.
+type accountIDs struct {
+	store    db.Store
+	publicID map[int64]uuid.UUID
+	id       map[uuid.UUID]int64
+}
+
+func newAccountIDs(store db.Store) *accountIDs {
+	return &accountIDs{
+		store:    store,
+		publicID: make(map[int64]uuid.UUID),
+		id:       make(map[uuid.UUID]int64),
+	}
+}
+
+func (ids *accountIDs) add(id int64, publicID uuid.UUID) {
+	ids.publicID[id] = publicID
+	ids.id[publicID] = id
+}
+
+// resolve looks up the serial IDs of publicIDs. It returns
+// db.ErrRecordNotFound when one of them names no account.
+func (ids *accountIDs) resolve(ctx context.Context, publicIDs []uuid.UUID) error {
+	var missing []uuid.UUID
+	for _, publicID := range publicIDs {
+		if _, ok := ids.id[publicID]; !ok {
+			missing = append(missing, publicID)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	rows, err := ids.store.ListAccountIDsByPublicID(ctx, missing)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		ids.add(row.ID, row.PublicID)
+	}
+
+	for _, publicID := range missing {
+		if _, ok := ids.id[publicID]; !ok {
+			return db.ErrRecordNotFound
+		}
+	}
+	return nil
+}
+
+// lookUpPublicIDs looks up the public_ids of serial IDs. IDs of accounts
+// that no longer exist are left out.
+func (ids *accountIDs) lookUpPublicIDs(ctx context.Context, serialIDs []int64) error {
+	var missing []int64
+	for _, id := range serialIDs {
+		if _, ok := ids.publicID[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	rows, err := ids.store.ListAccountPublicIDs(ctx, missing)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		ids.add(row.ID, row.PublicID)
+	}
+	return nil
+}
+
+// publicJSON rewrites a JSON document the way clients see it in UUID mode:
+// accounts carry their public_id as their id, and every field in
+// accountIDKeys holds a public_id.
+func (ids *accountIDs) publicJSON(ctx context.Context, data []byte) ([]byte, error) {
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// accounts in the document save looking up the IDs that refer to them
+	if err := ids.publicAccounts(doc); err != nil {
+		return nil, err
+	}
+
+	var serialIDs []int64
+	eachAccountID(doc, func(value interface{}) (interface{}, error) {
+		if id, ok := serialAccountID(value); ok {
+			serialIDs = append(serialIDs, id)
+		}
+		return value, nil
+	})
+	if err := ids.lookUpPublicIDs(ctx, serialIDs); err != nil {
+		return nil, err
+	}
+
+	eachAccountID(doc, func(value interface{}) (interface{}, error) {
+		id, ok := serialAccountID(value)
+		if !ok {
+			return value, nil
+		}
+		if publicID, ok := ids.publicID[id]; ok {
+			return publicID, nil
+		}
+		return nil, nil
+	})
+	return json.Marshal(doc)
+}
+
+// publicAccounts replaces the id of every account in doc, told apart by its
+// public_id field, with that public_id.
+func (ids *accountIDs) publicAccounts(doc interface{}) error {
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		if value, ok := doc["public_id"].(string); ok {
+			publicID, err := uuid.Parse(value)
+			if err != nil {
+				return err
+			}
+			if id, ok := serialAccountID(doc["id"]); ok {
+				ids.add(id, publicID)
+			}
+			doc["id"] = value
+		}
+		for _, value := range doc {
+			if err := ids.publicAccounts(value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, value := range doc {
+			if err := ids.publicAccounts(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// serialJSON rewrites the public_ids a client sent in a JSON document into
+// the serial IDs the handlers bind. Anything but a UUID in a field of
+// accountIDKeys is rejected with errAccountIDNotUUID.
+func (ids *accountIDs) serialJSON(ctx context.Context, data []byte) ([]byte, error) {
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var publicIDs []uuid.UUID
+	err = eachAccountID(doc, func(value interface{}) (interface{}, error) {
+		if value == nil {
+			return nil, nil
+		}
+		text, ok := value.(string)
+		if !ok {
+			return nil, errAccountIDNotUUID
+		}
+		publicID, err := uuid.Parse(text)
+		if err != nil {
+			return nil, errAccountIDNotUUID
+		}
+		publicIDs = append(publicIDs, publicID)
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(publicIDs) == 0 {
+		return data, nil
+	}
+	if err := ids.resolve(ctx, publicIDs); err != nil {
+		return nil, err
+	}
+
+	eachAccountID(doc, func(value interface{}) (interface{}, error) {
+		if value == nil {
+			return nil, nil
+		}
+		publicID := uuid.MustParse(value.(string))
+		return json.Number(strconv.FormatInt(ids.id[publicID], 10)), nil
+	})
+	return json.Marshal(doc)
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var doc interface{}
+	err := decoder.Decode(&doc)
+	return doc, err
+}
+
+// eachAccountID calls fn with the value of every field of accountIDKeys in a
+// decoded JSON document and puts back what it returns.
+func eachAccountID(doc interface{}, fn func(value interface{}) (interface{}, error)) error {
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range doc {
+			if accountIDKeys[key] {
+				replaced, err := fn(value)
+				if err != nil {
+					return err
+				}
+				doc[key] = replaced
+				continue
+			}
+			if err := eachAccountID(value, fn); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, value := range doc {
+			if err := eachAccountID(value, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func serialAccountID(value interface{}) (int64, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	id, err := number.Int64()
+	return id, err == nil && id > 0
+}
+
+// accountIDMiddleware puts the public_ids of accounts, in place of their
+// serial IDs, in every JSON response when the server runs in UUID mode, so
+// clients never learn the serial IDs. Streams, which are not JSON
+// documents, encode their events with publicValue.
+func (server *Server) accountIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if server.config.AccountIDMode != accountIDModeUUID {
+			ctx.Next()
+			return
+		}
+
+		ids := newAccountIDs(server.store)
+		ctx.Set(accountIDsKey, ids)
+
+		writer := &accountIDWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		defer writer.finish(ctx, ids)
+		ctx.Next()
+	}
+}
+
+// accountIDWriter holds a JSON response back until the handler is done, so
+// its account IDs can be rewritten. Anything else is sent as it is.
+type accountIDWriter struct {
+	gin.ResponseWriter
+	buf   bytes.Buffer
+	plain bool
+}
+
+func (w *accountIDWriter) Write(data []byte) (int, error) {
+	if !w.plain && !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.plain = true
+	}
+	if w.plain {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.buf.Write(data)
+}
+
+func (w *accountIDWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *accountIDWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *accountIDWriter) finish(ctx *gin.Context, ids *accountIDs) {
+	if w.plain {
+		return
+	}
+	// whatever comes after, such as the recovery middleware's response,
+	// goes straight out
+	w.plain = true
+	if w.buf.Len() == 0 {
+		return
+	}
+
+	data, err := ids.publicJSON(ctx.Request.Context(), w.buf.Bytes())
+	if err != nil {
+		// nothing has been sent yet, so the status can still change
+		w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+		data, _ = json.Marshal(errorResponse(err))
+	}
+	w.ResponseWriter.Write(data)
+}
+
+// accountIDRequestMiddleware turns the public_ids in the JSON body and the
+// query of a request into serial IDs when the server runs in UUID mode, so
+// the handlers bind the same request in both modes. It must run after
+// authMiddleware, so only clients that are signed in learn whether a
+// public_id exists.
+func (server *Server) accountIDRequestMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		value, ok := ctx.Get(accountIDsKey)
+		if !ok {
+			ctx.Next()
+			return
+		}
+		ids := value.(*accountIDs)
+
+		if err := ids.serialQuery(ctx); err != nil {
+			abortWithAccountIDError(ctx, err)
+			return
+		}
+
+		if ctx.Request.Body != nil && strings.HasPrefix(ctx.ContentType(), "application/json") {
+			data, err := ioutil.ReadAll(ctx.Request.Body)
+			if err != nil {
+				// bodyLimitMiddleware turns this into a 413 if the body was cut off
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+
+			// a body that is not JSON is left for the handler to reject
+			if rewritten, err := ids.serialJSON(ctx.Request.Context(), data); err == nil {
+				data = rewritten
+			} else if !isJSONError(err) {
+				abortWithAccountIDError(ctx, err)
+				return
+			}
+			ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(data))
+			ctx.Request.ContentLength = int64(len(data))
+		}
+
+		ctx.Next()
+	}
+}
+
+// serialQuery rewrites the public_ids in the query parameters of
+// accountIDKeys into serial IDs.
+func (ids *accountIDs) serialQuery(ctx *gin.Context) error {
+	query := ctx.Request.URL.Query()
+
+	var publicIDs []uuid.UUID
+	for key, values := range query {
+		if !accountIDKeys[key] {
+			continue
+		}
+		for _, value := range values {
+			publicID, err := uuid.Parse(value)
+			if err != nil {
+				return errAccountIDNotUUID
+			}
+			publicIDs = append(publicIDs, publicID)
+		}
+	}
+	if len(publicIDs) == 0 {
+		return nil
+	}
+	if err := ids.resolve(ctx.Request.Context(), publicIDs); err != nil {
+		return err
+	}
+
+	for key, values := range query {
+		if !accountIDKeys[key] {
+			continue
+		}
+		for i, value := range values {
+			values[i] = strconv.FormatInt(ids.id[uuid.MustParse(value)], 10)
+		}
+	}
+	ctx.Request.URL.RawQuery = query.Encode()
+	return nil
+}
+
+func isJSONError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+func abortWithAccountIDError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errAccountIDNotUUID):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+	case errors.Is(err, db.ErrRecordNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+// accountIDParamMiddleware lets an account route take the account's
+// public_id in place of its ID in UUID mode. It must run after
+// authMiddleware.
+func (server *Server) accountIDParamMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !resolveAccountIDParam(ctx) {
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// resolveAccountIDParam swaps the public_id in the id path parameter for the
+// account's serial ID in UUID mode, writing the error response itself when
+// it cannot. A serial ID is rejected, or the public_id would be no harder to
+// guess.
+func resolveAccountIDParam(ctx *gin.Context) bool {
+	value, ok := ctx.Get(accountIDsKey)
+	if !ok {
+		return true
+	}
+	ids := value.(*accountIDs)
+
+	for i, param := range ctx.Params {
+		if param.Key != "id" {
+			continue
+		}
+
+		publicID, err := uuid.Parse(param.Value)
+		if err != nil {
+			abortWithAccountIDError(ctx, errAccountIDNotUUID)
+			return false
+		}
+		if err := ids.resolve(ctx.Request.Context(), []uuid.UUID{publicID}); err != nil {
+			abortWithAccountIDError(ctx, err)
+			return false
+		}
+		ctx.Params[i].Value = strconv.FormatInt(ids.id[publicID], 10)
+	}
+	return true
+}
+
+// publicValue is v the way clients see it: in UUID mode, with public_ids
+// for account IDs. It is for responses that do not go out as a single JSON
+// document, such as stream events.
+func publicValue(ctx *gin.Context, v interface{}) (interface{}, error) {
+	value, ok := ctx.Get(accountIDsKey)
+	if !ok {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	data, err = value.(*accountIDs).publicJSON(ctx.Request.Context(), data)
+	return json.RawMessage(data), err
+}
+
+// accountRef names an account in an error message by the ID the client
+// knows it by.
+func accountRef(ctx *gin.Context, id int64) string {
+	value, ok := ctx.Get(accountIDsKey)
+	if !ok {
+		return strconv.FormatInt(id, 10)
+	}
+	// every account a request names has been resolved by the time a
+	// handler reports on it
+	if publicID, ok := value.(*accountIDs).publicID[id]; ok {
+		return publicID.String()
+	}
+	return "unknown"
+}
+
+// accountPath is the path of an account the way the account routes take it.
+func (server *Server) accountPath(account db.Account) string {
+	if server.config.AccountIDMode == accountIDModeUUID {
+		return "/accounts/" + account.PublicID.String()
+	}
+	return fmt.Sprintf("/accounts/%d", account.ID)
+}
@@ -0,0 +1,498 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// expectAccountIDLookups answers the public_id lookups of UUID mode for
+// accounts.
+func expectAccountIDLookups(store *mockdb.MockStore, accounts ...db.Account) {
+	store.EXPECT().
+		ListAccountIDsByPublicID(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, publicIDs []uuid.UUID) ([]db.ListAccountIDsByPublicIDRow, error) {
+			rows := []db.ListAccountIDsByPublicIDRow{}
+			for _, publicID := range publicIDs {
+				for _, account := range accounts {
+					if account.PublicID == publicID {
+						rows = append(rows, db.ListAccountIDsByPublicIDRow{ID: account.ID, PublicID: account.PublicID})
+					}
+				}
+			}
+			return rows, nil
+		})
+	store.EXPECT().
+		ListAccountPublicIDs(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, ids []int64) ([]db.ListAccountPublicIDsRow, error) {
+			rows := []db.ListAccountPublicIDsRow{}
+			for _, id := range ids {
+				for _, account := range accounts {
+					if account.ID == id {
+						rows = append(rows, db.ListAccountPublicIDsRow{ID: account.ID, PublicID: account.PublicID})
+					}
+				}
+			}
+			return rows, nil
+		})
+}
+
+// serveUUIDMode sends a request as user to a server in UUID mode.
+func serveUUIDMode(t *testing.T, store *mockdb.MockStore, user db.User, method, url string, body gin.H) *httptest.ResponseRecorder {
+	server, authHeader := newTestServerWithAuth(t, store)
+	server.config.AccountIDMode = accountIDModeUUID
+	recorder := httptest.NewRecorder()
+
+	var data bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&data).Encode(body))
+	}
+
+	request, err := http.NewRequest(method, url, &data)
+	require.NoError(t, err)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	request.Header.Set(authorizationHeaderKey, authHeader(user.Username, user.Role))
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func decodeBody(t *testing.T, recorder *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	return body
+}
+
+func TestAccountIDModeUUID(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	accountPath := "/accounts/" + account.PublicID.String()
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Create",
+			method: http.MethodPost,
+			url:    "/accounts",
+			body:   gin.H{"currency": account.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					OpenAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.OpenAccountTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, accountPath, recorder.Header().Get("Location"))
+
+				body := decodeBody(t, recorder)
+				require.Equal(t, account.PublicID.String(), body["id"])
+				require.Equal(t, account.PublicID.String(), body["public_id"])
+			},
+		},
+		{
+			name:   "Get",
+			method: http.MethodGet,
+			url:    accountPath,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				body := decodeBody(t, recorder)
+				require.Equal(t, account.PublicID.String(), body["id"])
+				require.Equal(t, account.Owner, body["owner"])
+			},
+		},
+		{
+			name:   "List",
+			method: http.MethodGet,
+			url:    "/accounts?page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Account{account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var body []map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Len(t, body, 1)
+				require.Equal(t, account.PublicID.String(), body[0]["id"])
+			},
+		},
+		{
+			name:   "Patch",
+			method: http.MethodPatch,
+			url:    accountPath,
+			body:   gin.H{"nickname": "savings"},
+			buildStubs: func(store *mockdb.MockStore) {
+				updated := account
+				updated.Nickname = "savings"
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					UpdateAccountDetails(gomock.Any(), gomock.Eq(db.UpdateAccountDetailsParams{
+						ID:       account.ID,
+						Nickname: sql.NullString{String: "savings", Valid: true},
+					})).
+					Times(1).
+					Return(updated, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				body := decodeBody(t, recorder)
+				require.Equal(t, account.PublicID.String(), body["id"])
+				require.Equal(t, "savings", body["nickname"])
+			},
+		},
+		{
+			name:   "Delete",
+			method: http.MethodDelete,
+			url:    accountPath,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "NestedRoute",
+			method: http.MethodGet,
+			url:    accountPath + "/labels",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListAccountLabels(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return([]db.AccountLabel{{AccountID: account.ID, Label: "rent"}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var body []map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Len(t, body, 1)
+				require.Equal(t, account.PublicID.String(), body[0]["account_id"])
+			},
+		},
+		{
+			name:   "SerialID",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/accounts/%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorBody(t, recorder, errAccountIDNotUUID.Error())
+			},
+		},
+		{
+			name:   "NotFound",
+			method: http.MethodGet,
+			url:    "/accounts/" + uuid.NewString(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorBody(t, recorder, errAccountNotFound.Error())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			expectAccountIDLookups(store, account)
+			tc.buildStubs(store)
+
+			recorder := serveUUIDMode(t, store, user, tc.method, tc.url, tc.body)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAccountIDModeUUIDLookupError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser(t)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountIDsByPublicID(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+
+	recorder := serveUUIDMode(t, store, user, http.MethodGet, "/accounts/"+uuid.NewString(), nil)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestCreateTransferAccountIDModeUUID(t *testing.T) {
+	user, _ := randomUser(t)
+	from := randomAccount(user.Username)
+	from.ID = 1
+	from.Currency = util.USD
+	from.Balance = 1000
+	to := randomAccount(util.RandomOwner())
+	to.ID = 2
+	to.Currency = util.USD
+
+	transfer := randomTransfer()
+	transfer.FromAccountID = from.ID
+	transfer.ToAccountID = to.ID
+	transfer.Amount = 10
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": from.PublicID,
+				"to_account_id":   to.PublicID,
+				"amount":          transfer.Amount,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to.ID)).Times(1).Return(to, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+						require.Equal(t, from.ID, arg.FromAccountID)
+						require.Equal(t, to.ID, arg.ToAccountID)
+						return db.TransferTxResult{
+							Transfer:    transfer,
+							FromAccount: from,
+							ToAccount:   to,
+							FromEntry:   randomEntry(from.ID, db.EntryTypeTransferDebit),
+							ToEntry:     randomEntry(to.ID, db.EntryTypeTransferCredit),
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				body := decodeBody(t, recorder)
+				gotTransfer := body["transfer"].(map[string]interface{})
+				require.Equal(t, from.PublicID.String(), gotTransfer["from_account_id"])
+				require.Equal(t, to.PublicID.String(), gotTransfer["to_account_id"])
+				require.Equal(t, from.PublicID.String(), body["from_account"].(map[string]interface{})["id"])
+				require.Equal(t, to.PublicID.String(), body["to_account"].(map[string]interface{})["id"])
+				require.Equal(t, from.PublicID.String(), body["from_entry"].(map[string]interface{})["account_id"])
+				require.Equal(t, to.PublicID.String(), body["to_entry"].(map[string]interface{})["account_id"])
+			},
+		},
+		{
+			name: "SerialID",
+			body: gin.H{
+				"from_account_id": from.ID,
+				"to_account_id":   to.PublicID,
+				"amount":          transfer.Amount,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorBody(t, recorder, errAccountIDNotUUID.Error())
+			},
+		},
+		{
+			name: "UnknownAccount",
+			body: gin.H{
+				"from_account_id": from.PublicID,
+				"to_account_id":   uuid.New(),
+				"amount":          transfer.Amount,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorBody(t, recorder, errAccountNotFound.Error())
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{
+				"from_account_id": from.PublicID,
+				"to_account_id":   to.PublicID,
+				"amount":          transfer.Amount,
+				"currency":        util.EUR,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorBody(t, recorder, fmt.Sprintf("account [%s] currency mismatch: USD vs EUR", from.PublicID))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			expectAccountIDLookups(store, from, to)
+			tc.buildStubs(store)
+
+			recorder := serveUUIDMode(t, store, user, http.MethodPost, "/transfers", tc.body)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListTransfersAccountIDModeUUID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.ID = 1
+	other := randomAccount(util.RandomOwner())
+	other.ID = 2
+
+	transfer := randomTransfer()
+	transfer.FromAccountID = account.ID
+	transfer.ToAccountID = other.ID
+
+	store := mockdb.NewMockStore(ctrl)
+	expectAccountIDLookups(store, account, other)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().
+		ListTransfersFrom(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.ListTransfersFromParams) ([]db.Transfer, error) {
+			require.Equal(t, account.ID, arg.FromAccountID)
+			return []db.Transfer{transfer}, nil
+		})
+
+	url := fmt.Sprintf("/transfers?account_id=%s&direction=out", account.PublicID)
+	recorder := serveUUIDMode(t, store, user, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var body []map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Len(t, body, 1)
+	require.Equal(t, account.PublicID.String(), body[0]["from_account_id"])
+	require.Equal(t, other.PublicID.String(), body[0]["to_account_id"])
+}
+
+// TestBalanceStreamAccountIDModeUUID checks the public_id of a WebSocket
+// request is only looked up once the client has shown a valid token.
+func TestBalanceStreamAccountIDModeUUID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListAccountIDsByPublicID(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	server.config.AccountIDMode = accountIDModeUUID
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/ws/accounts/"+uuid.NewString(), nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestAccountIDModeSerial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListAccountIDsByPublicID(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+
+	server, authHeader := newTestServerWithAuth(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/accounts/"+account.PublicID.String(), nil)
+	require.NoError(t, err)
+
+	request.Header.Set(authorizationHeaderKey, authHeader(user.Username, user.Role))
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestUnsupportedAccountIDMode(t *testing.T) {
+	_, err := NewServer(util.Config{
+		TokenSymmetricKey: testTokenSymmetricKey,
+		AccountIDMode:     "ulid",
+	}, nil, nil)
+	require.EqualError(t, err, `unsupported account ID mode "ulid": must be "serial" or "uuid"`)
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+)
+
+const (
+	maxImportFileSize = 1 << 20 // 1 MiB
+	maxImportRows     = 1000
+)
+
+type importAccountsQuery struct {
+	Atomic bool `form:"atomic"`
+}
+
+// importAccountRow is the outcome for one line of an imported CSV.
+type importAccountRow struct {
+	Line           int    `json:"line"`
+	Owner          string `json:"owner"`
+	Currency       string `json:"currency"`
+	InitialBalance int64  `json:"initial_balance"`
+	Created        bool   `json:"created"`
+	AccountID      int64  `json:"account_id,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+type importAccountsResponse struct {
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+	Rows    []importAccountRow `json:"rows"`
+}
+
+// @Summary     Import accounts from a CSV file (admin only)
+// @Description Each line holds owner, currency and initial_balance, optionally under a header line. Lines that fail are reported and skipped, unless atomic is set, in which case any failure creates nothing and answers 422.
+// @Tags        accounts
+// @Accept      multipart/form-data
+// @Produce     json
+// @Param       file formData file true "CSV of at most 1 MiB and 1000 lines"
+// @Param       atomic query boolean false "Create all accounts or none"
+// @Success     200 {object} api.importAccountsResponse
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     413 {object} map[string]string
+// @Failure     422 {object} api.importAccountsResponse
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/accounts/import [post]
+func (server *Server) importAccounts(ctx *gin.Context) {
+	var query importAccountsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if fileHeader.Size > maxImportFileSize {
+		err := fmt.Errorf("file exceeds the maximum size of %d bytes", maxImportFileSize)
+		ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rows, err := parseImportRows(data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// rows that passed validation, and where each sits in rows
+	var accounts []db.OpenAccountTxParams
+	var positions []int
+	for i, row := range rows {
+		if row.Error != "" {
+			continue
+		}
+		accounts = append(accounts, db.OpenAccountTxParams{
+			Owner:          row.Owner,
+			Currency:       row.Currency,
+			InitialDeposit: row.InitialBalance,
+		})
+		positions = append(positions, i)
+	}
+
+	if query.Atomic && len(accounts) < len(rows) {
+		ctx.JSON(http.StatusUnprocessableEntity, newImportAccountsResponse(rows))
+		return
+	}
+
+	if len(accounts) > 0 {
+		results, err := server.store.ImportAccountsTx(ctx.Request.Context(), db.ImportAccountsTxParams{
+			Accounts: accounts,
+			Atomic:   query.Atomic,
+		})
+		if err != nil && !errors.Is(err, db.ErrImportAborted) {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		for i, result := range results {
+			row := &rows[positions[i]]
+			switch {
+			case result.Err != nil:
+				row.Error = importErrorMessage(*row, result.Err)
+			case result.Account != nil:
+				row.Created = true
+				row.AccountID = result.Account.ID
+			}
+		}
+
+		if err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, newImportAccountsResponse(rows))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, newImportAccountsResponse(rows))
+}
+
+// parseImportRows reads the lines of an import file, validating each one.
+// A line that is invalid is returned with its Error set; only a file that
+// cannot be read as CSV at all, or has too many lines, is an error.
+func parseImportRows(data []byte) ([]importAccountRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	// a line with the wrong number of fields fails on its own
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []importAccountRow
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), "owner") {
+			continue
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("file exceeds the maximum of %d accounts", maxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, newImportAccountRow(line, record))
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("file holds no accounts")
+	}
+	return rows, nil
+}
+
+func newImportAccountRow(line int, record []string) importAccountRow {
+	row := importAccountRow{Line: line}
+	if len(record) != 3 {
+		row.Error = fmt.Sprintf("expected 3 fields, got %d", len(record))
+		return row
+	}
+
+	row.Owner = strings.TrimSpace(record[0])
+	row.Currency = strings.TrimSpace(record[1])
+
+	balance, err := strconv.ParseInt(strings.TrimSpace(record[2]), 10, 64)
+	switch {
+	case row.Owner == "" || !isAlphanumeric(row.Owner):
+		row.Error = "owner must be alphanumeric"
+	case !util.IsSupportedCurrency(row.Currency):
+		row.Error = fmt.Sprintf("unsupported currency %q", row.Currency)
+	case err != nil:
+		row.Error = "initial_balance must be a whole number"
+	case balance < 0:
+		row.Error = "initial_balance must not be negative"
+	default:
+		row.InitialBalance = balance
+	}
+	return row
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func importErrorMessage(row importAccountRow, err error) string {
+	switch db.ErrorCode(err) {
+	case db.ErrUniqueViolation:
+		return fmt.Sprintf("%s already has a %s account", row.Owner, row.Currency)
+	case db.ErrForeignKeyViolation:
+		return fmt.Sprintf("user %s does not exist", row.Owner)
+	}
+	return err.Error()
+}
+
+func newImportAccountsResponse(rows []importAccountRow) importAccountsResponse {
+	rsp := importAccountsResponse{Rows: rows}
+	for _, row := range rows {
+		if row.Created {
+			rsp.Created++
+		} else {
+			rsp.Failed++
+		}
+	}
+	return rsp
+}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportAccountsAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+
+	owner1 := util.RandomOwner()
+	owner2 := util.RandomOwner()
+
+	cleanCSV := fmt.Sprintf("owner,currency,initial_balance\n%s,USD,100\n%s,EUR,0\n", owner1, owner2)
+	badRowCSV := fmt.Sprintf("%s,USD,100\n%s,GBP,50\n", owner1, owner2)
+	accounts := []db.OpenAccountTxParams{
+		{Owner: owner1, Currency: util.USD, InitialDeposit: 100},
+		{Owner: owner2, Currency: util.EUR, InitialDeposit: 0},
+	}
+
+	// created returns what ImportAccountsTx reports for an account it opened
+	created := func(id int64, arg db.OpenAccountTxParams) db.ImportAccountResult {
+		return db.ImportAccountResult{Account: &db.Account{
+			ID:       id,
+			Owner:    arg.Owner,
+			Currency: arg.Currency,
+			Balance:  arg.InitialDeposit,
+		}}
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		data          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			data: cleanCSV,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ImportAccountsTxParams{Accounts: accounts}
+				store.EXPECT().ImportAccountsTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return([]db.ImportAccountResult{created(1, accounts[0]), created(2, accounts[1])}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := requireBodyImportAccounts(t, recorder.Body)
+				require.Equal(t, 2, rsp.Created)
+				require.Zero(t, rsp.Failed)
+				require.Equal(t, []importAccountRow{
+					{Line: 2, Owner: owner1, Currency: util.USD, InitialBalance: 100, Created: true, AccountID: 1},
+					{Line: 3, Owner: owner2, Currency: util.EUR, InitialBalance: 0, Created: true, AccountID: 2},
+				}, rsp.Rows)
+			},
+		},
+		{
+			name: "BadRow",
+			data: badRowCSV,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// only the valid line reaches the store
+				arg := db.ImportAccountsTxParams{Accounts: accounts[:1]}
+				store.EXPECT().ImportAccountsTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return([]db.ImportAccountResult{created(1, accounts[0])}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := requireBodyImportAccounts(t, recorder.Body)
+				require.Equal(t, 1, rsp.Created)
+				require.Equal(t, 1, rsp.Failed)
+				require.True(t, rsp.Rows[0].Created)
+				require.False(t, rsp.Rows[1].Created)
+				require.Equal(t, 2, rsp.Rows[1].Line)
+				require.Contains(t, rsp.Rows[1].Error, "currency")
+			},
+		},
+		{
+			name:  "BadRowAtomic",
+			query: "?atomic=true",
+			data:  badRowCSV,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ImportAccountsTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+				rsp := requireBodyImportAccounts(t, recorder.Body)
+				require.Zero(t, rsp.Created)
+				require.Equal(t, 2, rsp.Failed)
+				require.Empty(t, rsp.Rows[0].Error)
+				require.NotEmpty(t, rsp.Rows[1].Error)
+			},
+		},
+		{
+			name:  "StoreFailureAtomic",
+			query: "?atomic=true",
+			data:  cleanCSV,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ImportAccountsTxParams{Accounts: accounts, Atomic: true}
+				store.EXPECT().ImportAccountsTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return([]db.ImportAccountResult{{}, {Err: &pq.Error{Code: db.ForeignKeyViolation}}}, db.ErrImportAborted)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+				rsp := requireBodyImportAccounts(t, recorder.Body)
+				require.Zero(t, rsp.Created)
+				require.Empty(t, rsp.Rows[0].Error)
+				require.Equal(t, fmt.Sprintf("user %s does not exist", owner2), rsp.Rows[1].Error)
+			},
+		},
+		{
+			name: "TooManyRows",
+			data: strings.Repeat(fmt.Sprintf("%s,USD,1\n", owner1), maxImportRows+1),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ImportAccountsTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TooLarge",
+			data: strings.Repeat("a", maxImportFileSize+1),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ImportAccountsTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			data: cleanCSV,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ImportAccountsTx(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			data: cleanCSV,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ImportAccountsTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			body := new(bytes.Buffer)
+			writer := multipart.NewWriter(body)
+			part, err := writer.CreateFormFile("file", "accounts.csv")
+			require.NoError(t, err)
+			_, err = part.Write([]byte(tc.data))
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+
+			request, err := http.NewRequest(http.MethodPost, "/admin/accounts/import"+tc.query, body)
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", writer.FormDataContentType())
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyImportAccounts(t *testing.T, body *bytes.Buffer) importAccountsResponse {
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+
+	var rsp importAccountsResponse
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+	return rsp
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+const (
+	includeEntries             = "entries"
+	defaultAccountEntriesLimit = 10
+)
+
+type getAccountQueryRequest struct {
+	Include string `form:"include" binding:"omitempty,oneof=entries"`
+	Limit   int32  `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// accountWithEntriesResponse is an account followed by its most recent
+// entries, newest first, so a detail page needs a single request.
+type accountWithEntriesResponse struct {
+	db.Account
+	Entries []db.Entry `json:"entries"`
+}
+
+func newAccountWithEntriesResponse(row db.GetAccountWithEntriesRow) (accountWithEntriesResponse, error) {
+	rsp := accountWithEntriesResponse{
+		Account: db.Account{
+			ID:                  row.ID,
+			Owner:               row.Owner,
+			Balance:             row.Balance,
+			Currency:            row.Currency,
+			CreatedAt:           row.CreatedAt,
+			Nickname:            row.Nickname,
+			Status:              row.Status,
+			Metadata:            row.Metadata,
+			DailyLimitOverride:  row.DailyLimitOverride,
+			MaxTransferOverride: row.MaxTransferOverride,
+			PublicID:            row.PublicID,
+		},
+	}
+
+	// the entries come back as one JSON array, which is what lets the
+	// account and its entries share a round trip
+	err := json.Unmarshal(row.Entries, &rsp.Entries)
+	return rsp, err
+}
+
+// getAccountWithEntries answers GET /accounts/:id?include=entries. It is
+// getAccount with the account's last limit entries added.
+func (server *Server) getAccountWithEntries(ctx *gin.Context, accountID int64, limit int32) {
+	if limit == 0 {
+		limit = defaultAccountEntriesLimit
+	}
+
+	row, err := server.store.GetAccountWithEntries(ctx.Request.Context(), db.GetAccountWithEntriesParams{
+		ID:         accountID,
+		EntryLimit: limit,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := newAccountWithEntriesResponse(row)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !authorizedForAccount(ctx, rsp.Account) {
+		return
+	}
+
+	etag, err := etagFor(rsp)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("ETag", etag)
+	if etagMatches(ctx, etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// accountWithEntriesRow is what GetAccountWithEntries reads for account and
+// its entries.
+func accountWithEntriesRow(t *testing.T, account db.Account, entries []db.Entry) db.GetAccountWithEntriesRow {
+	data, err := json.Marshal(entries)
+	require.NoError(t, err)
+
+	return db.GetAccountWithEntriesRow{
+		ID:                  account.ID,
+		Owner:               account.Owner,
+		Balance:             account.Balance,
+		Currency:            account.Currency,
+		CreatedAt:           account.CreatedAt,
+		Nickname:            account.Nickname,
+		Status:              account.Status,
+		Metadata:            account.Metadata,
+		DailyLimitOverride:  account.DailyLimitOverride,
+		MaxTransferOverride: account.MaxTransferOverride,
+		PublicID:            account.PublicID,
+		Entries:             data,
+	}
+}
+
+func TestGetAccountIncludeEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	entries := []db.Entry{
+		randomEntry(account.ID, db.EntryTypeTransferCredit),
+		randomEntry(account.ID, db.EntryTypeTransferDebit),
+		randomEntry(account.ID, db.EntryTypeFee),
+	}
+	row := accountWithEntriesRow(t, account, entries)
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "IncludeEntries",
+			query:    url.Values{"include": []string{"entries"}, "limit": []string{"3"}},
+			username: user.Username,
+			role:     user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.GetAccountWithEntriesParams{
+					ID:         account.ID,
+					EntryLimit: 3,
+				}
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Eq(arg)).Times(1).Return(row, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListEntry(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotEmpty(t, recorder.Header().Get("ETag"))
+
+				var got accountWithEntriesResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, account, got.Account)
+				require.Equal(t, entries, got.Entries)
+			},
+		},
+		{
+			name:     "IncludeEntriesDefaultLimit",
+			query:    url.Values{"include": []string{"entries"}},
+			username: user.Username,
+			role:     user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.GetAccountWithEntriesParams{
+					ID:         account.ID,
+					EntryLimit: defaultAccountEntriesLimit,
+				}
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Eq(arg)).Times(1).Return(row, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NoEntries",
+			query:    url.Values{"include": []string{"entries"}},
+			username: user.Username,
+			role:     user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Any()).Times(1).
+					Return(accountWithEntriesRow(t, account, []db.Entry{}), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"entries":[]`)
+			},
+		},
+		{
+			name:     "IncludeAbsent",
+			query:    url.Values{},
+			username: user.Username,
+			role:     user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var fields map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &fields))
+				require.NotContains(t, fields, "entries")
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:     "LimitWithoutInclude",
+			query:    url.Values{"limit": []string{"5"}},
+			username: user.Username,
+			role:     user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), `"entries"`)
+			},
+		},
+		{
+			name:     "UnknownInclude",
+			query:    url.Values{"include": []string{"transfers"}},
+			username: user.Username,
+			role:     user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "LimitTooLarge",
+			query:    url.Values{"include": []string{"entries"}, "limit": []string{"101"}},
+			username: user.Username,
+			role:     user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "OtherUsersAccount",
+			query:    url.Values{"include": []string{"entries"}},
+			username: util.RandomOwner(),
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Any()).Times(1).Return(row, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), `"entries"`)
+			},
+		},
+		{
+			name:     "AdminSeesAnyAccount",
+			query:    url.Values{"include": []string{"entries"}},
+			username: util.RandomOwner(),
+			role:     util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Any()).Times(1).Return(row, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			query:    url.Values{"include": []string{"entries"}},
+			username: user.Username,
+			role:     user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Any()).Times(1).Return(db.GetAccountWithEntriesRow{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorBody(t, recorder, errAccountNotFound.Error())
+			},
+		},
+		{
+			name:     "InternalError",
+			query:    url.Values{"include": []string{"entries"}},
+			username: user.Username,
+			role:     user.Role,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountWithEntries(gomock.Any(), gomock.Any()).Times(1).Return(db.GetAccountWithEntriesRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/accounts/%d?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+// labelPattern is what a label may look like: up to 32 lowercase letters,
+// digits, hyphens or underscores, starting with a letter or digit.
+var labelPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+var validLabel validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if label, ok := fieldLevel.Field().Interface().(string); ok {
+		return labelPattern.MatchString(label)
+	}
+	return false
+}
+
+var errLabelNotFound = errors.New("label not found on this account")
+
+type accountLabelURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type addAccountLabelRequest struct {
+	Label string `json:"label" binding:"required,label"`
+}
+
+// @Summary     Label an account
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       request body api.addAccountLabelRequest true "Up to 32 lowercase letters, digits, '-' or '_'"
+// @Success     201 {object} db.AccountLabel
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/labels [post]
+func (server *Server) addAccountLabel(ctx *gin.Context) {
+	var uri accountLabelURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req addAccountLabelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	if !server.accessibleAccount(ctx, uri.ID) {
+		return
+	}
+
+	label, err := server.store.AddAccountLabel(ctx.Request.Context(), db.AddAccountLabelParams{
+		AccountID: uri.ID,
+		Label:     req.Label,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.ErrUniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, label)
+}
+
+// @Summary     List the labels of an account
+// @Tags        accounts
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Success     200 {array} db.AccountLabel
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/labels [get]
+func (server *Server) listAccountLabels(ctx *gin.Context) {
+	var uri accountLabelURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.accessibleAccount(ctx, uri.ID) {
+		return
+	}
+
+	labels, err := server.store.ListAccountLabels(ctx.Request.Context(), uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, labels)
+}
+
+type removeAccountLabelRequest struct {
+	ID    int64  `uri:"id" binding:"required,min=1"`
+	Label string `uri:"label" binding:"required,label"`
+}
+
+// @Summary     Remove a label from an account
+// @Tags        accounts
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       label path string true "Label to remove"
+// @Success     200
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/labels/{label} [delete]
+func (server *Server) removeAccountLabel(ctx *gin.Context) {
+	var req removeAccountLabelRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	if !server.accessibleAccount(ctx, req.ID) {
+		return
+	}
+
+	_, err := server.store.RemoveAccountLabel(ctx.Request.Context(), db.RemoveAccountLabelParams{
+		AccountID: req.ID,
+		Label:     req.Label,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errLabelNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+)
+
+// setAccountLimitsRequest replaces both overrides. One left out or null falls
+// back to the global limit again.
+type setAccountLimitsRequest struct {
+	// DailyLimitOverride replaces DAILY_TRANSFER_LIMIT for this account.
+	DailyLimitOverride *int64 `json:"daily_limit_override" binding:"omitempty,min=1"`
+	// MaxTransferOverride replaces MAX_TRANSFER_AMOUNT for this account.
+	MaxTransferOverride *int64 `json:"max_transfer_override" binding:"omitempty,min=1"`
+}
+
+// @Summary     Override an account's transfer limits (admin only)
+// @Description Overrides may raise or lower the global limits. Leaving one out or null clears it.
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       request body api.setAccountLimitsRequest true "Limit overrides in minor units"
+// @Success     200 {object} db.Account
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/accounts/{id}/limits [put]
+func (server *Server) setAccountLimits(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setAccountLimitsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	account, err := server.store.UpdateAccountLimitOverrides(ctx.Request.Context(), db.UpdateAccountLimitOverridesParams{
+		DailyLimitOverride:  optionalLimit(req.DailyLimitOverride),
+		MaxTransferOverride: optionalLimit(req.MaxTransferOverride),
+		ID:                  uri.ID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+func optionalLimit(limit *int64) util.NullInt64 {
+	if limit == nil {
+		return util.NullInt64{}
+	}
+	return util.NewNullInt64(*limit)
+}
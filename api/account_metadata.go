@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+// maxAccountMetadataSize bounds the metadata a client may store on an
+// account, measured as the JSON it sent.
+const maxAccountMetadataSize = 4 << 10 // 4 KiB
+
+// validMetadata checks that metadata, when given, is a JSON object of at most
+// maxAccountMetadataSize bytes, writing the error response when it is not.
+func validMetadata(ctx *gin.Context, metadata json.RawMessage) bool {
+	if metadata == nil {
+		return true
+	}
+
+	var err error
+	switch {
+	case len(metadata) > maxAccountMetadataSize:
+		err = fmt.Errorf("metadata exceeds the maximum size of %d bytes", maxAccountMetadataSize)
+	case !bytes.HasPrefix(bytes.TrimSpace(metadata), []byte("{")):
+		// arrays, scalars and null are all valid JSON, but not metadata
+		err = errors.New("metadata must be a JSON object")
+	default:
+		return true
+	}
+
+	ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	return false
+}
+
+type listAccountsByMetadataKeyRequest struct {
+	Key      string `form:"key" binding:"required,max=64"`
+	PageID   int32  `form:"page_id" binding:"min=1"`
+	PageSize int32  `form:"page_size" binding:"min=1,max=100"`
+}
+
+// @Summary     List the accounts whose metadata has a key (admin only)
+// @Tags        accounts
+// @Produce     json
+// @Param       key query string true "Top-level metadata key"
+// @Param       page_id query integer false "Page number, starting at 1, the first by default"
+// @Param       page_size query integer false "Accounts per page, 1 to 100, the server default when left out"
+// @Success     200 {array} db.Account
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/accounts/metadata [get]
+func (server *Server) listAccountsByMetadataKey(ctx *gin.Context) {
+	var req listAccountsByMetadataKeyRequest
+	req.PageID, req.PageSize = server.defaultPage()
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.GetAccountsByMetadataKeyParams{
+		Key:    req.Key,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	accounts, err := server.store.GetAccountsByMetadataKey(ctx.Request.Context(), arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accounts)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+// auditRejectedTransfer records a transfer the store refused. A failure to
+// write the record is only logged, the client still gets the original error.
+func (server *Server) auditRejectedTransfer(ctx *gin.Context, username string, arg db.TransferTxParams, reason error) {
+	_, err := server.store.CreateAuditLog(ctx.Request.Context(), db.CreateAuditLogParams{
+		Username:      username,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Reason:        reason.Error(),
+	})
+	if err != nil {
+		log.Println("cannot write audit log:", err)
+	}
+}
+
+type listAuditLogsRequest struct {
+	PageID   int32 `form:"page_id" binding:"min=1"`
+	PageSize int32 `form:"page_size" binding:"min=1,max=100"`
+}
+
+// @Summary     List rejected transfers and account ownership changes (admin only)
+// @Tags        audit
+// @Produce     json
+// @Param       page_id query integer false "Page number, starting at 1, the first by default"
+// @Param       page_size query integer false "Entries per page, 1 to 100, the server default when left out"
+// @Success     200 {array} db.AuditLog
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /audit/transfers [get]
+func (server *Server) listTransferAuditLogs(ctx *gin.Context) {
+	var req listAuditLogsRequest
+	req.PageID, req.PageSize = server.defaultPage()
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListAuditLogsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	logs, err := server.store.ListAuditLogs(ctx.Request.Context(), arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, logs)
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+)
+
+const (
+	// balanceStreamWriteWait bounds how long a single push may take.
+	balanceStreamWriteWait = 10 * time.Second
+	// balanceStreamPongWait is how long a client may stay silent before the
+	// connection is considered dead. Pings go out well within it.
+	balanceStreamPongWait   = 60 * time.Second
+	balanceStreamPingPeriod = balanceStreamPongWait * 9 / 10
+)
+
+var balanceStreamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+type balanceStreamQuery struct {
+	Token string `form:"token"`
+}
+
+// @Summary     Stream an account's balance over a WebSocket
+// @Description Each transfer that changes the balance pushes a db.BalanceUpdate.
+// @Tags        accounts
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       token query string true "Access token, as browsers cannot send headers on a WebSocket"
+// @Success     101 {object} db.BalanceUpdate "Switching to the WebSocket protocol"
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Router      /ws/accounts/{id} [get]
+func (server *Server) streamAccountBalance(ctx *gin.Context) {
+	var query balanceStreamQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil || query.Token == "" {
+		err := errors.New("access token is not provided")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(query.Token)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if payload.Type != token.AccessToken {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errNotAccessToken))
+		return
+	}
+	ctx.Set(authorizationPayloadKey, payload)
+
+	// only once the client is known to be signed in, like on the other
+	// account routes
+	if !resolveAccountIDParam(ctx) {
+		return
+	}
+
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx.Request.Context(), req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !authorizedForAccount(ctx, account) {
+		return
+	}
+
+	// subscribing before the upgrade means no transfer made once the client
+	// is connected can be missed
+	updates, unsubscribe := server.store.SubscribeBalance(account.ID)
+	defer unsubscribe()
+
+	conn, err := balanceStreamUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// Upgrade has already answered the client
+		return
+	}
+	defer conn.Close()
+
+	pushBalanceUpdates(ctx, conn, updates)
+}
+
+// pushBalanceUpdates writes every update to the connection until the client
+// goes away or stops answering pings.
+func pushBalanceUpdates(ctx *gin.Context, conn *websocket.Conn, updates <-chan db.BalanceUpdate) {
+	// the client sends nothing we need, but reading is what processes its
+	// pongs and notices when it closes the connection
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		conn.SetReadDeadline(time.Now().Add(balanceStreamPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(balanceStreamPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(balanceStreamPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			message, err := publicValue(ctx, update)
+			if err != nil {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(balanceStreamWriteWait))
+			if err := conn.WriteJSON(message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(balanceStreamWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamAccountBalanceAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	broker := db.NewBalanceBroker()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().SubscribeBalance(gomock.Eq(account.ID)).Times(1).DoAndReturn(broker.Subscribe)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, user.Role, uuid.New(), token.AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("ws%s/ws/accounts/%d?token=%s", strings.TrimPrefix(httpServer.URL, "http"), account.ID, accessToken)
+	conn, response, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, http.StatusSwitchingProtocols, response.StatusCode)
+
+	// what TransferTx publishes once a transfer to the account commits
+	update := db.BalanceUpdate{
+		AccountID:  account.ID,
+		Balance:    account.Balance + 10,
+		Currency:   account.Currency,
+		TransferID: util.RandomInt(1, 1000),
+	}
+	broker.Publish(update)
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var got db.BalanceUpdate
+	err = conn.ReadJSON(&got)
+	require.NoError(t, err)
+	require.Equal(t, update, got)
+}
+
+func TestStreamAccountBalanceRejectedAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		token         bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, response *http.Response)
+	}{
+		{
+			name: "NoToken",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().SubscribeBalance(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, response *http.Response) {
+				require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: util.RandomOwner(),
+			token:    true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SubscribeBalance(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, response *http.Response) {
+				require.Equal(t, http.StatusNotFound, response.StatusCode)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			token:    true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().SubscribeBalance(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, response *http.Response) {
+				require.Equal(t, http.StatusNotFound, response.StatusCode)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			httpServer := httptest.NewServer(server.router)
+			defer httpServer.Close()
+
+			url := fmt.Sprintf("ws%s/ws/accounts/%d", strings.TrimPrefix(httpServer.URL, "http"), account.ID)
+			if tc.token {
+				accessToken, _, err := server.tokenMaker.CreateToken(tc.username, util.DepositorRole, uuid.New(), token.AccessToken, time.Minute)
+				require.NoError(t, err)
+				url += "?token=" + accessToken
+			}
+
+			_, response, err := websocket.DefaultDialer.Dial(url, nil)
+			require.ErrorIs(t, err, websocket.ErrBadHandshake)
+			tc.checkResponse(t, response)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Codes that tell apart the two kinds of rejected request bodies: one that is
+// not valid JSON at all, and one that is but fails validation.
+const (
+	errCodeMalformedJSON    = "MALFORMED_JSON"
+	errCodeValidationFailed = "VALIDATION_FAILED"
+)
+
+type fieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// registerJSONFieldNames makes validation errors name fields the way clients
+// send them, by their json tag rather than their Go name.
+func registerJSONFieldNames() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// registerValidations adds the binding tags this API defines on top of the
+// validator's own.
+func registerValidations() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("label", validLabel)
+		v.RegisterValidation("currency", validCurrency)
+	}
+}
+
+// bindingErrorResponse lists every field that failed validation along with
+// the reason, so clients can point at the offending inputs. A body that could
+// not be decoded, because it is not JSON or a value has the wrong type, is
+// reported as malformed instead. Other errors fall back to errorResponse.
+func bindingErrorResponse(err error) gin.H {
+	if isMalformedJSON(err) {
+		return gin.H{"code": errCodeMalformedJSON, "error": err.Error()}
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return errorResponse(err)
+	}
+
+	fields := make([]fieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, fieldError{
+			Field:  fe.Field(),
+			Reason: validationReason(fe),
+		})
+	}
+	return gin.H{"code": errCodeValidationFailed, "errors": fields}
+}
+
+// isMalformedJSON reports whether err came from decoding the body rather than
+// validating it. A body cut short fails with io.ErrUnexpectedEOF, an empty
+// one with io.EOF.
+func isMalformedJSON(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) ||
+		errors.As(err, &typeErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+func validationReason(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "required"
+	case "oneof", "currency":
+		return "unsupported"
+	case "min", "gt", "gte":
+		return "too small"
+	case "max", "lt", "lte":
+		return "too large"
+	case "email":
+		return "invalid email"
+	case "alphanum":
+		return "must be alphanumeric"
+	case "label":
+		return "must be up to 32 lowercase letters, digits, '-' or '_'"
+	default:
+		return "failed " + fe.Tag() + " validation"
+	}
+}
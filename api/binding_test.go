@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindingErrorCodes(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          string
+		checkResponse func(t *testing.T, code string, fields []fieldError)
+	}{
+		{
+			name: "TruncatedJSON",
+			body: `{"from_account_id": 1, "to_account_id": 2, "amo`,
+			checkResponse: func(t *testing.T, code string, fields []fieldError) {
+				require.Equal(t, errCodeMalformedJSON, code)
+				require.Empty(t, fields)
+			},
+		},
+		{
+			name: "SyntaxError",
+			body: `{"from_account_id": 1,, "to_account_id": 2}`,
+			checkResponse: func(t *testing.T, code string, fields []fieldError) {
+				require.Equal(t, errCodeMalformedJSON, code)
+			},
+		},
+		{
+			name: "WrongType",
+			body: `{"from_account_id": "one", "to_account_id": 2, "amount": 10, "currency": "USD"}`,
+			checkResponse: func(t *testing.T, code string, fields []fieldError) {
+				require.Equal(t, errCodeMalformedJSON, code)
+				require.Empty(t, fields)
+			},
+		},
+		{
+			name: "MissingRequiredField",
+			body: `{"from_account_id": 1, "to_account_id": 2, "currency": "USD"}`,
+			checkResponse: func(t *testing.T, code string, fields []fieldError) {
+				require.Equal(t, errCodeValidationFailed, code)
+				require.Equal(t, []fieldError{{Field: "amount", Reason: "required"}}, fields)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+			code, fields := requireBodyErrorCode(t, recorder.Body)
+			tc.checkResponse(t, code, fields)
+		})
+	}
+}
+
+func requireBodyErrorCode(t *testing.T, body *bytes.Buffer) (string, []fieldError) {
+	var got struct {
+		Code   string       `json:"code"`
+		Errors []fieldError `json:"errors"`
+	}
+	err := json.Unmarshal(body.Bytes(), &got)
+	require.NoError(t, err)
+	return got.Code, got.Errors
+}
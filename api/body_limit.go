@@ -0,0 +1,67 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bodyLimitMiddleware rejects request bodies larger than the configured
+// limit with 413. Bodies that announce their size are refused up front, the
+// others are cut off by http.MaxBytesReader while the handler reads them.
+func (server *Server) bodyLimitMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit := server.config.MaxRequestBodyBytes
+		if limit <= 0 || ctx.Request.Body == nil {
+			ctx.Next()
+			return
+		}
+
+		if ctx.Request.ContentLength > limit {
+			err := fmt.Errorf("request body exceeds the limit of %d bytes", limit)
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+			return
+		}
+
+		body := &limitedBody{
+			ReadCloser: http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit),
+			limit:      limit,
+		}
+		ctx.Request.Body = body
+		ctx.Writer = &bodyLimitWriter{ResponseWriter: ctx.Writer, body: body}
+		ctx.Next()
+	}
+}
+
+// limitedBody remembers whether http.MaxBytesReader cut the body off.
+type limitedBody struct {
+	io.ReadCloser
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.read += int64(n)
+	if err != nil && err != io.EOF && body.read >= body.limit {
+		body.exceeded = true
+	}
+	return n, err
+}
+
+// bodyLimitWriter turns the 400 a handler writes when it fails to bind a
+// body that was cut off into a 413.
+type bodyLimitWriter struct {
+	gin.ResponseWriter
+	body *limitedBody
+}
+
+func (w *bodyLimitWriter) WriteHeader(code int) {
+	if code == http.StatusBadRequest && w.body.exceeded {
+		code = http.StatusRequestEntityTooLarge
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const forwardedForHeader = "X-Forwarded-For"
+
+// defaultTrustedProxies are trusted when TRUSTED_PROXIES names none: only a
+// proxy on the same host.
+var defaultTrustedProxies = []string{"127.0.0.1", "::1"}
+
+// parseTrustedProxies reads the configured trusted proxies, falling back to
+// defaultTrustedProxies.
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	trusted, err := parseCIDRs(proxies)
+	if err != nil || len(trusted) > 0 {
+		return trusted, err
+	}
+	return parseCIDRs(defaultTrustedProxies)
+}
+
+// requestIP is the address of the client that sent the request. A peer that
+// is not a trusted proxy is the client, whatever the headers say. Behind
+// trusted proxies, X-Forwarded-For is read from the right, past every
+// trusted proxy, up to the first address one of them saw connect. The
+// entries left of it were written by the client and could be anything.
+func requestIP(ctx *gin.Context, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(ctx.Request.RemoteAddr))
+	if err != nil {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(trustedProxies, ip) {
+		return ip
+	}
+
+	entries := strings.Split(ctx.GetHeader(forwardedForHeader), ",")
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := net.ParseIP(strings.TrimSpace(entries[i]))
+		if entry == nil {
+			break
+		}
+		ip = entry
+		if !containsIP(trustedProxies, ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// clientIP is requestIP as text, or empty when the peer address cannot be
+// read.
+func (server *Server) clientIP(ctx *gin.Context) string {
+	ip := requestIP(ctx, server.trustedProxies)
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIP(t *testing.T) {
+	trustedProxies, err := parseTrustedProxies([]string{"172.17.0.0/16"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expectedIP   string
+	}{
+		{
+			name:       "Direct",
+			remoteAddr: "203.0.113.9:52100",
+			expectedIP: "203.0.113.9",
+		},
+		{
+			name:         "UntrustedPeerForwardedFor",
+			remoteAddr:   "203.0.113.9:52100",
+			forwardedFor: "10.1.2.3",
+			expectedIP:   "203.0.113.9",
+		},
+		{
+			name:       "TrustedProxyWithoutHeader",
+			remoteAddr: "172.17.0.1:52100",
+			expectedIP: "172.17.0.1",
+		},
+		{
+			name:         "TrustedProxy",
+			remoteAddr:   "172.17.0.1:52100",
+			forwardedFor: "203.0.113.9",
+			expectedIP:   "203.0.113.9",
+		},
+		{
+			name:         "TrustedProxySpoofedEntry",
+			remoteAddr:   "172.17.0.1:52100",
+			forwardedFor: "10.1.2.3, 203.0.113.9",
+			expectedIP:   "203.0.113.9",
+		},
+		{
+			name:         "ChainOfTrustedProxies",
+			remoteAddr:   "172.17.0.1:52100",
+			forwardedFor: "10.1.2.3, 203.0.113.9, 172.17.0.2",
+			expectedIP:   "203.0.113.9",
+		},
+		{
+			name:         "MalformedEntry",
+			remoteAddr:   "172.17.0.1:52100",
+			forwardedFor: "203.0.113.9, not-an-ip",
+			expectedIP:   "172.17.0.1",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			router := gin.New()
+			router.GET("/ip", func(ctx *gin.Context) {
+				got = requestIP(ctx, trustedProxies).String()
+			})
+
+			request, err := http.NewRequest(http.MethodGet, "/ip", nil)
+			require.NoError(t, err)
+
+			request.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				request.Header.Set(forwardedForHeader, tc.forwardedFor)
+			}
+
+			router.ServeHTTP(httptest.NewRecorder(), request)
+			require.Equal(t, tc.expectedIP, got)
+		})
+	}
+}
+
+func TestTrustedProxiesDefaultToLoopback(t *testing.T) {
+	server := newTestServer(t, nil)
+	require.Equal(t, []string{"127.0.0.1/32", "::1/128"}, server.router.TrustedProxies)
+
+	request, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	request.RemoteAddr = "127.0.0.1:52100"
+	request.Header.Set(forwardedForHeader, "203.0.113.9")
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = request
+	require.Equal(t, "203.0.113.9", server.clientIP(ctx))
+
+	_, err = parseTrustedProxies([]string{"not-an-ip"})
+	require.Error(t, err)
+}
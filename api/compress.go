@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compressibleTypes are the content types worth compressing. Images, PDFs
+// and the like are compressed already.
+var compressibleTypes = []string{
+	"application/json",
+	"application/javascript",
+	"text/",
+}
+
+// gzipMiddleware compresses the responses of clients that accept gzip once
+// the body reaches the configured size. Smaller bodies and server-sent event
+// streams are sent as they are.
+func (server *Server) gzipMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		minSize := server.config.GzipMinBytes
+		if minSize <= 0 {
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("Vary", "Accept-Encoding")
+		if ctx.Request.Method == http.MethodHead ||
+			ctx.GetHeader("Upgrade") != "" ||
+			!acceptsGzip(ctx.GetHeader("Accept-Encoding")) {
+			ctx.Next()
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: ctx.Writer, minSize: minSize}
+		ctx.Writer = writer
+		// deferred so a panicking handler still leaves the writer sending,
+		// or the recovery middleware's 500 would sit in the buffer
+		defer writer.finish()
+		ctx.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header lists gzip without
+// refusing it with q=0.
+func acceptsGzip(header string) bool {
+	for _, coding := range strings.Split(header, ",") {
+		params := strings.Split(coding, ";")
+		if strings.TrimSpace(params[0]) != "gzip" {
+			continue
+		}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipWriter holds a response back until it either reaches minSize, and is
+// compressed from then on, or ends or is flushed short of it, and is sent
+// uncompressed.
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize int
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	plain   bool
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(data)
+	case w.plain:
+		return w.ResponseWriter.Write(data)
+	case !w.compressible():
+		if err := w.sendPlain(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() < w.minSize {
+		return len(data), nil
+	}
+
+	header := w.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	if _, err := w.gz.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	w.buf.Reset()
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends what is held back uncompressed, as a handler that flushes is
+// streaming and should not wait for minSize bytes.
+func (w *gzipWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	} else {
+		w.sendPlain()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipWriter) Written() bool {
+	return w.buf.Len() > 0 || w.gz != nil || w.ResponseWriter.Written()
+}
+
+func (w *gzipWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		return false
+	}
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *gzipWriter) sendPlain() error {
+	if w.plain || w.gz != nil {
+		return nil
+	}
+	w.plain = true
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// finish sends whatever the handler left behind once it returns.
+func (w *gzipWriter) finish() {
+	if w.gz != nil {
+		w.gz.Close()
+		return
+	}
+	w.sendPlain()
+}
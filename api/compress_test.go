@@ -0,0 +1,141 @@
+package api
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipMiddleware(t *testing.T) {
+	user, _ := randomUser(t)
+
+	const minSize = 1024
+
+	// a page of 50 accounts is well over minSize, a single one well under.
+	// Random public IDs hardly compress, so the page shares one.
+	manyAccounts := make([]db.Account, 50)
+	for i := range manyAccounts {
+		manyAccounts[i] = randomAccount(user.Username)
+		manyAccounts[i].PublicID = manyAccounts[0].PublicID
+	}
+	oneAccount := manyAccounts[:1]
+
+	testCases := []struct {
+		name           string
+		acceptEncoding string
+		accounts       []db.Account
+		compressed     bool
+	}{
+		{
+			name:           "LargeWithGzip",
+			acceptEncoding: "gzip, deflate",
+			accounts:       manyAccounts,
+			compressed:     true,
+		},
+		{
+			name:     "LargeWithoutGzip",
+			accounts: manyAccounts,
+		},
+		{
+			name:           "LargeGzipRefused",
+			acceptEncoding: "gzip;q=0, deflate",
+			accounts:       manyAccounts,
+		},
+		{
+			name:           "SmallWithGzip",
+			acceptEncoding: "gzip",
+			accounts:       oneAccount,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(1).Return(tc.accounts, nil)
+
+			server := newTestServer(t, store)
+			server.config.GzipMinBytes = minSize
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts?page_id=1&page_size=%d", len(tc.accounts))
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			if tc.acceptEncoding != "" {
+				request.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			require.Equal(t, "Accept-Encoding", recorder.Header().Get("Vary"))
+
+			body := recorder.Body.Bytes()
+			if tc.compressed {
+				require.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+				require.Less(t, len(body), minSize)
+
+				reader, err := gzip.NewReader(recorder.Body)
+				require.NoError(t, err)
+				body, err = ioutil.ReadAll(reader)
+				require.NoError(t, err)
+			} else {
+				require.Empty(t, recorder.Header().Get("Content-Encoding"))
+			}
+
+			var gotAccounts []db.Account
+			err = json.Unmarshal(body, &gotAccounts)
+			require.NoError(t, err)
+			require.Equal(t, tc.accounts, gotAccounts)
+		})
+	}
+}
+
+func TestGzipMiddlewareSkipsEventStream(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	// more entries than minSize bytes, so only the content type keeps the
+	// stream uncompressed
+	updates := make(chan db.BalanceUpdate, 16)
+	for i := 0; i < cap(updates); i++ {
+		updates <- db.BalanceUpdate{AccountID: account.ID, Entry: db.Entry{ID: int64(i + 1), AccountID: account.ID}}
+	}
+	close(updates)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().SubscribeBalance(gomock.Eq(account.ID)).Times(1).Return(updates, func() {})
+
+	server := newTestServer(t, store)
+	server.config.GzipMinBytes = 256
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/accounts/%d/entries/stream", account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	request.Header.Set("Accept-Encoding", "gzip")
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, recorder.Header().Get("Content-Encoding"))
+	require.Len(t, requireEntryEvents(t, recorder.Body.String()), cap(updates))
+}
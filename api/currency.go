@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/qwerqy/mock_bank/util"
+)
+
+// validCurrency backs the currency binding tag, so requests take exactly the
+// currencies util knows about.
+var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if currency, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedCurrency(currency)
+	}
+	return false
+}
+
+// @Summary     List the currencies accounts can be opened in
+// @Tags        currencies
+// @Produce     json
+// @Success     200 {array} util.Currency
+// @Router      /currencies [get]
+func (server *Server) listCurrencies(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, util.SupportedCurrencies())
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrenciesAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	recorder := httptest.NewRecorder()
+
+	// no authorization header, the list is public
+	request, err := http.NewRequest(http.MethodGet, "/currencies", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var currencies []util.Currency
+	err = json.Unmarshal(recorder.Body.Bytes(), &currencies)
+	require.NoError(t, err)
+	require.Contains(t, currencies, util.Currency{Code: util.USD, Symbol: "$", Decimals: 2})
+	require.Contains(t, currencies, util.Currency{Code: util.EUR, Symbol: "€", Decimals: 2})
+	require.Contains(t, currencies, util.Currency{Code: util.MYR, Symbol: "RM", Decimals: 2})
+}
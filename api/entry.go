@@ -0,0 +1,79 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+type listEntriesUriRequest struct {
+	AccountID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listEntriesQueryRequest struct {
+	PageID   int32  `form:"page_id" binding:"min=1"`
+	PageSize int32  `form:"page_size" binding:"min=1,max=100"`
+	Type     string `form:"type" binding:"omitempty,oneof=transfer_debit transfer_credit fee interest deposit adjustment"`
+}
+
+// @Summary     List an account's ledger entries
+// @Tags        accounts
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       page_id query integer false "Page number, starting at 1, the first by default"
+// @Param       page_size query integer false "Entries per page, 1 to 100, the server default when left out"
+// @Param       type query string false "Only entries of this type: transfer_debit, transfer_credit, fee, interest, deposit or adjustment"
+// @Param       tz query string false "IANA time zone to render timestamps in, UTC by default"
+// @Success     200 {array} db.Entry
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/entries [get]
+func (server *Server) listEntries(ctx *gin.Context) {
+	var uriReq listEntriesUriRequest
+	var queryReq listEntriesQueryRequest
+	queryReq.PageID, queryReq.PageSize = server.defaultPage()
+
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&queryReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	loc, ok := responseLocation(ctx)
+	if !ok {
+		return
+	}
+
+	if !server.accessibleAccount(ctx, uriReq.AccountID) {
+		return
+	}
+
+	arg := db.ListEntryParams{
+		AccountID: uriReq.AccountID,
+		Limit:     queryReq.PageSize,
+		Offset:    (queryReq.PageID - 1) * queryReq.PageSize,
+	}
+	if queryReq.Type != "" {
+		arg.Type = sql.NullString{String: queryReq.Type, Valid: true}
+	}
+
+	entries, err := server.store.ListEntry(ctx.Request.Context(), arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	for i := range entries {
+		entries[i].CreatedAt = entries[i].CreatedAt.In(loc)
+	}
+	ctx.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+const (
+	// entryStreamKeepAlive is how often an idle stream sends a comment, so
+	// proxies do not time the connection out.
+	entryStreamKeepAlive = 30 * time.Second
+	// entryStreamReplayBatch is how many missed entries are read at a time
+	// when a client reconnects.
+	entryStreamReplayBatch = 100
+)
+
+type streamEntriesRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// @Summary     Stream an account's new ledger entries as server-sent events
+// @Description Each entry is sent as an "entry" event whose ID is the entry ID. A client reconnecting with Last-Event-ID first receives the entries it missed.
+// @Tags        accounts
+// @Produce     text/event-stream
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       Last-Event-ID header integer false "ID of the last entry the client received"
+// @Success     200 {object} db.Entry
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/entries/stream [get]
+func (server *Server) streamEntries(ctx *gin.Context) {
+	var req streamEntriesRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var lastEventID int64
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			err := errors.New("invalid Last-Event-ID, expected an entry ID")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		lastEventID = id
+	}
+
+	if !server.accessibleAccount(ctx, req.ID) {
+		return
+	}
+
+	// subscribing before the replay means an entry written in between is
+	// delivered live instead of being missed
+	updates, unsubscribe := server.store.SubscribeBalance(req.ID)
+	defer unsubscribe()
+
+	var missed []db.Entry
+	for lastEventID > 0 {
+		entries, err := server.store.ListEntriesAfter(ctx.Request.Context(), db.ListEntriesAfterParams{
+			AccountID: req.ID,
+			AfterID:   lastEventID,
+			Limit:     entryStreamReplayBatch,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		missed = append(missed, entries...)
+		if len(entries) < entryStreamReplayBatch {
+			break
+		}
+		lastEventID = entries[len(entries)-1].ID
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Status(http.StatusOK)
+
+	for _, entry := range missed {
+		if writeEntryEvent(ctx, entry) != nil {
+			return
+		}
+		lastEventID = entry.ID
+	}
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(entryStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	done := clientContext(ctx).Done()
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			// the replay may already have sent it
+			if update.Entry.ID <= lastEventID {
+				continue
+			}
+			if writeEntryEvent(ctx, update.Entry) != nil {
+				return
+			}
+			lastEventID = update.Entry.ID
+		case <-keepAlive.C:
+			if _, err := io.WriteString(ctx.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func writeEntryEvent(ctx *gin.Context, entry db.Entry) error {
+	data, err := publicValue(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	return sse.Encode(ctx.Writer, sse.Event{
+		Id:    strconv.FormatInt(entry.ID, 10),
+		Event: "entry",
+		Data:  data,
+	})
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	entries := make([]db.Entry, 4)
+	for i := range entries {
+		entries[i] = db.Entry{
+			ID:        int64(10 + i),
+			AccountID: account.ID,
+			Amount:    util.RandomMoney(),
+		}
+	}
+
+	// updates returns a feed holding what TransferTx published for the
+	// entries, closed so the stream ends once it has sent them
+	updates := func(entries ...db.Entry) <-chan db.BalanceUpdate {
+		ch := make(chan db.BalanceUpdate, len(entries))
+		for _, entry := range entries {
+			ch <- db.BalanceUpdate{AccountID: account.ID, Entry: entry}
+		}
+		close(ch)
+		return ch
+	}
+
+	testCases := []struct {
+		name          string
+		lastEventID   string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "LiveEntry",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SubscribeBalance(gomock.Eq(account.ID)).Times(1).Return(updates(entries[0]), func() {})
+				store.EXPECT().ListEntriesAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+				require.Equal(t, []int64{entries[0].ID}, requireEntryEvents(t, recorder.Body.String()))
+			},
+		},
+		{
+			name:        "Reconnect",
+			lastEventID: fmt.Sprint(entries[0].ID),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				// entries[2] was written between subscribing and the replay,
+				// so it arrives twice but must be sent once
+				store.EXPECT().SubscribeBalance(gomock.Eq(account.ID)).Times(1).Return(updates(entries[2], entries[3]), func() {})
+
+				arg := db.ListEntriesAfterParams{
+					AccountID: account.ID,
+					AfterID:   entries[0].ID,
+					Limit:     entryStreamReplayBatch,
+				}
+				store.EXPECT().ListEntriesAfter(gomock.Any(), gomock.Eq(arg)).Times(1).Return(entries[1:3], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, []int64{entries[1].ID, entries[2].ID, entries[3].ID}, requireEntryEvents(t, recorder.Body.String()))
+			},
+		},
+		{
+			name:        "InvalidLastEventID",
+			lastEventID: "abc",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().SubscribeBalance(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotOwner",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, util.RandomOwner(), util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SubscribeBalance(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "ReplayError",
+			lastEventID: fmt.Sprint(entries[0].ID),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SubscribeBalance(gomock.Eq(account.ID)).Times(1).Return(updates(), func() {})
+				store.EXPECT().ListEntriesAfter(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/entries/stream", account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			if tc.lastEventID != "" {
+				request.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// requireEntryEvents returns the IDs of the entry events in an SSE body,
+// checking each carries the entry it names.
+func requireEntryEvents(t *testing.T, body string) []int64 {
+	var ids []int64
+	for _, event := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		var id int64
+		var data string
+		for _, line := range strings.Split(event, "\n") {
+			switch {
+			case strings.HasPrefix(line, "id:"):
+				_, err := fmt.Sscan(strings.TrimPrefix(line, "id:"), &id)
+				require.NoError(t, err)
+			case strings.HasPrefix(line, "event:"):
+				require.Equal(t, "event:entry", line)
+			case strings.HasPrefix(line, "data:"):
+				data = strings.TrimPrefix(line, "data:")
+			}
+		}
+		require.Contains(t, data, fmt.Sprintf(`"id":%d`, id))
+		ids = append(ids, id)
+	}
+	return ids
+}
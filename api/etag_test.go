@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountETagAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	updatedAccount := account
+	updatedAccount.Balance += 10
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil),
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(updatedAccount, nil),
+	)
+
+	server := newTestServer(t, store)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+
+		url := fmt.Sprintf("/accounts/%d", account.ID)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		if ifNoneMatch != "" {
+			request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// the first request has nothing to compare against
+	recorder := get("")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	requireBodyMatchAccount(t, recorder.Body, account)
+	etag := recorder.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// polling with the ETag returns no body while the account is unchanged
+	recorder = get(etag)
+	require.Equal(t, http.StatusNotModified, recorder.Code)
+	require.Empty(t, recorder.Body.String())
+	require.Equal(t, etag, recorder.Header().Get("ETag"))
+
+	// once the account changes, so does its ETag
+	recorder = get(etag)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	requireBodyMatchAccount(t, recorder.Body, updatedAccount)
+	require.NotEmpty(t, recorder.Header().Get("ETag"))
+	require.NotEqual(t, etag, recorder.Header().Get("ETag"))
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `"abc"`
+
+	testCases := []struct {
+		name        string
+		ifNoneMatch string
+		match       bool
+	}{
+		{name: "NoHeader", ifNoneMatch: "", match: false},
+		{name: "Same", ifNoneMatch: `"abc"`, match: true},
+		{name: "Different", ifNoneMatch: `"def"`, match: false},
+		{name: "List", ifNoneMatch: `"def", "abc"`, match: true},
+		{name: "Weak", ifNoneMatch: `W/"abc"`, match: true},
+		{name: "Any", ifNoneMatch: "*", match: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+
+			request, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+			if tc.ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			ctx.Request = request
+
+			require.Equal(t, tc.match, etagMatches(ctx, etag))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// noRoute answers requests for paths no route serves, in the same JSON
+// envelope as every other error.
+func (server *Server) noRoute(ctx *gin.Context) {
+	err := fmt.Errorf("no route for %s", ctx.Request.URL.Path)
+	ctx.JSON(http.StatusNotFound, errorResponse(err))
+}
+
+// noMethod answers requests for a path that is served, but not for their
+// method, listing the methods that are in the Allow header.
+func (server *Server) noMethod(ctx *gin.Context) {
+	allowed := allowedMethods(server.router.Routes(), ctx.Request.URL.Path)
+	ctx.Header("Allow", strings.Join(allowed, ", "))
+
+	err := fmt.Errorf("method %s is not allowed on %s", ctx.Request.Method, ctx.Request.URL.Path)
+	ctx.JSON(http.StatusMethodNotAllowed, errorResponse(err))
+}
+
+// allowedMethods lists, sorted, the methods of the routes that match path.
+func allowedMethods(routes gin.RoutesInfo, path string) []string {
+	seen := make(map[string]bool)
+	for _, route := range routes {
+		if routeMatches(route.Path, path) {
+			seen[route.Method] = true
+		}
+	}
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// routeMatches reports whether path is served by a route registered as
+// pattern, where :name matches one segment and *name the rest of the path.
+func routeMatches(pattern, path string) bool {
+	patternSegments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(segment, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(pathSegments)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackHandlers(t *testing.T) {
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "UnknownPath",
+			method: http.MethodGet,
+			path:   "/nowhere",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "application/json")
+				require.Empty(t, recorder.Header().Get("Allow"))
+				requireErrorBody(t, recorder, "no route for /nowhere")
+			},
+		},
+		{
+			name:   "PostOnGetOnlyRoute",
+			method: http.MethodPost,
+			path:   "/readyz",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "application/json")
+				require.Equal(t, "GET", recorder.Header().Get("Allow"))
+				requireErrorBody(t, recorder, "method POST is not allowed on /readyz")
+			},
+		},
+		{
+			name:   "PostOnRouteWithParam",
+			method: http.MethodPost,
+			path:   "/accounts/42",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+				require.Equal(t, "DELETE, GET, PATCH, PUT", recorder.Header().Get("Allow"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// none of them reaches a handler that touches the store
+			store := mockdb.NewMockStore(ctrl)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(tc.method, tc.path, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireErrorBody(t *testing.T, recorder *httptest.ResponseRecorder, want string) {
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Equal(t, map[string]string{"error": want}, body)
+}
+
+func TestRouteMatches(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "/readyz", path: "/readyz", want: true},
+		{pattern: "/readyz", path: "/readyz/now", want: false},
+		{pattern: "/accounts/:id", path: "/accounts/42", want: true},
+		{pattern: "/accounts/:id", path: "/accounts/", want: false},
+		{pattern: "/accounts/:id", path: "/accounts/42/labels", want: false},
+		{pattern: "/accounts/:id/labels/:label", path: "/accounts/42/labels/rent", want: true},
+		{pattern: "/swagger/*any", path: "/swagger/index.html", want: true},
+		{pattern: "/swagger/*any", path: "/swagger/a/b", want: true},
+		{pattern: "/transfers/all", path: "/transfers/42", want: false},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, routeMatches(tc.pattern, tc.path), "%s %s", tc.pattern, tc.path)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	featureAsyncTransfers = "async_transfers"
+
+	featureOverrideHeader = "X-Feature-Override"
+	featureFlagsKey       = "feature_flags"
+)
+
+var knownFeatures = []string{
+	featureAsyncTransfers,
+}
+
+// featureFlags tells which optional features are switched on.
+type featureFlags map[string]bool
+
+// newFeatureFlags enables every known feature except the disabled ones.
+func newFeatureFlags(disabled []string) featureFlags {
+	flags := featureFlags{}
+	for _, name := range knownFeatures {
+		flags[name] = true
+	}
+	for _, name := range disabled {
+		if _, ok := flags[name]; ok {
+			flags[name] = false
+		}
+	}
+	return flags
+}
+
+// withOverrides returns a copy of the flags with the overrides from a header
+// such as "async_transfers=off" applied. Unknown features are ignored.
+func (flags featureFlags) withOverrides(header string) featureFlags {
+	overridden := featureFlags{}
+	for name, enabled := range flags {
+		overridden[name] = enabled
+	}
+
+	for _, override := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(override), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		name := strings.TrimSpace(parts[0])
+		if _, ok := overridden[name]; !ok {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(parts[1])) {
+		case "on", "true", "1":
+			overridden[name] = true
+		case "off", "false", "0":
+			overridden[name] = false
+		}
+	}
+
+	return overridden
+}
+
+// featureMiddleware resolves the feature flags for a request. When the config
+// allows it they can be toggled per request with the X-Feature-Override
+// header, which never changes the server wide flags.
+func (server *Server) featureMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		flags := server.features
+		if server.config.AllowFeatureOverride {
+			if header := ctx.GetHeader(featureOverrideHeader); header != "" {
+				flags = flags.withOverrides(header)
+			}
+		}
+
+		ctx.Set(featureFlagsKey, flags)
+		ctx.Next()
+	}
+}
+
+// requireFeature hides a route behind a feature flag, answering 404 while the
+// feature is off.
+func requireFeature(name string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		flags := ctx.MustGet(featureFlagsKey).(featureFlags)
+		if !flags[name] {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "feature " + name + " is disabled"})
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/mail"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureFlagsWithOverrides(t *testing.T) {
+	flags := newFeatureFlags(nil)
+	require.True(t, flags[featureAsyncTransfers])
+
+	overridden := flags.withOverrides("async_transfers=off, unknown=on, malformed")
+	require.False(t, overridden[featureAsyncTransfers])
+	require.NotContains(t, overridden, "unknown")
+
+	// the original flags are left untouched
+	require.True(t, flags[featureAsyncTransfers])
+
+	flags = newFeatureFlags([]string{featureAsyncTransfers})
+	require.False(t, flags[featureAsyncTransfers])
+	require.True(t, flags.withOverrides("async_transfers=on")[featureAsyncTransfers])
+}
+
+func TestFeatureOverrideHeader(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		config        util.Config
+		override      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "DisabledByOverride",
+			config:   util.Config{AllowFeatureOverride: true},
+			override: "async_transfers=off",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateTransferJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "EnabledByOverride",
+			config: util.Config{
+				DisabledFeatures:     []string{featureAsyncTransfers},
+				AllowFeatureOverride: true,
+			},
+			override: "async_transfers=on",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				store.EXPECT().CreateTransferJob(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferJob{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			name:     "IgnoredWhenNotAllowed",
+			override: "async_transfers=off",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				store.EXPECT().CreateTransferJob(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferJob{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			config := tc.config
+			config.TokenSymmetricKey = util.RandomString(32)
+
+			server, err := NewServer(config, store, mail.NoopSender{})
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"from_account_id": account.ID,
+				"to_account_id":   account.ID,
+				"amount":          10,
+				"currency":        account.Currency,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/async", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set(featureOverrideHeader, tc.override)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+type readinessResponse struct {
+	Ready                    bool   `json:"ready"`
+	MigrationVersion         int64  `json:"migration_version"`
+	ExpectedMigrationVersion int64  `json:"expected_migration_version"`
+	MigrationDirty           bool   `json:"migration_dirty"`
+	Error                    string `json:"error,omitempty"`
+}
+
+// @Summary     Report whether the server can take traffic
+// @Description Ready means the database answers and its schema is at least at the migration this build expects.
+// @Tags        health
+// @Produce     json
+// @Success     200 {object} api.readinessResponse
+// @Failure     503 {object} api.readinessResponse
+// @Router      /readyz [get]
+func (server *Server) readyz(ctx *gin.Context) {
+	rsp := readinessResponse{ExpectedMigrationVersion: db.SchemaVersion}
+
+	status, err := server.store.MigrationStatus(ctx.Request.Context())
+	if err != nil {
+		rsp.Error = err.Error()
+		ctx.JSON(http.StatusServiceUnavailable, rsp)
+		return
+	}
+
+	rsp.MigrationVersion = status.Version
+	rsp.MigrationDirty = status.Dirty
+	// a schema ahead of this build is fine, it is what a rollout of a newer
+	// build leaves behind until the old servers are gone
+	rsp.Ready = !status.Dirty && status.Version >= db.SchemaVersion
+	if !rsp.Ready {
+		ctx.JSON(http.StatusServiceUnavailable, rsp)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
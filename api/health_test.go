@@ -0,0 +1,112 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyzAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "SchemaCurrent",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MigrationStatus(gomock.Any()).Times(1).
+					Return(db.MigrationStatus{Version: db.SchemaVersion}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, readinessResponse{
+					Ready:                    true,
+					MigrationVersion:         db.SchemaVersion,
+					ExpectedMigrationVersion: db.SchemaVersion,
+				}, requireBodyReadiness(t, recorder))
+			},
+		},
+		{
+			name: "SchemaAhead",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MigrationStatus(gomock.Any()).Times(1).
+					Return(db.MigrationStatus{Version: db.SchemaVersion + 1}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "SchemaBehind",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MigrationStatus(gomock.Any()).Times(1).
+					Return(db.MigrationStatus{Version: db.SchemaVersion - 1}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.Equal(t, readinessResponse{
+					MigrationVersion:         db.SchemaVersion - 1,
+					ExpectedMigrationVersion: db.SchemaVersion,
+				}, requireBodyReadiness(t, recorder))
+			},
+		},
+		{
+			name: "Dirty",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MigrationStatus(gomock.Any()).Times(1).
+					Return(db.MigrationStatus{Version: db.SchemaVersion, Dirty: true}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.True(t, requireBodyReadiness(t, recorder).MigrationDirty)
+			},
+		},
+		{
+			name: "DatabaseDown",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MigrationStatus(gomock.Any()).Times(1).
+					Return(db.MigrationStatus{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.Equal(t, sql.ErrConnDone.Error(), requireBodyReadiness(t, recorder).Error)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyReadiness(t *testing.T, recorder *httptest.ResponseRecorder) readinessResponse {
+	var rsp readinessResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	return rsp
+}
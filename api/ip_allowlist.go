@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseCIDRs reads an allowlist of CIDR ranges. A bare address is taken as a
+// range holding only that address.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	ranges := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			ranges = append(ranges, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q", cidr)
+		}
+		ranges = append(ranges, ipNet)
+	}
+	return ranges, nil
+}
+
+// containsIP reports whether ip is in any of cidrs.
+func containsIP(cidrs []*net.IPNet, ip net.IP) bool {
+	for _, cidr := range cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipAllowlistMiddleware answers 403 to clients outside cidrs. An empty
+// allowlist lets every client through.
+func ipAllowlistMiddleware(cidrs []*net.IPNet, trustedProxies []*net.IPNet) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if len(cidrs) == 0 {
+			ctx.Next()
+			return
+		}
+
+		if ip := requestIP(ctx, trustedProxies); ip != nil && containsIP(cidrs, ip) {
+			ctx.Next()
+			return
+		}
+
+		err := errors.New("client address is not allowed")
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	"github.com/qwerqy/mock_bank/mail"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPAllowlistMiddleware(t *testing.T) {
+	allowlist, err := parseCIDRs([]string{"10.0.0.0/8", "192.168.1.7"})
+	require.NoError(t, err)
+	trustedProxies, err := parseCIDRs([]string{"172.17.0.0/16"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expectedCode int
+	}{
+		{
+			name:         "AllowedRange",
+			remoteAddr:   "10.1.2.3:52100",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "AllowedAddress",
+			remoteAddr:   "192.168.1.7:52100",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Disallowed",
+			remoteAddr:   "192.168.1.8:52100",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "SpoofedForwardedFor",
+			remoteAddr:   "203.0.113.9:52100",
+			forwardedFor: "10.1.2.3",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "TrustedProxy",
+			remoteAddr:   "172.17.0.1:52100",
+			forwardedFor: "203.0.113.9, 10.1.2.3",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "TrustedProxyDisallowed",
+			remoteAddr:   "172.17.0.1:52100",
+			forwardedFor: "10.1.2.3, 203.0.113.9",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET(
+				"/allowlisted",
+				ipAllowlistMiddleware(allowlist, trustedProxies),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/allowlisted", nil)
+			require.NoError(t, err)
+
+			request.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				request.Header.Set(forwardedForHeader, tc.forwardedFor)
+			}
+
+			router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
+
+func TestIPAllowlistMiddlewareEmpty(t *testing.T) {
+	router := gin.New()
+	router.GET("/allowlisted", ipAllowlistMiddleware(nil, nil), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/allowlisted", nil)
+	require.NoError(t, err)
+
+	request.RemoteAddr = "203.0.113.9:52100"
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestAdminRoutesAllowlist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().SearchAccountsByOwner(gomock.Any(), gomock.Any()).Times(0)
+
+	config := util.Config{
+		TokenSymmetricKey:    testTokenSymmetricKey,
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Minute,
+		AdminAllowedCIDRs:    []string{"10.0.0.0/8"},
+	}
+	server, err := NewServer(config, store, mail.NoopSender{})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/admin/accounts/search?q=x", nil)
+	require.NoError(t, err)
+
+	request.RemoteAddr = "203.0.113.9:52100"
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestParseCIDRs(t *testing.T) {
+	cidrs, err := parseCIDRs([]string{"10.0.0.0/8", " 2001:db8::/32 ", "192.168.1.7", "::1", ""})
+	require.NoError(t, err)
+	require.Len(t, cidrs, 4)
+	require.Equal(t, "192.168.1.7/32", cidrs[2].String())
+	require.Equal(t, "::1/128", cidrs[3].String())
+
+	for _, cidr := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0"} {
+		_, err := parseCIDRs([]string{cidr})
+		require.Error(t, err, cidr)
+	}
+
+	_, err = NewServer(util.Config{
+		TokenSymmetricKey: testTokenSymmetricKey,
+		AdminAllowedCIDRs: []string{"not-an-ip"},
+	}, nil, nil)
+	require.Error(t, err)
+}
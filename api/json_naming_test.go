@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+var snakeCase = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+var jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// TestResponseJSONNaming keeps every field the API serializes in snake_case.
+// An untagged field would go out under its Go name, so it fails here too.
+func TestResponseJSONNaming(t *testing.T) {
+	responses := []interface{}{
+		db.Account{},
+		accountWithEntriesResponse{},
+		accountFeesResponse{},
+		db.AccountStatusHistory{},
+		db.Entry{},
+		db.Transfer{},
+		db.TransferTxResult{},
+		db.SplitTransferTxResult{},
+		db.ApproveTransferTxResult{},
+		db.CaptureHoldTxResult{},
+		db.CloseAccountTxResult{},
+		db.OpenAccountTxResult{},
+		db.AccountReconciliation{},
+		db.AdjustBalanceTxResult{},
+		db.Hold{},
+		db.ScheduledTransfer{},
+		db.TransferJob{},
+		userResponse{},
+		createUserResponse{},
+		loginUserResponse{},
+		renewAccessTokenResponse{},
+		walletResponse{},
+		importAccountsResponse{},
+		transferAttachmentResponse{},
+		transferPreviewResponse{},
+		readinessResponse{},
+		util.Currency{},
+	}
+
+	for _, response := range responses {
+		typ := reflect.TypeOf(response)
+		t.Run(typ.String(), func(t *testing.T) {
+			require.Empty(t, badJSONFields(typ, typ.Name(), map[reflect.Type]bool{}))
+		})
+	}
+}
+
+// badJSONFields returns the path of each field under typ whose JSON name is
+// not snake_case, looking into nested and embedded structs.
+func badJSONFields(typ reflect.Type, path string, seen map[reflect.Type]bool) []string {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+	// types that encode themselves, such as time.Time, decide their own form
+	if typ.Kind() != reflect.Struct || seen[typ] ||
+		typ.Implements(jsonMarshaler) || reflect.PtrTo(typ).Implements(jsonMarshaler) {
+		return nil
+	}
+	seen[typ] = true
+	defer delete(seen, typ)
+
+	var bad []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		// an untagged embedded struct has its fields promoted into the parent
+		if field.Anonymous && name == "" {
+			bad = append(bad, badJSONFields(field.Type, path, seen)...)
+			continue
+		}
+
+		fieldPath := path + "." + field.Name
+		if !snakeCase.MatchString(name) {
+			bad = append(bad, fieldPath)
+			continue
+		}
+		bad = append(bad, badJSONFields(field.Type, fieldPath, seen)...)
+	}
+	return bad
+}
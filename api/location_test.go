@@ -0,0 +1,144 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationHeader(t *testing.T) {
+	user, _ := randomUser(t)
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(user.Username)
+	account1.Currency = "USD"
+	account2.Currency = "USD"
+
+	transfer := randomTransfer()
+	transfer.FromAccountID = account1.ID
+	transfer.ToAccountID = account2.ID
+	transfer.Amount = 10
+	transferBody := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          transfer.Amount,
+		"currency":        "USD",
+	}
+
+	testCases := []struct {
+		name       string
+		url        string
+		body       gin.H
+		buildStubs func(store *mockdb.MockStore)
+		status     int
+		location   string
+	}{
+		{
+			name: "AccountCreated",
+			url:  "/accounts",
+			body: gin.H{"currency": account1.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().OpenAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.OpenAccountTxResult{Account: account1}, nil)
+			},
+			status:   http.StatusCreated,
+			location: fmt.Sprintf("/accounts/%d", account1.ID),
+		},
+		{
+			name: "AccountCreationFails",
+			url:  "/accounts",
+			body: gin.H{"currency": account1.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().OpenAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.OpenAccountTxResult{}, sql.ErrConnDone)
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name: "InvalidAccount",
+			url:  "/accounts",
+			body: gin.H{"currency": "XYZ"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().OpenAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "TransferCreated",
+			url:  "/transfers",
+			body: transferBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{Transfer: transfer}, nil)
+			},
+			status:   http.StatusCreated,
+			location: "/transfers/ref/" + transfer.Reference,
+		},
+		{
+			name: "TransferRejected",
+			url:  "/transfers",
+			body: transferBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrAccountFrozen)
+				store.EXPECT().CreateAuditLog(gomock.Any(), gomock.Any()).AnyTimes()
+			},
+			status: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, tc.url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code)
+			if tc.location == "" {
+				require.Empty(t, recorder.Header().Values("Location"))
+				return
+			}
+			require.Equal(t, tc.location, recorder.Header().Get("Location"))
+
+			// the header must lead back to the resource in the body
+			var body struct {
+				ID       int64 `json:"id"`
+				Transfer struct {
+					Reference string `json:"reference"`
+				} `json:"transfer"`
+			}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			if body.Transfer.Reference != "" {
+				require.Equal(t, "/transfers/ref/"+body.Transfer.Reference, tc.location)
+			} else {
+				require.Equal(t, fmt.Sprintf("/accounts/%d", body.ID), tc.location)
+			}
+		})
+	}
+}
@@ -1,13 +1,98 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/mail"
+	"github.com/qwerqy/mock_bank/token"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
 )
 
+// testTokenSymmetricKey keeps tokens from one test run comparable with the
+// next.
+const testTokenSymmetricKey = "01234567890123456789012345678901"
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	gin.SetMode(gin.TestMode)
+
+	config := util.Config{
+		TokenSymmetricKey:    testTokenSymmetricKey,
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Minute,
+	}
+
+	server, err := NewServer(config, store, mail.NoopSender{})
+	require.NoError(t, err)
+
+	return server
+}
+
+// newTestServerWithAuth is newTestServer plus a function returning a valid
+// Authorization header value for a user, for tests that only need to get
+// past the auth middleware.
+func newTestServerWithAuth(t *testing.T, store db.Store) (*Server, func(username, role string) string) {
+	server := newTestServer(t, store)
+
+	authHeader := func(username, role string) string {
+		accessToken, _, err := server.tokenMaker.CreateToken(username, role, uuid.New(), token.AccessToken, time.Minute)
+		require.NoError(t, err)
+		return fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken)
+	}
+
+	return server, authHeader
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
 }
+
+func TestNewTestServerWithAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	server, authHeader := newTestServerWithAuth(t, store)
+	require.Equal(t, gin.TestMode, gin.Mode())
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+	require.NoError(t, err)
+
+	request.Header.Set(authorizationHeaderKey, authHeader(user.Username, user.Role))
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	requireBodyMatchAccount(t, recorder.Body, account)
+}
+
+func TestNewServerDefaultCurrency(t *testing.T) {
+	config := util.Config{
+		TokenSymmetricKey: testTokenSymmetricKey,
+		AutoCreateAccount: true,
+		DefaultCurrency:   "XYZ",
+	}
+
+	_, err := NewServer(config, nil, nil)
+	require.Error(t, err)
+
+	// the currency does not matter while accounts are not auto-created
+	config.AutoCreateAccount = false
+	_, err = NewServer(config, nil, nil)
+	require.NoError(t, err)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maintenanceRetryAfter is the number of seconds clients are told to wait
+// before retrying a write rejected during maintenance.
+const maintenanceRetryAfter = 300
+
+// maintenanceExemptRoutes stay writable during maintenance, so that users
+// can still sign in and an admin can switch maintenance off again.
+var maintenanceExemptRoutes = map[string]bool{
+	"/users/login":         true,
+	"/users/logout":        true,
+	"/tokens/renew_access": true,
+	"/admin/maintenance":   true,
+}
+
+var errMaintenance = errors.New("the service is under maintenance, only reads are allowed")
+
+// maintenanceMode is a flag that can be flipped while requests are in
+// flight.
+type maintenanceMode struct {
+	enabled int32
+}
+
+func (mode *maintenanceMode) set(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&mode.enabled, value)
+}
+
+func (mode *maintenanceMode) on() bool {
+	return atomic.LoadInt32(&mode.enabled) == 1
+}
+
+// maintenanceMiddleware rejects writes with 503 while maintenance mode is
+// on. Reads keep working.
+func (server *Server) maintenanceMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !server.maintenance.on() || maintenanceExemptRoutes[ctx.FullPath()] {
+			ctx.Next()
+			return
+		}
+
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("Retry-After", strconv.Itoa(maintenanceRetryAfter))
+		ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, errorResponse(errMaintenance))
+	}
+}
+
+type maintenanceResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// @Summary     Show whether maintenance mode is on (admin only)
+// @Tags        admin
+// @Produce     json
+// @Success     200 {object} api.maintenanceResponse
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/maintenance [get]
+func (server *Server) getMaintenance(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, maintenanceResponse{Enabled: server.maintenance.on()})
+}
+
+type setMaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// @Summary     Switch maintenance mode on or off (admin only)
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Param       request body api.setMaintenanceRequest true "Whether writes are rejected"
+// @Success     200 {object} api.maintenanceResponse
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/maintenance [put]
+func (server *Server) setMaintenance(ctx *gin.Context) {
+	var req setMaintenanceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	server.maintenance.set(*req.Enabled)
+	ctx.JSON(http.StatusOK, maintenanceResponse{Enabled: server.maintenance.on()})
+}
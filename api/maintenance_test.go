@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		maintenance   bool
+		method        string
+		url           string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "WriteBlocked",
+			maintenance: true,
+			method:      http.MethodPost,
+			url:         "/accounts",
+			body:        gin.H{"currency": account.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().OpenAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.Equal(t, fmt.Sprint(maintenanceRetryAfter), recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name:        "ReadAllowed",
+			maintenance: true,
+			method:      http.MethodGet,
+			url:         fmt.Sprintf("/accounts/%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:   "WriteAllowedWhenOff",
+			method: http.MethodPost,
+			url:    "/accounts",
+			body:   gin.H{"currency": account.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().OpenAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.OpenAccountTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Empty(t, recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name:   "ReadAllowedWhenOff",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/accounts/%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.maintenance.set(tc.maintenance)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				err := json.NewEncoder(&body).Encode(tc.body)
+				require.NoError(t, err)
+			}
+
+			request, err := http.NewRequest(tc.method, tc.url, &body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestSetMaintenanceAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().OpenAccountTx(gomock.Any(), gomock.Any()).Times(0)
+	server := newTestServer(t, store)
+
+	send := func(method, url, role string, body gin.H) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			err := json.NewEncoder(&buf).Encode(body)
+			require.NoError(t, err)
+		}
+
+		request, err := http.NewRequest(method, url, &buf)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.RandomOwner(), role, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// only admins may flip the switch
+	recorder := send(http.MethodPut, "/admin/maintenance", util.DepositorRole, gin.H{"enabled": true})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.False(t, server.maintenance.on())
+
+	recorder = send(http.MethodPut, "/admin/maintenance", util.AdminRole, gin.H{})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodPut, "/admin/maintenance", util.AdminRole, gin.H{"enabled": true})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"enabled":true}`, recorder.Body.String())
+
+	recorder = send(http.MethodGet, "/admin/maintenance", util.AdminRole, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"enabled":true}`, recorder.Body.String())
+
+	recorder = send(http.MethodPost, "/accounts", util.DepositorRole, gin.H{"currency": util.RandomCurrency()})
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	// the toggle itself stays writable so maintenance can be ended
+	recorder = send(http.MethodPut, "/admin/maintenance", util.AdminRole, gin.H{"enabled": false})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.False(t, server.maintenance.on())
+}
@@ -0,0 +1,19 @@
+package api
+
+// Listing endpoints take page_id and page_size. Both may be left out, in
+// which case the first page of DefaultPageSize items is returned; values the
+// client does send are still validated.
+const (
+	fallbackPageSize = 20
+	maxPageSize      = 100
+)
+
+// defaultPage is what a listing request starts from before its query is
+// bound, so that only the parameters the client sent replace it.
+func (server *Server) defaultPage() (pageID int32, pageSize int32) {
+	pageSize = server.config.DefaultPageSize
+	if pageSize <= 0 {
+		pageSize = fallbackPageSize
+	}
+	return 1, pageSize
+}
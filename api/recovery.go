@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+var errInternal = errors.New("internal server error")
+
+// requestIDMiddleware tags every request with an ID, keeping the one a proxy
+// in front already assigned, and echoes it back so clients can quote it.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(requestIDHeader, requestID)
+		ctx.Next()
+	}
+}
+
+// recoveryMiddleware replaces gin's recovery. A panicking handler is logged
+// with its stack trace and request ID, and the client only gets a plain 500
+// error, never the trace itself. It must run after requestIDMiddleware.
+func recoveryMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// net/http's way of aborting a response on purpose
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			log.Printf("panic serving %s %s (request %s): %v\n%s",
+				ctx.Request.Method, ctx.Request.URL.Path, ctx.GetString(requestIDKey), recovered, debug.Stack())
+
+			// once the response has started it can only be cut short
+			if ctx.Writer.Written() {
+				ctx.Abort()
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(errInternal))
+		}()
+
+		ctx.Next()
+	}
+}
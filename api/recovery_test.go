@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	testCases := []struct {
+		name           string
+		requestID      string
+		acceptEncoding string
+		checkResponse  func(t *testing.T, recorder *httptest.ResponseRecorder, logged string)
+	}{
+		{
+			name: "GeneratedRequestID",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, logged string) {
+				requestID := recorder.Header().Get(requestIDHeader)
+				require.NotEmpty(t, requestID)
+				require.Contains(t, logged, "(request "+requestID+")")
+			},
+		},
+		{
+			name:      "ForwardedRequestID",
+			requestID: "req-123",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, logged string) {
+				require.Equal(t, "req-123", recorder.Header().Get(requestIDHeader))
+				require.Contains(t, logged, "(request req-123)")
+			},
+		},
+		{
+			name:           "Gzip",
+			acceptEncoding: "gzip",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, logged string) {
+				require.Empty(t, recorder.Header().Get("Content-Encoding"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var logged bytes.Buffer
+			log.SetOutput(&logged)
+			defer log.SetOutput(os.Stderr)
+
+			server := newTestServer(t, mockdb.NewMockStore(ctrl))
+			server.router.GET("/panic", func(ctx *gin.Context) {
+				panic("handler bug")
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/panic", nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(requestIDHeader, tc.requestID)
+			}
+			if tc.acceptEncoding != "" {
+				server.config.GzipMinBytes = 1024
+				request.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			requireErrorBody(t, recorder, errInternal.Error())
+			require.NotContains(t, recorder.Body.String(), "handler bug")
+
+			require.Contains(t, logged.String(), "panic serving GET /panic")
+			require.Contains(t, logged.String(), "handler bug")
+			require.Contains(t, logged.String(), "goroutine ")
+			tc.checkResponse(t, recorder, logged.String())
+		})
+	}
+}
+
+func TestRecoveryMiddlewareAfterWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	server.router.GET("/panic", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "partial")
+		panic("handler bug")
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/panic", nil)
+	require.NoError(t, err)
+
+	// the status is already out, so nothing is appended to the body
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "partial", recorder.Body.String())
+	require.Contains(t, logged.String(), "handler bug")
+}
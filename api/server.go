@@ -1,33 +1,205 @@
 package api
 
 import (
+	"fmt"
+	"net"
+
 	"github.com/gin-gonic/gin"
 	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/mail"
+	"github.com/qwerqy/mock_bank/token"
+	"github.com/qwerqy/mock_bank/util"
 )
 
 type Server struct {
-	store  db.Store
-	router *gin.Engine
+	config      util.Config
+	store       db.Store
+	tokenMaker  token.Maker
+	mailer      mail.Sender
+	features    featureFlags
+	maintenance maintenanceMode
+	// adminAllowlist is where clients of the /admin routes may connect
+	// from. Empty allows everyone.
+	adminAllowlist []*net.IPNet
+	// trustedProxies are the peers whose X-Forwarded-For is believed.
+	trustedProxies []*net.IPNet
+	router         *gin.Engine
 }
 
-func NewServer(store db.Store) *Server {
-	server := &Server{store: store}
-	router := gin.Default()
+// NewServer serves the API on top of store. Handlers send email through
+// mailer while the request waits, so it should only queue the email, as
+// worker.MailWorker does.
+func NewServer(config util.Config, store db.Store, mailer mail.Sender) (*Server, error) {
+	verificationKeys := make([]token.Key, 0, len(config.TokenVerificationKeys))
+	for _, entry := range config.TokenVerificationKeys {
+		key, err := token.ParseKey(entry)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse token verification key: %w", err)
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	primaryKey := token.Key{ID: config.TokenKeyID, Secret: config.TokenSymmetricKey}
+	tokenMaker, err := token.NewMakerWithKeys(config.TokenType, primaryKey, verificationKeys)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	if config.AutoCreateAccount && !util.IsSupportedCurrency(config.DefaultCurrency) {
+		return nil, fmt.Errorf("unsupported default currency %q", config.DefaultCurrency)
+	}
+
+	if config.DefaultPageSize > maxPageSize {
+		return nil, fmt.Errorf("default page size %d is above the maximum of %d", config.DefaultPageSize, maxPageSize)
+	}
+
+	if err := validAccountIDMode(config.AccountIDMode); err != nil {
+		return nil, err
+	}
+
+	adminAllowlist, err := parseCIDRs(config.AdminAllowedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse admin allowlist: %w", err)
+	}
+
+	trustedProxies, err := parseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse trusted proxies: %w", err)
+	}
+
+	server := &Server{
+		config:         config,
+		store:          store,
+		tokenMaker:     tokenMaker,
+		mailer:         mailer,
+		features:       newFeatureFlags(config.DisabledFeatures),
+		adminAllowlist: adminAllowlist,
+		trustedProxies: trustedProxies,
+	}
+	server.maintenance.set(config.MaintenanceMode)
+
+	registerJSONFieldNames()
+	registerValidations()
+	server.setupRouter()
+	return server, nil
+}
+
+func (server *Server) setupRouter() {
+	router := gin.New()
+	router.Use(gin.Logger(), requestIDMiddleware(), recoveryMiddleware())
+	// gin trusts every proxy unless told otherwise; it reads these when the
+	// server starts
+	router.TrustedProxies = make([]string, 0, len(server.trustedProxies))
+	for _, proxy := range server.trustedProxies {
+		router.TrustedProxies = append(router.TrustedProxies, proxy.String())
+	}
+
+	// without this gin answers a path served for other methods with 404
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(server.noRoute)
+	router.NoMethod(server.noMethod)
+
+	router.Use(server.gzipMiddleware())
+	router.Use(server.featureMiddleware())
+	router.Use(server.dbTimeoutMiddleware())
+	router.Use(server.bodyLimitMiddleware())
+	router.Use(server.maintenanceMiddleware())
+	router.Use(server.accountIDMiddleware())
 
-	router.POST("/accounts", server.createAccount)
-	router.GET("/accounts/:id", server.getAccount)
-	router.GET("/accounts", server.listAccounts)
-	router.PUT("/accounts/:id", server.updateAccount)
-	router.DELETE("/accounts/:id", server.deleteAccount)
+	router.GET("/swagger/*any", serveSwagger)
+	router.GET("/readyz", server.readyz)
+	router.GET("/currencies", server.listCurrencies)
+
+	router.POST("/users", server.createUser)
+	router.POST("/users/login", server.loginUser)
+	router.GET("/users/verify", server.verifyEmail)
+	router.POST("/tokens/renew_access", server.renewAccessToken)
+
+	// account routes take a public_id in place of the account ID in UUID mode
+	accountID := server.accountIDParamMiddleware()
+
+	// WebSocket clients pass their access token as a query parameter
+	router.GET("/ws/accounts/:id", server.streamAccountBalance)
+
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker), server.accountIDRequestMiddleware())
+
+	authRoutes.POST("/users/logout", server.logoutUser)
+	authRoutes.GET("/users/me", server.getCurrentUser)
+	authRoutes.PUT("/users/me/password", server.changePassword)
+
+	authRoutes.POST("/accounts", server.createAccount)
+	authRoutes.GET("/accounts/:id", accountID, server.getAccount)
+	authRoutes.GET("/accounts", server.listAccounts)
+	authRoutes.PUT("/accounts/:id", authorizeRole(util.AdminRole), accountID, server.updateAccount)
+	authRoutes.PATCH("/accounts/:id", accountID, server.patchAccount)
+	authRoutes.DELETE("/accounts/:id", accountID, server.deleteAccount)
+	authRoutes.POST("/accounts/:id/freeze", authorizeRole(util.AdminRole), accountID, server.freezeAccount)
+	authRoutes.POST("/accounts/:id/unfreeze", authorizeRole(util.AdminRole), accountID, server.unfreezeAccount)
+	authRoutes.POST("/accounts/:id/close", accountID, server.closeAccount)
+	authRoutes.GET("/accounts/:id/status-history", accountID, server.listAccountStatusHistory)
+	authRoutes.GET("/accounts/:id/transfers/largest", accountID, server.listLargestTransfers)
+	authRoutes.GET("/accounts/:id/fees", accountID, server.getAccountFees)
+	authRoutes.GET("/accounts/:id/entries", accountID, server.listEntries)
+	authRoutes.GET("/accounts/:id/entries/stream", accountID, server.streamEntries)
+	authRoutes.POST("/accounts/:id/labels", accountID, server.addAccountLabel)
+	authRoutes.GET("/accounts/:id/labels", accountID, server.listAccountLabels)
+	authRoutes.DELETE("/accounts/:id/labels/:label", accountID, server.removeAccountLabel)
+	authRoutes.GET("/wallet", server.getWallet)
+
+	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/transfers/preview", server.previewTransfer)
+	authRoutes.POST("/transfers/split", server.createSplitTransfer)
+	authRoutes.GET("/transfers", server.listTransfers)
+	authRoutes.GET("/transfers/all", server.listAllTransfers)
+	authRoutes.POST("/transfers/async", requireFeature(featureAsyncTransfers), server.createAsyncTransfer)
+	authRoutes.POST("/transfers/schedule", server.scheduleTransfer)
+	authRoutes.GET("/transfers/scheduled/:id", server.getScheduledTransfer)
+	authRoutes.POST("/transfers/scheduled/:id/cancel", server.cancelScheduledTransfer)
+	authRoutes.POST("/transfers/authorize", server.authorizeTransfer)
+	authRoutes.POST("/transfers/capture", server.captureHold)
+	authRoutes.POST("/transfers/void", server.voidHold)
+	authRoutes.GET("/transfers/jobs/:id", requireFeature(featureAsyncTransfers), server.getTransferJob)
+	authRoutes.GET("/transfers/ref/:reference", server.getTransferByReference)
+	authRoutes.POST("/transfers/:id/reverse", server.reverseTransfer)
+	authRoutes.POST("/transfers/:id/approve", authorizeRole(util.BankerRole, util.AdminRole), server.approveTransfer)
+	authRoutes.POST("/transfers/:id/attachments", server.uploadTransferAttachment)
+	authRoutes.GET("/transfers/:id/attachments/:attachment_id", server.getTransferAttachment)
+
+	authRoutes.GET("/audit/transfers", authorizeRole(util.AdminRole), server.listTransferAuditLogs)
+
+	// the allowlist runs first, so clients outside it learn nothing more
+	adminRoutes := router.Group("/admin").Use(
+		ipAllowlistMiddleware(server.adminAllowlist, server.trustedProxies),
+		authMiddleware(server.tokenMaker),
+		authorizeRole(util.AdminRole),
+		server.accountIDRequestMiddleware(),
+	)
+
+	adminRoutes.POST("/accounts/import", server.importAccounts)
+	adminRoutes.GET("/accounts/search", server.searchAccounts)
+	adminRoutes.GET("/accounts/metadata", server.listAccountsByMetadataKey)
+	adminRoutes.POST("/accounts/:id/transfer-ownership", accountID, server.transferAccountOwnership)
+	adminRoutes.GET("/accounts/:id/reconcile", accountID, server.reconcileAccount)
+	adminRoutes.POST("/accounts/:id/adjust", accountID, server.adjustBalance)
+	adminRoutes.PUT("/accounts/:id/limits", accountID, server.setAccountLimits)
+	adminRoutes.GET("/jobs/failed", server.listFailedTransferJobs)
+	adminRoutes.POST("/jobs/:id/retry", server.retryTransferJob)
+	adminRoutes.GET("/maintenance", server.getMaintenance)
+	adminRoutes.PUT("/maintenance", server.setMaintenance)
 
 	server.router = router
-	return server
 }
 
 func (server *Server) Start(address string) error {
 	return server.router.Run(address)
 }
 
+// setLocation points the client at a resource the request created, so it can
+// follow up with a GET.
+func setLocation(ctx *gin.Context, path string) {
+	ctx.Header("Location", path)
+}
+
 func errorResponse(err error) gin.H {
 	return gin.H{"error": err.Error()}
 }
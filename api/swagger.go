@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qwerqy/mock_bank/docs"
+)
+
+// swaggerUI loads Swagger UI from a CDN and points it at the spec served
+// next to it.
+const swaggerUI = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Mock Bank API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@4/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@4/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({url: "doc.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>`
+
+// serveSwagger serves the spec at /swagger/doc.json and the UI for any other
+// path below /swagger/.
+func serveSwagger(ctx *gin.Context) {
+	if ctx.Param("any") == "/doc.json" {
+		ctx.Data(http.StatusOK, "application/json; charset=utf-8", docs.SwaggerJSON)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUI))
+}
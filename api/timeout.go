@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const clientContextKey = "client_context"
+
+// dbTimeoutMiddleware bounds how long the store calls of a request may take.
+// Handlers pass ctx.Request.Context() to the store, so the deadline reaches
+// the database driver.
+func (server *Server) dbTimeoutMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(clientContextKey, ctx.Request.Context())
+
+		timeout := server.config.DBTimeout
+		if timeout <= 0 {
+			ctx.Next()
+			return
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+
+		ctx.Request = ctx.Request.WithContext(timeoutCtx)
+		ctx.Writer = &timeoutWriter{ResponseWriter: ctx.Writer, ctx: timeoutCtx}
+		ctx.Next()
+	}
+}
+
+// timeoutWriter turns the 500 a handler writes for a failed store call into a
+// 504 when the call failed because the request ran out of time.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx context.Context
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if code == http.StatusInternalServerError && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		code = http.StatusGatewayTimeout
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// clientContext is the request's context without the store deadline. It is
+// only done once the client goes away, which is what a handler streaming a
+// response for longer than the deadline waits for.
+func clientContext(ctx *gin.Context) context.Context {
+	if clientCtx, ok := ctx.Get(clientContextKey); ok {
+		return clientCtx.(context.Context)
+	}
+	return ctx.Request.Context()
+}
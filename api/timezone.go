@@ -0,0 +1,39 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	// embeds the IANA database so ?tz= works in images without zoneinfo
+	_ "time/tzdata"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+// responseLocation reads the optional tz query parameter of the history
+// endpoints. Timestamps are rendered in UTC unless it names an IANA time
+// zone; anything else is answered with 400 and reported as false.
+func responseLocation(ctx *gin.Context) (*time.Location, bool) {
+	name := ctx.Query("tz")
+	if name == "" {
+		return time.UTC, true
+	}
+
+	// "Local" would leak whatever zone the server happens to run in
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("unknown time zone %q", name)))
+		return nil, false
+	}
+	return loc, true
+}
+
+// transfersIn moves the timestamps of transfers into loc, in place.
+func transfersIn(transfers []db.Transfer, loc *time.Location) []db.Transfer {
+	for i := range transfers {
+		transfers[i].CreatedAt = transfers[i].CreatedAt.In(loc)
+	}
+	return transfers
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryTimezone(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	// as a driver in a non-UTC session would hand it back
+	createdAt := time.Date(2024, 3, 1, 10, 30, 0, 0, time.FixedZone("", 5*60*60))
+
+	entry := randomEntry(account.ID, db.EntryTypeFee)
+	entry.CreatedAt = createdAt
+
+	transfer := randomTransfer()
+	transfer.FromAccountID = account.ID
+	transfer.CreatedAt = createdAt
+
+	testCases := []struct {
+		name          string
+		path          string
+		buildStubs    func(store *mockdb.MockStore)
+		wantCode      int
+		wantCreatedAt string
+	}{
+		{
+			name: "EntriesUTCByDefault",
+			path: fmt.Sprintf("/accounts/%d/entries", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntry(gomock.Any(), gomock.Any()).Times(1).Return([]db.Entry{entry}, nil)
+			},
+			wantCode:      http.StatusOK,
+			wantCreatedAt: "2024-03-01T05:30:00Z",
+		},
+		{
+			name: "EntriesInTimezone",
+			path: fmt.Sprintf("/accounts/%d/entries?tz=Asia/Kuala_Lumpur", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntry(gomock.Any(), gomock.Any()).Times(1).Return([]db.Entry{entry}, nil)
+			},
+			wantCode:      http.StatusOK,
+			wantCreatedAt: "2024-03-01T13:30:00+08:00",
+		},
+		{
+			name: "TransfersUTCByDefault",
+			path: fmt.Sprintf("/transfers?account_id=%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListTransfer(gomock.Any(), gomock.Any()).Times(1).Return([]db.Transfer{transfer}, nil)
+			},
+			wantCode:      http.StatusOK,
+			wantCreatedAt: "2024-03-01T05:30:00Z",
+		},
+		{
+			name: "TransfersInTimezone",
+			path: fmt.Sprintf("/transfers?account_id=%d&tz=America/New_York", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListTransfer(gomock.Any(), gomock.Any()).Times(1).Return([]db.Transfer{transfer}, nil)
+			},
+			wantCode:      http.StatusOK,
+			wantCreatedAt: "2024-03-01T00:30:00-05:00",
+		},
+		{
+			name: "UnknownTimezone",
+			path: fmt.Sprintf("/accounts/%d/entries?tz=Mars/Olympus_Mons", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListEntry(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "LocalTimezone",
+			path: fmt.Sprintf("/transfers?account_id=%d&tz=Local", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.wantCode, recorder.Code)
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			// compare the raw strings, since decoding into time.Time would
+			// hide the offset
+			var items []map[string]interface{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &items))
+			require.Len(t, items, 1)
+			require.Equal(t, tc.wantCreatedAt, items[0]["created_at"])
+		})
+	}
+}
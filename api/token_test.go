@@ -0,0 +1,220 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		refreshToken  func(t *testing.T, tokenMaker token.Maker) string
+		buildStubs    func(store *mockdb.MockStore, refreshToken string)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) string {
+				return createRefreshToken(t, tokenMaker, user, sessionID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(db.Session{
+					ID:           sessionID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    time.Now().Add(time.Minute),
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEmpty(t, rsp.AccessToken)
+			},
+		},
+		{
+			name: "BlockedSession",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) string {
+				return createRefreshToken(t, tokenMaker, user, sessionID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(db.Session{
+					ID:           sessionID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					IsBlocked:    true,
+					ExpiresAt:    time.Now().Add(time.Minute),
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MismatchedToken",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) string {
+				return createRefreshToken(t, tokenMaker, user, sessionID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(db.Session{
+					ID:           sessionID,
+					Username:     user.Username,
+					RefreshToken: "another token",
+					ExpiresAt:    time.Now().Add(time.Minute),
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) string {
+				return createRefreshToken(t, tokenMaker, user, sessionID, -time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "AccessToken",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) string {
+				accessToken, _, err := tokenMaker.CreateToken(user.Username, user.Role, sessionID, token.AccessToken, time.Minute)
+				require.NoError(t, err)
+				return accessToken
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireErrorBody(t, recorder, errNotRefreshToken.Error())
+			},
+		},
+		{
+			name: "SessionNotFound",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) string {
+				return createRefreshToken(t, tokenMaker, user, sessionID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(db.Session{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) string {
+				return createRefreshToken(t, tokenMaker, user, sessionID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			refreshToken := tc.refreshToken(t, server.tokenMaker)
+			tc.buildStubs(store, refreshToken)
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestRenewAccessTokenAfterLogout logs out with an access token and then
+// tries to renew with the refresh token of the same session.
+func TestRenewAccessTokenAfterLogout(t *testing.T) {
+	user, _ := randomUser(t)
+	sessionID := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	refreshToken := createRefreshToken(t, server.tokenMaker, user, sessionID, time.Minute)
+	session := db.Session{
+		ID:           sessionID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+
+	store.EXPECT().BlockSession(gomock.Any(), gomock.Eq(sessionID)).Times(1).DoAndReturn(
+		func(_ context.Context, _ uuid.UUID) (db.Session, error) {
+			session.IsBlocked = true
+			return session, nil
+		})
+	store.EXPECT().GetSession(gomock.Any(), gomock.Eq(sessionID)).Times(1).DoAndReturn(
+		func(_ context.Context, _ uuid.UUID) (db.Session, error) {
+			return session, nil
+		})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/users/logout", nil)
+	require.NoError(t, err)
+	addSessionAuthorization(t, request, server.tokenMaker, user, sessionID)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.True(t, session.IsBlocked)
+
+	data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+	require.NoError(t, err)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func createRefreshToken(t *testing.T, tokenMaker token.Maker, user db.User, sessionID uuid.UUID, duration time.Duration) string {
+	refreshToken, _, err := tokenMaker.CreateToken(user.Username, user.Role, sessionID, token.RefreshToken, duration)
+	require.NoError(t, err)
+	return refreshToken
+}
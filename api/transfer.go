@@ -0,0 +1,709 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+	"github.com/qwerqy/mock_bank/util"
+)
+
+const minTransferDescriptionLength = 3
+
+type transferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Description   string `json:"description" binding:"max=140"`
+}
+
+type createTransferQuery struct {
+	// Force makes a transfer even when an identical one was just made.
+	Force bool `form:"force"`
+}
+
+// @Summary     Transfer money between two accounts
+// @Tags        transfers
+// @Accept      json
+// @Produce     json
+// @Param       request body api.transferRequest true "Transfer to make"
+// @Param       force query boolean false "Make the transfer even if an identical one was just made"
+// @Success     201 {object} db.TransferTxResult "Transfer executed"
+// @Header      201 {string} Location "Path of the new transfer"
+// @Success     202 {object} db.PendingApproval "Transfer held for approval"
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string "Identical transfer made moments ago, its reference is included"
+// @Failure     422 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers [post]
+func (server *Server) createTransfer(ctx *gin.Context) {
+	var query createTransferQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	req.Description = cleanDescription(req.Description)
+	if !server.validDescription(ctx, req.Description) {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if !server.validAmount(ctx, req.Amount, req.Currency, server.maxTransferAmount(fromAccount)) {
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	if !query.Force && server.duplicateTransfer(ctx, req) {
+		return
+	}
+
+	if server.requiresApproval(req.Amount) {
+		server.holdTransferForApproval(ctx, req, authPayload.Username)
+		return
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		Currency:      req.Currency,
+	}
+
+	result, err := server.store.TransferTx(ctx.Request.Context(), arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed), errors.Is(err, db.ErrDailyLimitExceeded), errors.Is(err, db.ErrBalanceCapExceeded):
+			server.auditRejectedTransfer(ctx, authPayload.Username, arg, err)
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case errors.Is(err, db.ErrCurrencyMismatch), errors.Is(err, db.ErrInvalidAmount):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		case errors.Is(err, db.ErrRecordNotFound), db.ErrorCode(err) == db.ErrForeignKeyViolation:
+			// an account was deleted after it was validated above
+			ctx.JSON(http.StatusNotFound, errorResponse(missingTransferAccount(ctx, arg, err)))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	setLocation(ctx, "/transfers/ref/"+url.PathEscape(result.Transfer.Reference))
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// @Summary     Queue a transfer for background processing
+// @Tags        transfers
+// @Accept      json
+// @Produce     json
+// @Param       request body api.transferRequest true "Transfer to queue"
+// @Success     202 {object} db.TransferJob "Transfer queued, or held for approval as a db.PendingApproval"
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/async [post]
+func (server *Server) createAsyncTransfer(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	req.Description = cleanDescription(req.Description)
+	if !server.validDescription(ctx, req.Description) {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if !server.validAmount(ctx, req.Amount, req.Currency, server.maxTransferAmount(fromAccount)) {
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	if server.requiresApproval(req.Amount) {
+		server.holdTransferForApproval(ctx, req, authPayload.Username)
+		return
+	}
+
+	arg := db.CreateTransferJobParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Description:   req.Description,
+	}
+
+	job, err := server.store.CreateTransferJob(ctx.Request.Context(), arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, job)
+}
+
+type reverseTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// @Summary     Reverse a transfer
+// @Tags        transfers
+// @Produce     json
+// @Param       id path integer true "Transfer ID"
+// @Success     200 {object} db.TransferTxResult
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     422 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/{id}/reverse [post]
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var req reverseTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// only the original sender or an admin may take money back
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.AdminRole {
+		transfer, err := server.store.GetTransfer(ctx.Request.Context(), req.ID)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				ctx.JSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		fromAccount, err := server.store.GetAccount(ctx.Request.Context(), transfer.FromAccountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if fromAccount.Owner != authPayload.Username {
+			err := errors.New("only the sender or an admin may reverse a transfer")
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx.Request.Context(), req.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrTransferAlreadyReversed):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed), errors.Is(err, db.ErrBalanceCapExceeded):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type getTransferJobRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// @Summary     Get a queued transfer job
+// @Tags        transfers
+// @Produce     json
+// @Param       id path integer true "Transfer job ID"
+// @Success     200 {object} db.TransferJob
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/jobs/{id} [get]
+func (server *Server) getTransferJob(ctx *gin.Context) {
+	var req getTransferJobRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	job, err := server.store.GetTransferJob(ctx.Request.Context(), req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !server.accessibleAccount(ctx, job.FromAccountID) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, job)
+}
+
+type getTransferByReferenceRequest struct {
+	Reference string `uri:"reference" binding:"required"`
+}
+
+// @Summary     Get a transfer by its reference
+// @Tags        transfers
+// @Produce     json
+// @Param       reference path string true "Transfer reference, such as TRX-20240101-7KQ2ZD"
+// @Success     200 {object} db.Transfer
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/ref/{reference} [get]
+func (server *Server) getTransferByReference(ctx *gin.Context) {
+	var req getTransferByReferenceRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransferByReference(ctx.Request.Context(), strings.ToUpper(req.Reference))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !server.authorizedForTransfer(ctx, transfer) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfer)
+}
+
+const defaultLargestTransfersLimit = 10
+
+type listLargestTransfersUriRequest struct {
+	AccountID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listLargestTransfersQueryRequest struct {
+	Limit     int32     `form:"limit" binding:"omitempty,min=1,max=50"`
+	StartTime time.Time `form:"start_time"`
+	EndTime   time.Time `form:"end_time"`
+}
+
+// @Summary     List the largest transfers of an account
+// @Tags        transfers
+// @Produce     json
+// @Param       id path string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       limit query integer false "Maximum number of transfers, 10 by default"
+// @Param       start_time query string false "Start of the time range (RFC 3339)"
+// @Param       end_time query string false "End of the time range (RFC 3339), now by default"
+// @Success     200 {array} db.Transfer
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /accounts/{id}/transfers/largest [get]
+func (server *Server) listLargestTransfers(ctx *gin.Context) {
+	var uriReq listLargestTransfersUriRequest
+	var queryReq listLargestTransfersQueryRequest
+
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&queryReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if queryReq.Limit == 0 {
+		queryReq.Limit = defaultLargestTransfersLimit
+	}
+	if queryReq.EndTime.IsZero() {
+		queryReq.EndTime = time.Now()
+	}
+	if !queryReq.StartTime.Before(queryReq.EndTime) {
+		err := fmt.Errorf("start_time must be before end_time")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.accessibleAccount(ctx, uriReq.AccountID) {
+		return
+	}
+
+	arg := db.ListLargestTransfersParams{
+		AccountID: uriReq.AccountID,
+		StartTime: queryReq.StartTime,
+		EndTime:   queryReq.EndTime,
+		RowLimit:  queryReq.Limit,
+	}
+
+	transfers, err := server.store.ListLargestTransfers(ctx.Request.Context(), arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfers)
+}
+
+const (
+	directionIn  = "in"
+	directionOut = "out"
+)
+
+type listTransfersRequest struct {
+	AccountID int64  `form:"account_id" binding:"required,min=1"`
+	Direction string `form:"direction" binding:"omitempty,oneof=in out all"`
+	PageID    int32  `form:"page_id" binding:"min=1"`
+	PageSize  int32  `form:"page_size" binding:"min=1,max=100"`
+}
+
+// @Summary     List the transfers of an account
+// @Tags        transfers
+// @Produce     json
+// @Param       account_id query string true "Account ID, or its public_id when ACCOUNT_ID_MODE is uuid"
+// @Param       direction query string false "in, out or all (the default)"
+// @Param       page_id query integer false "Page number, starting at 1, the first by default"
+// @Param       page_size query integer false "Transfers per page, 1 to 100, the server default when left out"
+// @Param       tz query string false "IANA time zone to render timestamps in, UTC by default"
+// @Success     200 {array} db.Transfer
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers [get]
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	req.PageID, req.PageSize = server.defaultPage()
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	loc, ok := responseLocation(ctx)
+	if !ok {
+		return
+	}
+
+	if !server.accessibleAccount(ctx, req.AccountID) {
+		return
+	}
+
+	var transfers []db.Transfer
+	var err error
+	offset := (req.PageID - 1) * req.PageSize
+
+	switch req.Direction {
+	case directionIn:
+		transfers, err = server.store.ListTransfersTo(ctx.Request.Context(), db.ListTransfersToParams{
+			ToAccountID: req.AccountID,
+			Limit:       req.PageSize,
+			Offset:      offset,
+		})
+	case directionOut:
+		transfers, err = server.store.ListTransfersFrom(ctx.Request.Context(), db.ListTransfersFromParams{
+			FromAccountID: req.AccountID,
+			Limit:         req.PageSize,
+			Offset:        offset,
+		})
+	default:
+		// "all", which is also what an empty direction means
+		transfers, err = server.store.ListTransfer(ctx.Request.Context(), db.ListTransferParams{
+			FromAccountID: req.AccountID,
+			ToAccountID:   req.AccountID,
+			Limit:         req.PageSize,
+			Offset:        offset,
+		})
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfersIn(transfers, loc))
+}
+
+type listAllTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"min=1"`
+	PageSize int32 `form:"page_size" binding:"min=1,max=100"`
+}
+
+// @Summary     List the transfers of all the authenticated user's accounts, newest first
+// @Tags        transfers
+// @Produce     json
+// @Param       page_id query integer false "Page number, starting at 1, the first by default"
+// @Param       page_size query integer false "Transfers per page, 1 to 100, the server default when left out"
+// @Param       tz query string false "IANA time zone to render timestamps in, UTC by default"
+// @Success     200 {array} db.Transfer
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/all [get]
+func (server *Server) listAllTransfers(ctx *gin.Context) {
+	var req listAllTransfersRequest
+	req.PageID, req.PageSize = server.defaultPage()
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	loc, ok := responseLocation(ctx)
+	if !ok {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	accountIDs, err := server.store.ListAccountIDsByOwner(ctx.Request.Context(), authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(accountIDs) == 0 {
+		ctx.JSON(http.StatusOK, []db.Transfer{})
+		return
+	}
+
+	// a transfer between two of the user's accounts matches both IDs but is
+	// still a single row
+	transfers, err := server.store.ListTransfersForAccounts(ctx.Request.Context(), db.ListTransfersForAccountsParams{
+		AccountIds: accountIDs,
+		Limit:      req.PageSize,
+		Offset:     (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfersIn(transfers, loc))
+}
+
+// validAccount checks that the account exists and holds the given currency,
+// writing the error response itself when it does not.
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx.Request.Context(), accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	if account.Currency != currency {
+		err := fmt.Errorf("account [%s] currency mismatch: %s vs %s", accountRef(ctx, account.ID), account.Currency, currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// missingTransferAccount names the account whose foreign key a transfer
+// violated.
+func missingTransferAccount(ctx *gin.Context, arg db.TransferTxParams, err error) error {
+	switch db.ErrorConstraint(err) {
+	case "transfers_from_account_id_fkey":
+		return fmt.Errorf("from account [%s] not found", accountRef(ctx, arg.FromAccountID))
+	case "transfers_to_account_id_fkey":
+		return fmt.Errorf("to account [%s] not found", accountRef(ctx, arg.ToAccountID))
+	default:
+		return fmt.Errorf("account [%s] or [%s] not found", accountRef(ctx, arg.FromAccountID), accountRef(ctx, arg.ToAccountID))
+	}
+}
+
+// authorizedForTransfer reports whether the authenticated user sent or
+// received the transfer, or is an admin, writing the error response when not.
+func (server *Server) authorizedForTransfer(ctx *gin.Context, transfer db.Transfer) bool {
+	return server.authorizedForParties(ctx, "transfer", transfer.FromAccountID, transfer.ToAccountID)
+}
+
+// authorizedForParties reports whether the authenticated user owns one of the
+// accounts, or is an admin, writing the error response when not.
+func (server *Server) authorizedForParties(ctx *gin.Context, what string, accountIDs ...int64) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == util.AdminRole {
+		return true
+	}
+
+	for _, accountID := range accountIDs {
+		account, err := server.store.GetAccount(ctx.Request.Context(), accountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+		if account.Owner == authPayload.Username {
+			return true
+		}
+	}
+
+	err := fmt.Errorf("%s doesn't involve the authenticated user", what)
+	ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	return false
+}
+
+// cleanDescription drops control characters, such as newlines and escape
+// sequences, that have no place in a one-line memo.
+func cleanDescription(description string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, description)
+}
+
+// validDescription enforces the description requirement some deployments
+// turn on for compliance, writing the error response when it is not met.
+func (server *Server) validDescription(ctx *gin.Context, description string) bool {
+	if !server.config.RequireTransferDescription {
+		return true
+	}
+
+	if len(strings.TrimSpace(description)) < minTransferDescriptionLength {
+		err := fmt.Errorf("description of at least %d characters is required", minTransferDescriptionLength)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// maxTransferAmount is the most account may send in one transfer: its own
+// override when it has one, the configured maximum otherwise. Zero means no
+// maximum.
+func (server *Server) maxTransferAmount(account db.Account) int64 {
+	if account.MaxTransferOverride.Valid {
+		return account.MaxTransferOverride.Int64
+	}
+	return server.config.MaxTransferAmount
+}
+
+// duplicateTransfer answers 409 with the earlier transfer's reference when
+// the same amount went between the same accounts within the duplicate window,
+// which is most likely a double submit. It reports whether it wrote a
+// response. Two identical requests racing each other both get through.
+func (server *Server) duplicateTransfer(ctx *gin.Context, req transferRequest) bool {
+	window := server.config.DuplicateTransferWindow
+	if window <= 0 {
+		return false
+	}
+
+	transfer, err := server.store.FindRecentIdenticalTransfer(ctx.Request.Context(), db.FindRecentIdenticalTransferParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Since:         time.Now().Add(-window),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return true
+	}
+
+	ctx.JSON(http.StatusConflict, gin.H{
+		"error":     "an identical transfer was made moments ago, pass force=true to make it again",
+		"reference": transfer.Reference,
+	})
+	return true
+}
+
+// validAmount enforces the configured minimum and the sender's maximum per
+// transfer, writing the error response when the amount falls outside them.
+// The bounds are in minor units whatever the currency; errors show them
+// in the major unit of currency.
+func (server *Server) validAmount(ctx *gin.Context, amount int64, currency string, max int64) bool {
+	min := server.config.MinTransferAmount
+
+	format := func(amount int64) string {
+		return util.FormatAmount(amount, currency)
+	}
+
+	var err error
+	switch {
+	case amount <= 0:
+		err = fmt.Errorf("amount must be positive, got %s", format(amount))
+	case min > 0 && max > 0 && (amount < min || amount > max):
+		err = fmt.Errorf("amount %s is outside the allowed range of %s to %s", format(amount), format(min), format(max))
+	case min > 0 && amount < min:
+		err = fmt.Errorf("amount %s is below the minimum of %s", format(amount), format(min))
+	case max > 0 && amount > max:
+		err = fmt.Errorf("amount %s is above the maximum of %s", format(amount), format(max))
+	default:
+		return true
+	}
+
+	ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	return false
+}
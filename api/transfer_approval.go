@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+)
+
+// requiresApproval reports whether a transfer of the given amount has to be
+// held until a second user approves it.
+func (server *Server) requiresApproval(amount int64) bool {
+	threshold := server.config.TransferApprovalThreshold
+	return threshold > 0 && amount > threshold
+}
+
+// holdTransferForApproval records the transfer as pending approval instead of
+// executing it.
+func (server *Server) holdTransferForApproval(ctx *gin.Context, req transferRequest, initiatedBy string) {
+	arg := db.CreatePendingApprovalParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		InitiatedBy:   initiatedBy,
+	}
+
+	approval, err := server.store.CreatePendingApproval(ctx.Request.Context(), arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, approval)
+}
+
+type approveTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// @Summary     Approve a transfer held for approval (banker or admin)
+// @Tags        transfers
+// @Produce     json
+// @Param       id path integer true "Pending approval ID"
+// @Success     200 {object} db.ApproveTransferTxResult
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     422 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/{id}/approve [post]
+func (server *Server) approveTransfer(ctx *gin.Context) {
+	var req approveTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ApproveTransferTxParams{
+		ID:         req.ID,
+		ApprovedBy: authPayload.Username,
+	}
+
+	result, err := server.store.ApproveTransferTx(ctx.Request.Context(), arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrSelfApproval):
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		case errors.Is(err, db.ErrApprovalNotPending):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed), errors.Is(err, db.ErrDailyLimitExceeded), errors.Is(err, db.ErrBalanceCapExceeded):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+const maxAttachmentSize = 1 << 20 // 1 MiB
+
+var allowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+type transferAttachmentResponse struct {
+	ID          int64     `json:"id"`
+	TransferID  int64     `json:"transfer_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newTransferAttachmentResponse(attachment db.TransferAttachment) transferAttachmentResponse {
+	return transferAttachmentResponse{
+		ID:          attachment.ID,
+		TransferID:  attachment.TransferID,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        len(attachment.Data),
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
+
+type uploadTransferAttachmentRequest struct {
+	TransferID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// @Summary     Attach a receipt to a transfer
+// @Tags        transfers
+// @Accept      multipart/form-data
+// @Produce     json
+// @Param       id path integer true "Transfer ID"
+// @Param       file formData file true "PDF, JPEG or PNG of at most 1 MiB"
+// @Success     201 {object} api.transferAttachmentResponse
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/{id}/attachments [post]
+func (server *Server) uploadTransferAttachment(ctx *gin.Context) {
+	var req uploadTransferAttachmentRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if fileHeader.Size > maxAttachmentSize {
+		err := fmt.Errorf("attachment exceeds the maximum size of %d bytes", maxAttachmentSize)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the client supplied content type is not trusted, sniff the bytes instead
+	contentType := http.DetectContentType(data)
+	if !allowedAttachmentTypes[contentType] {
+		err := fmt.Errorf("unsupported attachment content type %s", contentType)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx.Request.Context(), req.TransferID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !server.authorizedForTransfer(ctx, transfer) {
+		return
+	}
+
+	arg := db.CreateTransferAttachmentParams{
+		TransferID:  req.TransferID,
+		Filename:    fileHeader.Filename,
+		ContentType: contentType,
+		Data:        data,
+	}
+
+	attachment, err := server.store.CreateTransferAttachment(ctx.Request.Context(), arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newTransferAttachmentResponse(attachment))
+}
+
+type getTransferAttachmentRequest struct {
+	TransferID   int64 `uri:"id" binding:"required,min=1"`
+	AttachmentID int64 `uri:"attachment_id" binding:"required,min=1"`
+}
+
+// @Summary     Download a transfer attachment
+// @Tags        transfers
+// @Produce     octet-stream
+// @Param       id path integer true "Transfer ID"
+// @Param       attachment_id path integer true "Attachment ID"
+// @Success     200 {file} file
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/{id}/attachments/{attachment_id} [get]
+func (server *Server) getTransferAttachment(ctx *gin.Context) {
+	var req getTransferAttachmentRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx.Request.Context(), req.TransferID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !server.authorizedForTransfer(ctx, transfer) {
+		return
+	}
+
+	arg := db.GetTransferAttachmentParams{
+		ID:         req.AttachmentID,
+		TransferID: req.TransferID,
+	}
+
+	attachment, err := server.store.GetTransferAttachment(ctx.Request.Context(), arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.Filename))
+	ctx.Data(http.StatusOK, attachment.ContentType, attachment.Data)
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+)
+
+// @Summary     Hold funds for a transfer that is captured later
+// @Tags        transfers
+// @Accept      json
+// @Produce     json
+// @Param       request body api.transferRequest true "Transfer to hold funds for"
+// @Success     201 {object} db.Hold
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     422 {object} map[string]string
+// @Failure     429 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/authorize [post]
+func (server *Server) authorizeTransfer(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	req.Description = cleanDescription(req.Description)
+	if !server.validDescription(ctx, req.Description) {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if !server.validAmount(ctx, req.Amount, req.Currency, server.maxTransferAmount(fromAccount)) {
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	hold, err := server.store.AuthorizeHoldTx(ctx.Request.Context(), db.AuthorizeHoldTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Description:   req.Description,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case errors.Is(err, db.ErrTooManyHolds):
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, hold)
+}
+
+type holdRequest struct {
+	HoldID int64 `json:"hold_id" binding:"required,min=1"`
+}
+
+// @Summary     Capture a hold, executing its transfer
+// @Tags        transfers
+// @Accept      json
+// @Produce     json
+// @Param       request body api.holdRequest true "Hold to capture"
+// @Success     200 {object} db.CaptureHoldTxResult
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     422 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/capture [post]
+func (server *Server) captureHold(ctx *gin.Context) {
+	hold, ok := server.boundHold(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx.Request.Context(), hold.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrHoldNotActive):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrHoldExpired), errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed), errors.Is(err, db.ErrDailyLimitExceeded), errors.Is(err, db.ErrBalanceCapExceeded):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// @Summary     Void a hold, releasing its funds
+// @Tags        transfers
+// @Accept      json
+// @Produce     json
+// @Param       request body api.holdRequest true "Hold to void"
+// @Success     200 {object} db.Hold
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/void [post]
+func (server *Server) voidHold(ctx *gin.Context) {
+	hold, ok := server.boundHold(ctx)
+	if !ok {
+		return
+	}
+
+	hold, err := server.store.VoidHoldTx(ctx.Request.Context(), hold.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrHoldNotActive):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+// boundHold loads the hold named in the request body and checks the
+// authenticated user is a party to it, writing the error response when not.
+func (server *Server) boundHold(ctx *gin.Context) (db.Hold, bool) {
+	var req holdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return db.Hold{}, false
+	}
+
+	hold, err := server.store.GetHold(ctx.Request.Context(), req.HoldID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+
+	if !server.authorizedForParties(ctx, "hold", hold.FromAccountID, hold.ToAccountID) {
+		return hold, false
+	}
+
+	return hold, true
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+var (
+	errTransferJobNotFound  = errors.New("transfer job not found")
+	errTransferJobNotFailed = errors.New("only failed transfer jobs can be retried")
+)
+
+type listFailedTransferJobsRequest struct {
+	PageID   int32 `form:"page_id" binding:"min=1"`
+	PageSize int32 `form:"page_size" binding:"min=1,max=100"`
+}
+
+// @Summary     List transfer jobs that failed permanently (admin only)
+// @Description Newest first. The error field holds the reason the transfer was rejected.
+// @Tags        admin
+// @Produce     json
+// @Param       page_id query integer false "Page number, starting at 1, the first by default"
+// @Param       page_size query integer false "Jobs per page, 1 to 100, the server default when left out"
+// @Success     200 {array} db.TransferJob
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/jobs/failed [get]
+func (server *Server) listFailedTransferJobs(ctx *gin.Context) {
+	var req listFailedTransferJobsRequest
+	req.PageID, req.PageSize = server.defaultPage()
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	jobs, err := server.store.ListFailedTransferJobs(ctx.Request.Context(), db.ListFailedTransferJobsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, jobs)
+}
+
+// @Summary     Requeue a failed transfer job (admin only)
+// @Description The job goes back to pending with its error cleared, and the transfer worker attempts it again.
+// @Tags        admin
+// @Produce     json
+// @Param       id path integer true "Transfer job ID"
+// @Success     200 {object} db.TransferJob
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /admin/jobs/{id}/retry [post]
+func (server *Server) retryTransferJob(ctx *gin.Context) {
+	var req getTransferJobRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	job, err := server.store.GetTransferJob(ctx.Request.Context(), req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errTransferJobNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if job.Status != db.TransferJobStatusFailed {
+		ctx.JSON(http.StatusConflict, errorResponse(errTransferJobNotFailed))
+		return
+	}
+
+	job, err = server.store.RequeueTransferJob(ctx.Request.Context(), req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// another admin requeued it since it was read above
+			ctx.JSON(http.StatusConflict, errorResponse(errTransferJobNotFailed))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func randomFailedTransferJob() db.TransferJob {
+	return db.TransferJob{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1, 1000),
+		Amount:        util.RandomMoney(),
+		Status:        db.TransferJobStatusFailed,
+		Error:         db.ErrInsufficientFunds.Error(),
+		ProcessedAt:   util.NewNullTime(time.Now().UTC()),
+	}
+}
+
+func TestListFailedTransferJobsAPI(t *testing.T) {
+	admin := util.RandomOwner()
+
+	var jobs []db.TransferJob
+	for i := 0; i < 3; i++ {
+		jobs = append(jobs, randomFailedTransferJob())
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"page_id": []string{"2"}, "page_size": []string{"3"}},
+			role:  util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListFailedTransferJobsParams{
+					Limit:  3,
+					Offset: 3,
+				}
+				store.EXPECT().ListFailedTransferJobs(gomock.Any(), gomock.Eq(arg)).Times(1).Return(jobs, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.TransferJob
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, len(jobs))
+				for i := range jobs {
+					require.Equal(t, jobs[i].ID, got[i].ID)
+					require.Equal(t, db.TransferJobStatusFailed, got[i].Status)
+					require.Equal(t, jobs[i].Error, got[i].Error)
+				}
+			},
+		},
+		{
+			name:  "DefaultPage",
+			query: url.Values{},
+			role:  util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListFailedTransferJobs(gomock.Any(), gomock.Any()).Times(1).Return([]db.TransferJob{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "[]", recorder.Body.String())
+			},
+		},
+		{
+			name:  "InvalidPageSize",
+			query: url.Values{"page_size": []string{"1000"}},
+			role:  util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListFailedTransferJobs(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "NotAdmin",
+			query: url.Values{},
+			role:  util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListFailedTransferJobs(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{},
+			role:  util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListFailedTransferJobs(gomock.Any(), gomock.Any()).Times(1).Return([]db.TransferJob{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/jobs/failed?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRetryTransferJobAPI(t *testing.T) {
+	admin := util.RandomOwner()
+	job := randomFailedTransferJob()
+
+	requeued := job
+	requeued.Status = db.TransferJobStatusPending
+	requeued.Error = ""
+	requeued.ProcessedAt = util.NullTime{}
+
+	completed := job
+	completed.Status = db.TransferJobStatusCompleted
+	completed.Error = ""
+
+	testCases := []struct {
+		name          string
+		jobID         int64
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			jobID: job.ID,
+			role:  util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(job, nil)
+				store.EXPECT().RequeueTransferJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(requeued, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.TransferJob
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, job.ID, got.ID)
+				require.Equal(t, db.TransferJobStatusPending, got.Status)
+				require.Empty(t, got.Error)
+				require.False(t, got.ProcessedAt.Valid)
+			},
+		},
+		{
+			name:  "NotFailed",
+			jobID: job.ID,
+			role:  util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(completed, nil)
+				store.EXPECT().RequeueTransferJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorBody(t, recorder, errTransferJobNotFailed.Error())
+			},
+		},
+		{
+			name:  "RequeuedConcurrently",
+			jobID: job.ID,
+			role:  util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(job, nil)
+				store.EXPECT().RequeueTransferJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(db.TransferJob{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:  "NotFound",
+			jobID: job.ID,
+			role:  util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(db.TransferJob{}, db.ErrRecordNotFound)
+				store.EXPECT().RequeueTransferJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorBody(t, recorder, errTransferJobNotFound.Error())
+			},
+		},
+		{
+			name:  "InvalidID",
+			jobID: 0,
+			role:  util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "NotAdmin",
+			jobID: job.ID,
+			role:  util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferJob(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RequeueTransferJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			jobID: job.ID,
+			role:  util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(job, nil)
+				store.EXPECT().RequeueTransferJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(db.TransferJob{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/admin/jobs/%d/retry", tc.jobID)
+			request, err := http.NewRequest(http.MethodPost, path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
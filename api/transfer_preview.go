@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+)
+
+// transferPreviewResponse is what a transfer would do if it were made now.
+// Both accounts hold the transfer's currency, so no amount is converted.
+type transferPreviewResponse struct {
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	Fee           int64  `json:"fee"`
+	// TotalDebit is what leaves the sending account, the amount plus the fee.
+	TotalDebit  int64 `json:"total_debit"`
+	FromBalance int64 `json:"from_balance"`
+	ToBalance   int64 `json:"to_balance"`
+	// RequiresApproval is set when the transfer would be held for a banker to
+	// approve rather than made straight away.
+	RequiresApproval bool `json:"requires_approval"`
+}
+
+func newTransferPreviewResponse(req transferRequest, result db.TransferTxResult, requiresApproval bool) transferPreviewResponse {
+	return transferPreviewResponse{
+		FromAccountID:    req.FromAccountID,
+		ToAccountID:      req.ToAccountID,
+		Amount:           req.Amount,
+		Currency:         req.Currency,
+		Fee:              result.Fee,
+		TotalDebit:       req.Amount + result.Fee,
+		FromBalance:      result.FromAccount.Balance,
+		ToBalance:        result.ToAccount.Balance,
+		RequiresApproval: requiresApproval,
+	}
+}
+
+// @Summary     Preview a transfer without making it
+// @Description Runs every check of POST /transfers and reports the fee and resulting balances. Nothing is written; a transfer that would fail answers with the same error.
+// @Tags        transfers
+// @Accept      json
+// @Produce     json
+// @Param       request body api.transferRequest true "Transfer to preview"
+// @Success     200 {object} api.transferPreviewResponse
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     422 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/preview [post]
+func (server *Server) previewTransfer(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	req.Description = cleanDescription(req.Description)
+	if !server.validDescription(ctx, req.Description) {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if !server.validAmount(ctx, req.Amount, req.Currency, server.maxTransferAmount(fromAccount)) {
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		Currency:      req.Currency,
+	}
+
+	// a rejected preview is not audited: nothing was attempted
+	result, err := server.store.PreviewTransferTx(ctx.Request.Context(), arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed), errors.Is(err, db.ErrDailyLimitExceeded), errors.Is(err, db.ErrBalanceCapExceeded):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case errors.Is(err, db.ErrCurrencyMismatch), errors.Is(err, db.ErrInvalidAmount):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		case errors.Is(err, db.ErrRecordNotFound), db.ErrorCode(err) == db.ErrForeignKeyViolation:
+			ctx.JSON(http.StatusNotFound, errorResponse(missingTransferAccount(ctx, arg, err)))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferPreviewResponse(req, result, server.requiresApproval(req.Amount)))
+}
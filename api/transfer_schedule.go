@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+	"github.com/qwerqy/mock_bank/util"
+)
+
+var (
+	errScheduledTransferNotFound   = errors.New("scheduled transfer not found")
+	errScheduledTransferNotPending = errors.New("scheduled transfer is no longer pending")
+	errScheduleNeedsApproval       = errors.New("transfers that need approval cannot be scheduled")
+)
+
+type scheduleTransferRequest struct {
+	transferRequest
+	ExecuteAt time.Time `json:"execute_at" binding:"required"`
+}
+
+// @Summary     Schedule a transfer for a future time
+// @Description The transfer worker executes it once execute_at has passed, with the same checks as an immediate transfer.
+// @Tags        transfers
+// @Accept      json
+// @Produce     json
+// @Param       request body api.scheduleTransferRequest true "Transfer to schedule"
+// @Success     201 {object} db.ScheduledTransfer
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     422 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/schedule [post]
+func (server *Server) scheduleTransfer(ctx *gin.Context) {
+	var req scheduleTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	if !req.ExecuteAt.After(time.Now()) {
+		err := errors.New("execute_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	req.Description = cleanDescription(req.Description)
+	if !server.validDescription(ctx, req.Description) {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if !server.validAmount(ctx, req.Amount, req.Currency, server.maxTransferAmount(fromAccount)) {
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	// nobody would be around to approve it when it falls due
+	if server.requiresApproval(req.Amount) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errScheduleNeedsApproval))
+		return
+	}
+
+	arg := db.CreateScheduledTransferParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		CreatedBy:     authPayload.Username,
+		ExecuteAt:     req.ExecuteAt,
+	}
+
+	scheduled, err := server.store.CreateScheduledTransfer(ctx.Request.Context(), arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, scheduled)
+}
+
+type scheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// @Summary     Get a scheduled transfer
+// @Tags        transfers
+// @Produce     json
+// @Param       id path integer true "Scheduled transfer ID"
+// @Success     200 {object} db.ScheduledTransfer
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/scheduled/{id} [get]
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req scheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.ownScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+// @Summary     Cancel a scheduled transfer that has not run yet
+// @Tags        transfers
+// @Produce     json
+// @Param       id path integer true "Scheduled transfer ID"
+// @Success     200 {object} db.ScheduledTransfer
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     409 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/scheduled/{id}/cancel [post]
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	var req scheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.ownScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	if scheduled.Status != db.ScheduledTransferStatusPending {
+		ctx.JSON(http.StatusConflict, errorResponse(errScheduledTransferNotPending))
+		return
+	}
+
+	scheduled, err := server.store.CancelScheduledTransfer(ctx.Request.Context(), req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// the worker picked it up since it was read above
+			ctx.JSON(http.StatusConflict, errorResponse(errScheduledTransferNotPending))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+// ownScheduledTransfer fetches a scheduled transfer made by the authenticated
+// user, or by anyone for an admin. Those of other users are reported as not
+// found.
+func (server *Server) ownScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfer, bool) {
+	scheduled, err := server.store.GetScheduledTransfer(ctx.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errScheduledTransferNotFound))
+			return scheduled, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return scheduled, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.AdminRole && scheduled.CreatedBy != authPayload.Username {
+		ctx.JSON(http.StatusNotFound, errorResponse(errScheduledTransferNotFound))
+		return scheduled, false
+	}
+
+	return scheduled, true
+}
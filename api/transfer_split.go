@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+)
+
+var errSplitNeedsApproval = errors.New("splits whose total needs approval are not supported")
+
+type splitTarget struct {
+	ToAccountID int64 `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64 `json:"amount" binding:"required,gt=0"`
+}
+
+type splitTransferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Description   string `json:"description" binding:"max=140"`
+	// Targets is capped at 20, which bounds how many rows one split locks.
+	Targets []splitTarget `json:"targets" binding:"required,min=2,max=20,dive"`
+}
+
+// @Summary     Send money from one account to several at once
+// @Description Every target is paid or none is. The source must cover the total, fees included.
+// @Tags        transfers
+// @Accept      json
+// @Produce     json
+// @Param       request body api.splitTransferRequest true "Source and the targets to pay from it"
+// @Success     201 {object} db.SplitTransferTxResult
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     422 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /transfers/split [post]
+func (server *Server) createSplitTransfer(ctx *gin.Context) {
+	var req splitTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	req.Description = cleanDescription(req.Description)
+	if !server.validDescription(ctx, req.Description) {
+		return
+	}
+
+	if err := validSplitTargets(ctx, req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	var total int64
+	for _, target := range req.Targets {
+		if !server.validAmount(ctx, target.Amount, req.Currency, server.maxTransferAmount(fromAccount)) {
+			return
+		}
+		total += target.Amount
+	}
+
+	for _, target := range req.Targets {
+		_, valid = server.validAccount(ctx, target.ToAccountID, req.Currency)
+		if !valid {
+			return
+		}
+	}
+
+	if server.requiresApproval(total) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errSplitNeedsApproval))
+		return
+	}
+
+	arg := db.SplitTransferTxParams{
+		FromAccountID: req.FromAccountID,
+		Targets:       make([]db.SplitTarget, 0, len(req.Targets)),
+		Description:   req.Description,
+	}
+	for _, target := range req.Targets {
+		arg.Targets = append(arg.Targets, db.SplitTarget{
+			ToAccountID: target.ToAccountID,
+			Amount:      target.Amount,
+		})
+	}
+
+	result, err := server.store.SplitTransferTx(ctx.Request.Context(), arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed), errors.Is(err, db.ErrDailyLimitExceeded), errors.Is(err, db.ErrBalanceCapExceeded):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case errors.Is(err, db.ErrRecordNotFound), db.ErrorCode(err) == db.ErrForeignKeyViolation:
+			// an account was deleted after it was validated above
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountNotFound))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// validSplitTargets checks the targets of a split name each account once,
+// never the source, and add up to a total that fits in an int64.
+func validSplitTargets(ctx *gin.Context, req splitTransferRequest) error {
+	seen := make(map[int64]bool, len(req.Targets))
+	var total int64
+	for _, target := range req.Targets {
+		if target.ToAccountID == req.FromAccountID {
+			return fmt.Errorf("account [%s] cannot pay itself", accountRef(ctx, target.ToAccountID))
+		}
+		if seen[target.ToAccountID] {
+			return fmt.Errorf("account [%s] is a target more than once", accountRef(ctx, target.ToAccountID))
+		}
+		seen[target.ToAccountID] = true
+
+		if target.Amount > math.MaxInt64-total {
+			return errors.New("the amounts add up to more than can be sent")
+		}
+		total += target.Amount
+	}
+	return nil
+}
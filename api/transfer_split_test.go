@@ -0,0 +1,206 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	source := randomAccount(user.Username)
+	source.ID = 1
+	target1 := randomAccount(util.RandomOwner())
+	target1.ID = 2
+	target1.Currency = source.Currency
+	target2 := randomAccount(util.RandomOwner())
+	target2.ID = 3
+	target2.Currency = source.Currency
+
+	body := func(targets ...gin.H) gin.H {
+		return gin.H{
+			"from_account_id": source.ID,
+			"currency":        source.Currency,
+			"description":     "dinner",
+			"targets":         targets,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: body(gin.H{"to_account_id": target1.ID, "amount": 30}, gin.H{"to_account_id": target2.ID, "amount": 20}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(source.ID)).Times(1).Return(source, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(target1.ID)).Times(1).Return(target1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(target2.ID)).Times(1).Return(target2, nil)
+
+				arg := db.SplitTransferTxParams{
+					FromAccountID: source.ID,
+					Targets: []db.SplitTarget{
+						{ToAccountID: target1.ID, Amount: 30},
+						{ToAccountID: target2.ID, Amount: 20},
+					},
+					Description: "dinner",
+				}
+				result := db.SplitTransferTxResult{
+					FromAccount: source,
+					Transfers: []db.TransferTxResult{
+						{Transfer: db.Transfer{ID: 1, FromAccountID: source.ID, ToAccountID: target1.ID, Amount: 30}},
+						{Transfer: db.Transfer{ID: 2, FromAccountID: source.ID, ToAccountID: target2.ID, Amount: 20}},
+					},
+				}
+				result.FromAccount.Balance -= 50
+				store.EXPECT().SplitTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var got db.SplitTransferTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, source.Balance-50, got.FromAccount.Balance)
+				require.Len(t, got.Transfers, 2)
+				require.Equal(t, target1.ID, got.Transfers[0].Transfer.ToAccountID)
+				require.Equal(t, target2.ID, got.Transfers[1].Transfer.ToAccountID)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: body(gin.H{"to_account_id": target1.ID, "amount": 30}, gin.H{"to_account_id": target2.ID, "amount": 20}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+					func(_ interface{}, id int64) (db.Account, error) {
+						return map[int64]db.Account{source.ID: source, target1.ID: target1, target2.ID: target2}[id], nil
+					})
+				store.EXPECT().SplitTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.SplitTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateDestination",
+			body: body(gin.H{"to_account_id": target1.ID, "amount": 30}, gin.H{"to_account_id": target1.ID, "amount": 20}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().SplitTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "is a target more than once")
+			},
+		},
+		{
+			name: "PaysItself",
+			body: body(gin.H{"to_account_id": source.ID, "amount": 30}, gin.H{"to_account_id": target1.ID, "amount": 20}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SplitTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "SingleTarget",
+			body: body(gin.H{"to_account_id": target1.ID, "amount": 30}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SplitTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ZeroAmount",
+			body: body(gin.H{"to_account_id": target1.ID, "amount": 30}, gin.H{"to_account_id": target2.ID, "amount": 0}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SplitTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotOwner",
+			body: gin.H{
+				"from_account_id": target1.ID,
+				"currency":        source.Currency,
+				"targets":         []gin.H{{"to_account_id": source.ID, "amount": 30}, {"to_account_id": target2.ID, "amount": 20}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(target1.ID)).Times(1).Return(target1, nil)
+				store.EXPECT().SplitTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "TargetNotFound",
+			body: body(gin.H{"to_account_id": target1.ID, "amount": 30}, gin.H{"to_account_id": target2.ID, "amount": 20}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(source.ID)).Times(1).Return(source, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(target1.ID)).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().SplitTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: body(gin.H{"to_account_id": target1.ID, "amount": 30}, gin.H{"to_account_id": target2.ID, "amount": 20}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+					func(_ interface{}, id int64) (db.Account, error) {
+						return map[int64]db.Account{source.ID: source, target1.ID: target1, target2.ID: target2}[id], nil
+					})
+				store.EXPECT().SplitTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.SplitTransferTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/split", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/mail"
+)
+
+const (
+	defaultVerificationTokenTTL = 24 * time.Hour
+	verificationTokenBytes      = 32
+)
+
+// newVerificationToken returns a random, URL-safe token for a verification
+// link.
+func newVerificationToken() (string, error) {
+	buf := make([]byte, verificationTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (server *Server) verificationTokenTTL() time.Duration {
+	if server.config.VerificationTokenTTL > 0 {
+		return server.config.VerificationTokenTTL
+	}
+	return defaultVerificationTokenTTL
+}
+
+// verificationLink is the link in the verification email, on PublicURL.
+func (server *Server) verificationLink(token string) string {
+	query := url.Values{"token": []string{token}}
+	return strings.TrimSuffix(server.config.PublicURL, "/") + "/users/verify?" + query.Encode()
+}
+
+// sendVerificationEmail hands the verification link to the mailer. The user
+// already exists by then, so a failure is only logged rather than failing
+// the signup.
+func (server *Server) sendVerificationEmail(ctx *gin.Context, user db.User, token string) {
+	err := server.mailer.Send(ctx.Request.Context(), mail.Email{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen this link to verify your email address:\n%s\n",
+			user.FullName, server.verificationLink(token)),
+	})
+	if err != nil {
+		log.Printf("cannot send verification email to %s: %v", user.Username, err)
+	}
+}
+
+type verifyEmailRequest struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// verifyEmail marks the email of the user a verification token was issued
+// to as verified. Each token works once, until it expires.
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	user, err := server.store.VerifyEmailTx(ctx.Request.Context(), req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(errors.New("verification token not found")))
+		case errors.Is(err, db.ErrVerificationTokenUsed):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrVerificationExpired):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
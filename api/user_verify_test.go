@@ -0,0 +1,240 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/mail"
+	mockmail "github.com/qwerqy/mock_bank/mail/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	token, err := newVerificationToken()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"token": []string{token}},
+			buildStubs: func(store *mockdb.MockStore) {
+				verified := user
+				verified.IsEmailVerified = true
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Eq(token)).Times(1).Return(verified, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got userResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, user.Username, got.Username)
+				require.True(t, got.IsEmailVerified)
+			},
+		},
+		{
+			name:  "Expired",
+			query: url.Values{"token": []string{token}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Eq(token)).Times(1).Return(db.User{}, db.ErrVerificationExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorBody(t, recorder, db.ErrVerificationExpired.Error())
+			},
+		},
+		{
+			name:  "AlreadyUsed",
+			query: url.Values{"token": []string{token}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Eq(token)).Times(1).Return(db.User{}, db.ErrVerificationTokenUsed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorBody(t, recorder, db.ErrVerificationTokenUsed.Error())
+			},
+		},
+		{
+			name:  "UnknownToken",
+			query: url.Values{"token": []string{"unknown"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Eq("unknown")).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingToken",
+			query: url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{"token": []string{token}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users/verify?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreateUserVerificationEmail(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name        string
+		returnToken bool
+	}{
+		{name: "Hidden", returnToken: false},
+		{name: "Returned", returnToken: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var issued string
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+				func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+					issued = arg.VerificationToken
+					return db.CreateUserTxResult{
+						User: user,
+						VerificationToken: &db.VerificationToken{
+							Username:  user.Username,
+							Token:     arg.VerificationToken,
+							ExpiresAt: arg.VerificationExpiresAt,
+						},
+					}, nil
+				})
+
+			var sent mail.Email
+			mailer := mockmail.NewMockSender(ctrl)
+			mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+				func(_ context.Context, email mail.Email) error {
+					sent = email
+					return nil
+				})
+
+			server := newTestServer(t, store)
+			server.mailer = mailer
+			server.config.PublicURL = "https://bank.example.com/"
+			server.config.ReturnVerificationToken = tc.returnToken
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusCreated, recorder.Code)
+			require.NotEmpty(t, issued)
+
+			require.Equal(t, user.Email, sent.To)
+			require.Contains(t, sent.Body, "https://bank.example.com/users/verify?token="+url.QueryEscape(issued))
+
+			var rsp createUserResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+			require.False(t, rsp.IsEmailVerified)
+			if tc.returnToken {
+				require.Equal(t, issued, rsp.VerificationToken)
+			} else {
+				require.Empty(t, rsp.VerificationToken)
+			}
+		})
+	}
+}
+
+func TestCreateUserMailerFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, password := randomUser(t)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+			return db.CreateUserTxResult{
+				User:              user,
+				VerificationToken: &db.VerificationToken{Token: arg.VerificationToken},
+			}, nil
+		})
+
+	mailer := mockmail.NewMockSender(ctrl)
+	mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).Return(errors.New("mail queue is full"))
+
+	server := newTestServer(t, store)
+	server.mailer = mailer
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"username":  user.Username,
+		"password":  password,
+		"full_name": user.FullName,
+		"email":     user.Email,
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/users", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// the user exists by the time the email fails, so signup still succeeds
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+}
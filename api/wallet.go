@@ -0,0 +1,74 @@
+package api
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/token"
+)
+
+type walletCurrency struct {
+	Currency string       `json:"currency"`
+	Balance  int64        `json:"balance"`
+	Count    int          `json:"count"`
+	Accounts []db.Account `json:"accounts"`
+}
+
+type walletResponse struct {
+	Count      int              `json:"count"`
+	Currencies []walletCurrency `json:"currencies"`
+}
+
+// @Summary     Show the authenticated user's accounts grouped by currency
+// @Tags        accounts
+// @Produce     json
+// @Success     200 {object} api.walletResponse
+// @Failure     401 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Security    BearerAuth
+// @Router      /wallet [get]
+func (server *Server) getWallet(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ListAccountsParams{
+		Owner: sql.NullString{String: authPayload.Username, Valid: true},
+		Limit: math.MaxInt32,
+	}
+
+	accounts, err := server.store.ListAccounts(ctx.Request.Context(), arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWalletResponse(accounts))
+}
+
+func newWalletResponse(accounts []db.Account) walletResponse {
+	byCurrency := make(map[string]*walletCurrency)
+	for _, account := range accounts {
+		group, ok := byCurrency[account.Currency]
+		if !ok {
+			group = &walletCurrency{Currency: account.Currency, Accounts: []db.Account{}}
+			byCurrency[account.Currency] = group
+		}
+		group.Balance += account.Balance
+		group.Count++
+		group.Accounts = append(group.Accounts, account)
+	}
+
+	rsp := walletResponse{
+		Count:      len(accounts),
+		Currencies: make([]walletCurrency, 0, len(byCurrency)),
+	}
+	for _, group := range byCurrency {
+		rsp.Currencies = append(rsp.Currencies, *group)
+	}
+	sort.Slice(rsp.Currencies, func(i, j int) bool {
+		return rsp.Currencies[i].Currency < rsp.Currencies[j].Currency
+	})
+	return rsp
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	_ "github.com/lib/pq"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+)
+
+func main() {
+	numAccounts := flag.Int("accounts", 10, "number of accounts to create")
+	numTransfers := flag.Int("transfers", 50, "number of transfers to attempt between them")
+	password := flag.String("password", "secret", "password every seeded user logs in with")
+	configPath := flag.String("config", ".", "directory containing app.env")
+	flag.Parse()
+
+	config, err := util.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal("cannot load config:", err)
+	}
+
+	_, store, err := db.Connect(config)
+	if err != nil {
+		log.Fatal("cannot connect to db:", err)
+	}
+
+	hashedPassword, err := util.HashPassword(*password)
+	if err != nil {
+		log.Fatal("cannot hash password:", err)
+	}
+
+	result, err := seed(context.Background(), store, *numAccounts, *numTransfers, hashedPassword)
+	if err != nil {
+		log.Fatal("cannot seed db:", err)
+	}
+
+	log.Printf("seeded %d users, %d accounts and %d transfers", len(result.Users), len(result.Accounts), len(result.Transfers))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+)
+
+type seedResult struct {
+	Users     []db.User
+	Accounts  []db.Account
+	Transfers []db.TransferTxResult
+}
+
+// seed creates numAccounts random accounts, each owned by a new depositor
+// logging in with hashedPassword, followed by up to numTransfers random
+// transfers between them. Transfers only ever pair accounts of the same
+// currency and never move more than the sender holds. Seeding only adds rows,
+// so it can safely be run against a database that was seeded before.
+func seed(ctx context.Context, store db.Store, numAccounts int, numTransfers int, hashedPassword string) (seedResult, error) {
+	var result seedResult
+
+	byCurrency := make(map[string][]*db.Account)
+	for i := 0; i < numAccounts; i++ {
+		username := util.RandomOwner()
+		user, err := store.CreateUser(ctx, db.CreateUserParams{
+			Username:       username,
+			Role:           util.DepositorRole,
+			HashedPassword: hashedPassword,
+			FullName:       username,
+			Email:          username + "@email.com",
+		})
+		if err != nil {
+			return result, err
+		}
+
+		account, err := store.CreateAccount(ctx, db.CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  util.RandomMoney(),
+			Currency: util.RandomCurrency(),
+		})
+		if err != nil {
+			return result, err
+		}
+
+		result.Users = append(result.Users, user)
+		result.Accounts = append(result.Accounts, account)
+		byCurrency[account.Currency] = append(byCurrency[account.Currency], &account)
+	}
+
+	var currencies []string
+	for currency, accounts := range byCurrency {
+		if len(accounts) >= 2 {
+			currencies = append(currencies, currency)
+		}
+	}
+	if len(currencies) == 0 {
+		return result, nil
+	}
+
+	for i := 0; i < numTransfers; i++ {
+		accounts := byCurrency[currencies[rand.Intn(len(currencies))]]
+
+		from := accounts[rand.Intn(len(accounts))]
+		to := accounts[rand.Intn(len(accounts))]
+		if from == to || from.Balance <= 0 {
+			continue
+		}
+
+		transfer, err := store.TransferTx(ctx, db.TransferTxParams{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        util.RandomInt(1, from.Balance),
+		})
+		if err != nil {
+			return result, err
+		}
+
+		from.Balance = transfer.FromAccount.Balance
+		to.Balance = transfer.ToAccount.Balance
+		result.Transfers = append(result.Transfers, transfer)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLedger backs the mock store with just enough state for seed to run.
+type fakeLedger struct {
+	users    map[string]db.User
+	accounts map[int64]db.Account
+}
+
+func (ledger *fakeLedger) createUser(_ context.Context, arg db.CreateUserParams) (db.User, error) {
+	user := db.User{
+		Username:       arg.Username,
+		Role:           arg.Role,
+		HashedPassword: arg.HashedPassword,
+		FullName:       arg.FullName,
+		Email:          arg.Email,
+	}
+	ledger.users[user.Username] = user
+	return user, nil
+}
+
+func (ledger *fakeLedger) createAccount(_ context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	account := db.Account{
+		ID:       int64(len(ledger.accounts) + 1),
+		Owner:    arg.Owner,
+		Balance:  arg.Balance,
+		Currency: arg.Currency,
+	}
+	ledger.accounts[account.ID] = account
+	return account, nil
+}
+
+func (ledger *fakeLedger) transferTx(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+	from := ledger.accounts[arg.FromAccountID]
+	to := ledger.accounts[arg.ToAccountID]
+
+	from.Balance -= arg.Amount
+	to.Balance += arg.Amount
+	ledger.accounts[from.ID] = from
+	ledger.accounts[to.ID] = to
+
+	return db.TransferTxResult{FromAccount: from, ToAccount: to}, nil
+}
+
+func TestSeed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ledger := &fakeLedger{
+		users:    make(map[string]db.User),
+		accounts: make(map[int64]db.Account),
+	}
+	numAccounts := 20
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(numAccounts).DoAndReturn(ledger.createUser)
+	store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(numAccounts).DoAndReturn(ledger.createAccount)
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+			from := ledger.accounts[arg.FromAccountID]
+			to := ledger.accounts[arg.ToAccountID]
+
+			require.NotEqual(t, from.ID, to.ID)
+			require.Equal(t, from.Currency, to.Currency)
+			require.Positive(t, arg.Amount)
+			require.LessOrEqual(t, arg.Amount, from.Balance)
+
+			return ledger.transferTx(ctx, arg)
+		})
+
+	result, err := seed(context.Background(), store, numAccounts, 100, "hashed")
+	require.NoError(t, err)
+	require.Len(t, result.Users, numAccounts)
+	require.Len(t, result.Accounts, numAccounts)
+
+	for _, user := range result.Users {
+		require.Equal(t, util.DepositorRole, user.Role)
+		require.Equal(t, "hashed", user.HashedPassword)
+	}
+
+	for _, account := range result.Accounts {
+		require.Contains(t, ledger.users, account.Owner)
+		require.True(t, util.IsSupportedCurrency(account.Currency), account.Currency)
+		require.GreaterOrEqual(t, account.Balance, int64(0))
+	}
+
+	for _, account := range ledger.accounts {
+		require.GreaterOrEqual(t, account.Balance, int64(0))
+	}
+}
+
+func TestSeedCreateAccountError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{Username: "seeded"}, nil)
+	store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := seed(context.Background(), store, 5, 5, "hashed")
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
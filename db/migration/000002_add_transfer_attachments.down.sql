@@ -0,0 +1 @@
+DROP TABLE IF EXISTS transfer_attachments;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE "transfer_attachments" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint NOT NULL,
+  "filename" varchar NOT NULL,
+  "content_type" varchar NOT NULL,
+  "data" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_attachments" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfer_attachments" ("transfer_id");
@@ -0,0 +1 @@
+ALTER TABLE "accounts" ADD COLUMN "nickname" varchar NOT NULL DEFAULT '';
\ No newline at end of file
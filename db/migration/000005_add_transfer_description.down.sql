@@ -0,0 +1,3 @@
+ALTER TABLE "transfer_jobs" DROP COLUMN IF EXISTS "description";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "description";
\ No newline at end of file
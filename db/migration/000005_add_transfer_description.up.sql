@@ -0,0 +1,3 @@
+ALTER TABLE "transfers" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "transfer_jobs" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
\ No newline at end of file
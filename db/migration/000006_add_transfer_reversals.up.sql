@@ -0,0 +1,4 @@
+ALTER TABLE "transfers" ADD COLUMN "reversal_of" bigint REFERENCES "transfers" ("id");
+
+-- a transfer can be reversed at most once
+CREATE UNIQUE INDEX ON "transfers" ("reversal_of");
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS pending_approvals;
\ No newline at end of file
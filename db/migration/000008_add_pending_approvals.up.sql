@@ -0,0 +1,27 @@
+CREATE TABLE "pending_approvals" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'pending_approval',
+  "initiated_by" varchar NOT NULL,
+  "approved_by" varchar,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "approved_at" timestamptz
+);
+
+ALTER TABLE "pending_approvals" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "pending_approvals" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "pending_approvals" ADD FOREIGN KEY ("initiated_by") REFERENCES "users" ("username");
+
+ALTER TABLE "pending_approvals" ADD FOREIGN KEY ("approved_by") REFERENCES "users" ("username");
+
+ALTER TABLE "pending_approvals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "pending_approvals" ("status");
+
+COMMENT ON COLUMN "pending_approvals"."status" IS 'pending_approval or approved';
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE "transfers" ADD COLUMN "reference" varchar;
+
+-- existing transfers get a reference derived from their id, so each one is
+-- unique before the index goes on
+UPDATE "transfers"
+SET "reference" = 'TRX-' || to_char("created_at", 'YYYYMMDD') || '-' || lpad("id"::text, 6, '0');
+
+ALTER TABLE "transfers" ALTER COLUMN "reference" SET NOT NULL;
+
+CREATE UNIQUE INDEX ON "transfers" ("reference");
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS account_labels;
\ No newline at end of file
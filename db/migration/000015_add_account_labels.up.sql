@@ -0,0 +1,11 @@
+CREATE TABLE "account_labels" (
+  "account_id" bigint NOT NULL,
+  "label" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "label")
+);
+
+ALTER TABLE "account_labels" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+-- lets GET /accounts?label= find the accounts carrying a label
+CREATE INDEX ON "account_labels" ("label");
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE "accounts" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+CREATE INDEX ON "accounts" USING GIN ("metadata");
+
+COMMENT ON COLUMN "accounts"."metadata" IS 'client-defined JSON object';
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE "entries" ADD COLUMN "type" varchar;
+
+-- entries made before their type was recorded can only be told apart by sign
+UPDATE "entries" SET "type" = CASE WHEN "amount" < 0 THEN 'transfer_debit' ELSE 'transfer_credit' END;
+
+ALTER TABLE "entries" ALTER COLUMN "type" SET NOT NULL;
+
+CREATE INDEX ON "entries" ("account_id", "type");
+
+COMMENT ON COLUMN "entries"."type" IS 'transfer_debit, transfer_credit, fee, interest, deposit or adjustment';
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "max_transfer_override";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "daily_limit_override";
\ No newline at end of file
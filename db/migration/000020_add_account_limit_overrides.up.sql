@@ -0,0 +1,7 @@
+ALTER TABLE "accounts" ADD COLUMN "daily_limit_override" bigint;
+
+ALTER TABLE "accounts" ADD COLUMN "max_transfer_override" bigint;
+
+COMMENT ON COLUMN "accounts"."daily_limit_override" IS 'replaces the global daily transfer limit when set';
+
+COMMENT ON COLUMN "accounts"."max_transfer_override" IS 'replaces the global maximum transfer amount when set';
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS verification_tokens;
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "is_email_verified";
\ No newline at end of file
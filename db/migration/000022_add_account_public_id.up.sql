@@ -0,0 +1,5 @@
+ALTER TABLE "accounts" ADD COLUMN "public_id" uuid NOT NULL DEFAULT (gen_random_uuid());
+
+CREATE UNIQUE INDEX ON "accounts" ("public_id");
+
+COMMENT ON COLUMN "accounts"."public_id" IS 'addresses the account in the API when ACCOUNT_ID_MODE is uuid';
\ No newline at end of file
@@ -0,0 +1,9 @@
+// Package migration embeds the SQL migrations so the server binary can apply
+// them itself. The files keep the golang-migrate naming used by `make
+// migrateup`.
+package migration
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -12,16 +12,69 @@ RETURNING *;
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountByOwnerCurrency :one
+SELECT * FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1;
+
 -- name: GetAccountForUpdate :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
+-- name: GetAccountWithEntries :one
+SELECT
+  a.*,
+  COALESCE((
+    SELECT json_agg(e ORDER BY e.id DESC)
+    FROM (
+      SELECT * FROM entries
+      WHERE account_id = a.id
+      ORDER BY id DESC
+      LIMIT sqlc.arg(entry_limit)
+    ) e
+  ), '[]')::json AS entries
+FROM accounts a
+WHERE a.id = sqlc.arg(id) LIMIT 1;
+
+-- name: GetAccountsByMetadataKey :many
+SELECT * FROM accounts
+WHERE metadata ? sqlc.arg(key)::text
+ORDER BY id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListAccountIDsByOwner :many
+SELECT id FROM accounts
+WHERE owner = $1
+ORDER BY id;
+
+-- name: ListAccountIDsByPublicID :many
+SELECT id, public_id FROM accounts
+WHERE public_id = ANY(sqlc.arg(public_ids)::uuid[]);
+
+-- name: ListAccountPublicIDs :many
+SELECT id, public_id FROM accounts
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
+WHERE (sqlc.narg(owner)::varchar IS NULL OR owner = sqlc.narg(owner))
+AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after))
+AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before))
+AND (sqlc.narg(label)::varchar IS NULL OR EXISTS (
+  SELECT 1 FROM account_labels
+  WHERE account_labels.account_id = accounts.id AND account_labels.label = sqlc.narg(label)
+))
+ORDER BY id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: SearchAccountsByOwner :many
+SELECT * FROM accounts
+WHERE owner ILIKE '%' || sqlc.arg(query)::varchar || '%'
 ORDER BY id
-LIMIT $1
-OFFSET $2;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
 
 -- name: UpdateAccount :one
 UPDATE accounts 
@@ -29,6 +82,33 @@ SET balance = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateAccountDetails :one
+UPDATE accounts
+SET nickname = COALESCE(sqlc.narg(nickname), nickname),
+  metadata = COALESCE(sqlc.narg(metadata), metadata)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateAccountLimitOverrides :one
+UPDATE accounts
+SET daily_limit_override = sqlc.narg(daily_limit_override),
+  max_transfer_override = sqlc.narg(max_transfer_override)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateAccountOwner :one
+UPDATE accounts
+SET owner = users.username
+FROM users
+WHERE accounts.id = sqlc.arg(id) AND users.username = sqlc.arg(owner)
+RETURNING accounts.*;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: AddAccountBalance :one
 UPDATE accounts 
 SET balance = balance + sqlc.arg(amount)
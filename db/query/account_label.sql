@@ -0,0 +1,18 @@
+-- name: AddAccountLabel :one
+INSERT INTO account_labels (
+  account_id,
+  label
+) VALUES (
+  $1, $2
+)
+RETURNING *;
+
+-- name: ListAccountLabels :many
+SELECT * FROM account_labels
+WHERE account_id = $1
+ORDER BY label;
+
+-- name: RemoveAccountLabel :one
+DELETE FROM account_labels
+WHERE account_id = $1 AND label = $2
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- name: CreateAccountStatusChange :one
+INSERT INTO account_status_history (
+  account_id,
+  from_status,
+  to_status,
+  reason,
+  changed_by
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListAccountStatusHistory :many
+SELECT * FROM account_status_history
+WHERE account_id = $1
+ORDER BY id;
\ No newline at end of file
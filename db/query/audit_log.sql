@@ -0,0 +1,17 @@
+-- name: CreateAuditLog :one
+INSERT INTO audit_logs (
+  username,
+  from_account_id,
+  to_account_id,
+  amount,
+  reason
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListAuditLogs :many
+SELECT * FROM audit_logs
+ORDER BY id
+LIMIT $1
+OFFSET $2;
\ No newline at end of file
@@ -1,9 +1,10 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  type
 ) VALUES (
-  $1, $2
+  $1, $2, $3
 )
 RETURNING *;
 
@@ -11,9 +12,29 @@ RETURNING *;
 SELECT * FROM entries
 WHERE id = $1 LIMIT 1;
 
+-- name: ListEntriesAfter :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
 -- name: ListEntry :many
 SELECT * FROM entries
-WHERE account_id = $1
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(type)::varchar IS NULL OR type = sqlc.narg(type))
 ORDER BY id
-LIMIT $2
-OFFSET $3;
\ No newline at end of file
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: SumEntries :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM entries
+WHERE account_id = $1;
+
+-- name: SumFeesCharged :one
+SELECT COALESCE(SUM(-amount), 0)::bigint AS total FROM entries
+WHERE
+  account_id = sqlc.arg(account_id) AND
+  type = 'fee' AND
+  amount < 0 AND
+  created_at >= sqlc.arg(start_time) AND
+  created_at < sqlc.arg(end_time);
\ No newline at end of file
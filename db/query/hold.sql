@@ -0,0 +1,43 @@
+-- name: CountActiveHolds :one
+SELECT COUNT(*) FROM holds
+WHERE from_account_id = $1 AND status = 'held' AND expires_at > now();
+
+-- name: CreateHold :one
+INSERT INTO holds (
+  from_account_id,
+  to_account_id,
+  amount,
+  description,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetNextExpiredHold :one
+SELECT * FROM holds
+WHERE status = 'held' AND expires_at <= now()
+ORDER BY expires_at, id
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: SumActiveHolds :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM holds
+WHERE from_account_id = $1 AND status = 'held' AND expires_at > now();
+
+-- name: UpdateHoldStatus :one
+UPDATE holds
+SET
+  status = sqlc.arg(status),
+  transfer_id = sqlc.narg(transfer_id)
+WHERE id = sqlc.arg(id)
+RETURNING *;
\ No newline at end of file
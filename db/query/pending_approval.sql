@@ -0,0 +1,30 @@
+-- name: ApprovePendingApproval :one
+UPDATE pending_approvals
+SET
+  status = 'approved',
+  approved_by = sqlc.arg(approved_by),
+  transfer_id = sqlc.arg(transfer_id),
+  approved_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending_approval'
+RETURNING *;
+
+-- name: CreatePendingApproval :one
+INSERT INTO pending_approvals (
+  from_account_id,
+  to_account_id,
+  amount,
+  description,
+  initiated_by
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetPendingApproval :one
+SELECT * FROM pending_approvals
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPendingApprovalForUpdate :one
+SELECT * FROM pending_approvals
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
\ No newline at end of file
@@ -0,0 +1,39 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  description,
+  created_by,
+  execute_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetNextDueScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE status = 'pending' AND execute_at <= now()
+ORDER BY execute_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: UpdateScheduledTransferStatus :one
+UPDATE scheduled_transfers
+SET
+  status = sqlc.arg(status),
+  error = sqlc.arg(error),
+  transfer_id = sqlc.arg(transfer_id),
+  executed_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending'
+RETURNING *;
+
+-- name: CancelScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'cancelled'
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
\ No newline at end of file
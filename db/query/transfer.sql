@@ -2,16 +2,43 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  description,
+  reversal_of,
+  reference
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 )
+ON CONFLICT (reference) DO NOTHING
 RETURNING *;
 
+-- name: FindRecentIdenticalTransfer :one
+SELECT * FROM transfers
+WHERE
+  from_account_id = sqlc.arg(from_account_id) AND
+  to_account_id = sqlc.arg(to_account_id) AND
+  amount = sqlc.arg(amount) AND
+  created_at >= sqlc.arg(since)
+ORDER BY created_at DESC, id DESC
+LIMIT 1;
+
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferByReference :one
+SELECT * FROM transfers
+WHERE reference = $1 LIMIT 1;
+
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetTransferReversal :one
+SELECT * FROM transfers
+WHERE reversal_of = $1 LIMIT 1;
+
 -- name: ListTransfer :many
 SELECT * FROM transfers
 WHERE
@@ -19,4 +46,40 @@ WHERE
   to_account_id = $2
 ORDER BY id
 LIMIT $3
-OFFSET $4;
\ No newline at end of file
+OFFSET $4;
+
+-- name: ListTransfersForAccounts :many
+SELECT * FROM transfers
+WHERE
+  from_account_id = ANY(sqlc.arg(account_ids)::bigint[]) OR
+  to_account_id = ANY(sqlc.arg(account_ids)::bigint[])
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListTransfersFrom :many
+SELECT * FROM transfers
+WHERE from_account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListTransfersTo :many
+SELECT * FROM transfers
+WHERE to_account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListLargestTransfers :many
+SELECT * FROM transfers
+WHERE
+  (from_account_id = sqlc.arg(account_id) OR to_account_id = sqlc.arg(account_id)) AND
+  created_at >= sqlc.arg(start_time) AND
+  created_at < sqlc.arg(end_time)
+ORDER BY amount DESC, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: SumOutboundTransfersSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM transfers
+WHERE from_account_id = sqlc.arg(account_id) AND created_at >= sqlc.arg(since);
\ No newline at end of file
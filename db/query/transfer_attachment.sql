@@ -0,0 +1,14 @@
+-- name: CreateTransferAttachment :one
+INSERT INTO transfer_attachments (
+  transfer_id,
+  filename,
+  content_type,
+  data
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetTransferAttachment :one
+SELECT * FROM transfer_attachments
+WHERE id = $1 AND transfer_id = $2 LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,47 @@
+-- name: CreateTransferJob :one
+INSERT INTO transfer_jobs (
+  from_account_id,
+  to_account_id,
+  amount,
+  description
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetTransferJob :one
+SELECT * FROM transfer_jobs
+WHERE id = $1 LIMIT 1;
+
+-- name: GetNextPendingTransferJob :one
+SELECT * FROM transfer_jobs
+WHERE status = 'pending'
+ORDER BY id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: ListFailedTransferJobs :many
+SELECT * FROM transfer_jobs
+WHERE status = 'failed'
+ORDER BY id DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: RequeueTransferJob :one
+UPDATE transfer_jobs
+SET
+  status = 'pending',
+  error = '',
+  processed_at = NULL
+WHERE id = $1 AND status = 'failed'
+RETURNING *;
+
+-- name: UpdateTransferJobStatus :one
+UPDATE transfer_jobs
+SET
+  status = sqlc.arg(status),
+  error = sqlc.arg(error),
+  transfer_id = sqlc.arg(transfer_id),
+  processed_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending'
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- name: CreateUser :one
+INSERT INTO users (
+  username,
+  role,
+  hashed_password,
+  full_name,
+  email
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: MarkEmailVerified :one
+UPDATE users
+SET is_email_verified = true
+WHERE username = $1
+RETURNING *;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2,
+  password_changed_at = now()
+WHERE username = $1
+RETURNING *;
\ No newline at end of file
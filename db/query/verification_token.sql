@@ -0,0 +1,20 @@
+-- name: CreateVerificationToken :one
+INSERT INTO verification_tokens (
+  username,
+  token,
+  expires_at
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetVerificationTokenForUpdate :one
+SELECT * FROM verification_tokens
+WHERE token = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: MarkVerificationTokenUsed :one
+UPDATE verification_tokens
+SET is_used = true
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
@@ -5,13 +5,20 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/qwerqy/mock_bank/util"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts 
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id
 `
 
 type AddAccountBalanceParams struct {
@@ -28,6 +35,12 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
 	)
 	return i, err
 }
@@ -40,7 +53,7 @@ INSERT INTO accounts (
 ) VALUES (
   $1, $2, $3
 )
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id
 `
 
 type CreateAccountParams struct {
@@ -58,6 +71,12 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
 	)
 	return i, err
 }
@@ -72,7 +91,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -85,12 +104,47 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
+	)
+	return i, err
+}
+
+const getAccountByOwnerCurrency = `-- name: GetAccountByOwnerCurrency :one
+SELECT id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1
+`
+
+type GetAccountByOwnerCurrencyParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetAccountByOwnerCurrency(ctx context.Context, arg GetAccountByOwnerCurrencyParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByOwnerCurrency, arg.Owner, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -104,24 +158,303 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
+	)
+	return i, err
+}
+
+const getAccountWithEntries = `-- name: GetAccountWithEntries :one
+SELECT
+  a.id, a.owner, a.balance, a.currency, a.created_at, a.nickname, a.status, a.metadata, a.daily_limit_override, a.max_transfer_override, a.public_id,
+  COALESCE((
+    SELECT json_agg(e ORDER BY e.id DESC)
+    FROM (
+      SELECT id, account_id, amount, created_at, type FROM entries
+      WHERE account_id = a.id
+      ORDER BY id DESC
+      LIMIT $1
+    ) e
+  ), '[]')::json AS entries
+FROM accounts a
+WHERE a.id = $2 LIMIT 1
+`
+
+type GetAccountWithEntriesParams struct {
+	EntryLimit int32 `json:"entry_limit"`
+	ID         int64 `json:"id"`
+}
+
+type GetAccountWithEntriesRow struct {
+	ID                  int64           `json:"id"`
+	Owner               string          `json:"owner"`
+	Balance             int64           `json:"balance"`
+	Currency            string          `json:"currency"`
+	CreatedAt           time.Time       `json:"created_at"`
+	Nickname            string          `json:"nickname"`
+	Status              string          `json:"status"`
+	Metadata            json.RawMessage `json:"metadata"`
+	DailyLimitOverride  util.NullInt64  `json:"daily_limit_override"`
+	MaxTransferOverride util.NullInt64  `json:"max_transfer_override"`
+	PublicID            uuid.UUID       `json:"public_id"`
+	Entries             json.RawMessage `json:"entries"`
+}
+
+func (q *Queries) GetAccountWithEntries(ctx context.Context, arg GetAccountWithEntriesParams) (GetAccountWithEntriesRow, error) {
+	row := q.db.QueryRowContext(ctx, getAccountWithEntries, arg.EntryLimit, arg.ID)
+	var i GetAccountWithEntriesRow
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
+		&i.Entries,
 	)
 	return i, err
 }
 
+const getAccountsByMetadataKey = `-- name: GetAccountsByMetadataKey :many
+SELECT id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id FROM accounts
+WHERE metadata ? $1::text
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type GetAccountsByMetadataKeyParams struct {
+	Key    string `json:"key"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) GetAccountsByMetadataKey(ctx context.Context, arg GetAccountsByMetadataKeyParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, getAccountsByMetadataKey, arg.Key, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Nickname,
+			&i.Status,
+			&i.Metadata,
+			&i.DailyLimitOverride,
+			&i.MaxTransferOverride,
+			&i.PublicID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountIDsByOwner = `-- name: ListAccountIDsByOwner :many
+SELECT id FROM accounts
+WHERE owner = $1
+ORDER BY id
+`
+
+func (q *Queries) ListAccountIDsByOwner(ctx context.Context, owner string) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountIDsByOwner, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountIDsByPublicID = `-- name: ListAccountIDsByPublicID :many
+SELECT id, public_id FROM accounts
+WHERE public_id = ANY($1::uuid[])
+`
+
+type ListAccountIDsByPublicIDRow struct {
+	ID       int64     `json:"id"`
+	PublicID uuid.UUID `json:"public_id"`
+}
+
+func (q *Queries) ListAccountIDsByPublicID(ctx context.Context, publicIds []uuid.UUID) ([]ListAccountIDsByPublicIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountIDsByPublicID, pq.Array(publicIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountIDsByPublicIDRow{}
+	for rows.Next() {
+		var i ListAccountIDsByPublicIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PublicID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountPublicIDs = `-- name: ListAccountPublicIDs :many
+SELECT id, public_id FROM accounts
+WHERE id = ANY($1::bigint[])
+`
+
+type ListAccountPublicIDsRow struct {
+	ID       int64     `json:"id"`
+	PublicID uuid.UUID `json:"public_id"`
+}
+
+func (q *Queries) ListAccountPublicIDs(ctx context.Context, ids []int64) ([]ListAccountPublicIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountPublicIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountPublicIDsRow{}
+	for rows.Next() {
+		var i ListAccountPublicIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PublicID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id FROM accounts
+WHERE ($1::varchar IS NULL OR owner = $1)
+AND ($2::timestamptz IS NULL OR created_at >= $2)
+AND ($3::timestamptz IS NULL OR created_at < $3)
+AND ($4::varchar IS NULL OR EXISTS (
+  SELECT 1 FROM account_labels
+  WHERE account_labels.account_id = accounts.id AND account_labels.label = $4
+))
 ORDER BY id
-LIMIT $1
-OFFSET $2
+LIMIT $5
+OFFSET $6
 `
 
 type ListAccountsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Owner         sql.NullString `json:"owner"`
+	CreatedAfter  util.NullTime  `json:"created_after"`
+	CreatedBefore util.NullTime  `json:"created_before"`
+	Label         sql.NullString `json:"label"`
+	Limit         int32          `json:"limit"`
+	Offset        int32          `json:"offset"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listAccounts,
+		arg.Owner,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Label,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Nickname,
+			&i.Status,
+			&i.Metadata,
+			&i.DailyLimitOverride,
+			&i.MaxTransferOverride,
+			&i.PublicID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchAccountsByOwner = `-- name: SearchAccountsByOwner :many
+SELECT id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id FROM accounts
+WHERE owner ILIKE '%' || $1::varchar || '%'
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type SearchAccountsByOwnerParams struct {
+	Query  string `json:"query"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) SearchAccountsByOwner(ctx context.Context, arg SearchAccountsByOwnerParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, searchAccountsByOwner, arg.Query, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -135,6 +468,12 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Nickname,
+			&i.Status,
+			&i.Metadata,
+			&i.DailyLimitOverride,
+			&i.MaxTransferOverride,
+			&i.PublicID,
 		); err != nil {
 			return nil, err
 		}
@@ -153,7 +492,7 @@ const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts 
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id
 `
 
 type UpdateAccountParams struct {
@@ -170,6 +509,141 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
+	)
+	return i, err
+}
+
+const updateAccountDetails = `-- name: UpdateAccountDetails :one
+UPDATE accounts
+SET nickname = COALESCE($1, nickname),
+  metadata = COALESCE($2, metadata)
+WHERE id = $3
+RETURNING id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id
+`
+
+type UpdateAccountDetailsParams struct {
+	Nickname sql.NullString  `json:"nickname"`
+	Metadata json.RawMessage `json:"metadata"`
+	ID       int64           `json:"id"`
+}
+
+func (q *Queries) UpdateAccountDetails(ctx context.Context, arg UpdateAccountDetailsParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountDetails, arg.Nickname, arg.Metadata, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
+	)
+	return i, err
+}
+
+const updateAccountLimitOverrides = `-- name: UpdateAccountLimitOverrides :one
+UPDATE accounts
+SET daily_limit_override = $1,
+  max_transfer_override = $2
+WHERE id = $3
+RETURNING id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id
+`
+
+type UpdateAccountLimitOverridesParams struct {
+	DailyLimitOverride  util.NullInt64 `json:"daily_limit_override"`
+	MaxTransferOverride util.NullInt64 `json:"max_transfer_override"`
+	ID                  int64          `json:"id"`
+}
+
+func (q *Queries) UpdateAccountLimitOverrides(ctx context.Context, arg UpdateAccountLimitOverridesParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountLimitOverrides, arg.DailyLimitOverride, arg.MaxTransferOverride, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
+	)
+	return i, err
+}
+
+const updateAccountOwner = `-- name: UpdateAccountOwner :one
+UPDATE accounts
+SET owner = users.username
+FROM users
+WHERE accounts.id = $1 AND users.username = $2
+RETURNING accounts.id, accounts.owner, accounts.balance, accounts.currency, accounts.created_at, accounts.nickname, accounts.status, accounts.metadata, accounts.daily_limit_override, accounts.max_transfer_override, accounts.public_id
+`
+
+type UpdateAccountOwnerParams struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+}
+
+func (q *Queries) UpdateAccountOwner(ctx context.Context, arg UpdateAccountOwnerParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountOwner, arg.ID, arg.Owner)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, nickname, status, metadata, daily_limit_override, max_transfer_override, public_id
+`
+
+type UpdateAccountStatusParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.ID, arg.Status)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Nickname,
+		&i.Status,
+		&i.Metadata,
+		&i.DailyLimitOverride,
+		&i.MaxTransferOverride,
+		&i.PublicID,
 	)
 	return i, err
 }
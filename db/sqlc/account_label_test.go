@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccountLabel(t *testing.T, account Account) AccountLabel {
+	arg := AddAccountLabelParams{
+		AccountID: account.ID,
+		Label:     util.RandomString(8),
+	}
+
+	label, err := testQueries.AddAccountLabel(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.AccountID, label.AccountID)
+	require.Equal(t, arg.Label, label.Label)
+	require.NotZero(t, label.CreatedAt)
+
+	return label
+}
+
+func TestAddAccountLabel(t *testing.T) {
+	account := createRandomAccount(t)
+	label := createRandomAccountLabel(t, account)
+
+	// the same label twice on one account is a conflict
+	_, err := testQueries.AddAccountLabel(context.Background(), AddAccountLabelParams{
+		AccountID: account.ID,
+		Label:     label.Label,
+	})
+	require.ErrorIs(t, ErrorCode(err), ErrUniqueViolation)
+}
+
+func TestListAccountLabels(t *testing.T) {
+	account := createRandomAccount(t)
+	for i := 0; i < 3; i++ {
+		createRandomAccountLabel(t, account)
+	}
+	createRandomAccountLabel(t, createRandomAccount(t))
+
+	labels, err := testQueries.ListAccountLabels(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, labels, 3)
+	for i, label := range labels {
+		require.Equal(t, account.ID, label.AccountID)
+		if i > 0 {
+			require.Less(t, labels[i-1].Label, label.Label)
+		}
+	}
+}
+
+func TestListAccountsByLabel(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	label := createRandomAccountLabel(t, account1)
+
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Label:  sql.NullString{String: label.Label, Valid: true},
+		Limit:  10,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account1.ID, accounts[0].ID)
+	require.NotEqual(t, account2.ID, accounts[0].ID)
+}
+
+func TestRemoveAccountLabel(t *testing.T) {
+	account := createRandomAccount(t)
+	label := createRandomAccountLabel(t, account)
+
+	arg := RemoveAccountLabelParams{
+		AccountID: account.ID,
+		Label:     label.Label,
+	}
+	removed, err := testQueries.RemoveAccountLabel(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, label.Label, removed.Label)
+
+	_, err = testQueries.RemoveAccountLabel(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	labels, err := testQueries.ListAccountLabels(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Empty(t, labels)
+}
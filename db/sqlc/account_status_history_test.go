@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAccountStatusChange(t *testing.T) {
+	account := createRandomAccount(t)
+
+	arg := CreateAccountStatusChangeParams{
+		AccountID:  account.ID,
+		FromStatus: AccountStatusActive,
+		ToStatus:   AccountStatusFrozen,
+		Reason:     util.RandomString(12),
+		ChangedBy:  util.RandomOwner(),
+	}
+
+	change, err := testQueries.CreateAccountStatusChange(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, change.ID)
+	require.Equal(t, arg.AccountID, change.AccountID)
+	require.Equal(t, arg.FromStatus, change.FromStatus)
+	require.Equal(t, arg.ToStatus, change.ToStatus)
+	require.Equal(t, arg.Reason, change.Reason)
+	require.Equal(t, arg.ChangedBy, change.ChangedBy)
+	require.NotZero(t, change.CreatedAt)
+}
+
+func TestListAccountStatusHistory(t *testing.T) {
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	var want []AccountStatusHistory
+	for _, status := range []string{AccountStatusFrozen, AccountStatusActive, AccountStatusFrozen} {
+		from := AccountStatusActive
+		if len(want) > 0 {
+			from = want[len(want)-1].ToStatus
+		}
+
+		change, err := testQueries.CreateAccountStatusChange(context.Background(), CreateAccountStatusChangeParams{
+			AccountID:  account.ID,
+			FromStatus: from,
+			ToStatus:   status,
+			Reason:     util.RandomString(12),
+			ChangedBy:  util.RandomOwner(),
+		})
+		require.NoError(t, err)
+		want = append(want, change)
+	}
+
+	history, err := testQueries.ListAccountStatusHistory(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, want, history)
+
+	history, err = testQueries.ListAccountStatusHistory(context.Background(), other.ID)
+	require.NoError(t, err)
+	require.Empty(t, history)
+}
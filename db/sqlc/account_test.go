@@ -3,16 +3,23 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/qwerqy/mock_bank/util"
 	"github.com/stretchr/testify/require"
 )
 
 func createRandomAccount(t *testing.T) Account {
+	user := createRandomUser(t)
+
 	arg := CreateAccountParams{
-		Owner:    util.RandomOwner(),
+		Owner:    user.Username,
 		Balance:  util.RandomMoney(),
 		Currency: util.RandomCurrency(),
 	}
@@ -24,9 +31,13 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, AccountStatusActive, account.Status)
+	require.False(t, account.DailyLimitOverride.Valid)
+	require.False(t, account.MaxTransferOverride.Valid)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
+	require.NotEqual(t, uuid.Nil, account.PublicID)
 
 	return account
 }
@@ -45,9 +56,133 @@ func TestGetAccount(t *testing.T) {
 	require.Equal(t, account1.Owner, account2.Owner)
 	require.Equal(t, account1.Balance, account2.Balance)
 	require.Equal(t, account1.Currency, account2.Currency)
+	require.Equal(t, account1.PublicID, account2.PublicID)
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
 
+func TestListAccountIDsByPublicID(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	require.NotEqual(t, account1.PublicID, account2.PublicID)
+
+	rows, err := testQueries.ListAccountIDsByPublicID(context.Background(), []uuid.UUID{account1.PublicID, account2.PublicID, uuid.New()})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ListAccountIDsByPublicIDRow{
+		{ID: account1.ID, PublicID: account1.PublicID},
+		{ID: account2.ID, PublicID: account2.PublicID},
+	}, rows)
+}
+
+func TestListAccountPublicIDs(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	rows, err := testQueries.ListAccountPublicIDs(context.Background(), []int64{account1.ID, account2.ID})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ListAccountPublicIDsRow{
+		{ID: account1.ID, PublicID: account1.PublicID},
+		{ID: account2.ID, PublicID: account2.PublicID},
+	}, rows)
+}
+
+func TestGetAccountWithEntries(t *testing.T) {
+	account := createRandomAccount(t)
+
+	row, err := testQueries.GetAccountWithEntries(context.Background(), GetAccountWithEntriesParams{
+		ID:         account.ID,
+		EntryLimit: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, row.ID)
+	require.Equal(t, account.Owner, row.Owner)
+	require.Equal(t, account.Balance, row.Balance)
+	require.JSONEq(t, `[]`, string(row.Entries))
+
+	var created []Entry
+	for i := 0; i < 3; i++ {
+		created = append(created, createRandomEntry(t, account.ID))
+	}
+
+	row, err = testQueries.GetAccountWithEntries(context.Background(), GetAccountWithEntriesParams{
+		ID:         account.ID,
+		EntryLimit: 2,
+	})
+	require.NoError(t, err)
+
+	// only the two most recent, newest first
+	var entries []Entry
+	require.NoError(t, json.Unmarshal(row.Entries, &entries))
+	require.Len(t, entries, 2)
+	for i, entry := range entries {
+		want := created[len(created)-1-i]
+		require.Equal(t, want.ID, entry.ID)
+		require.Equal(t, want.Amount, entry.Amount)
+		require.Equal(t, want.Type, entry.Type)
+		require.WithinDuration(t, want.CreatedAt, entry.CreatedAt, time.Second)
+	}
+
+	_, err = testQueries.GetAccountWithEntries(context.Background(), GetAccountWithEntriesParams{
+		ID:         -1,
+		EntryLimit: 2,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestGetAccountByOwnerCurrency(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	arg := GetAccountByOwnerCurrencyParams{
+		Owner:    account1.Owner,
+		Currency: account1.Currency,
+	}
+
+	account2, err := testQueries.GetAccountByOwnerCurrency(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+}
+
+func TestCreateAccountDuplicateOwnerCurrency(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	arg := CreateAccountParams{
+		Owner:    account1.Owner,
+		Balance:  util.RandomMoney(),
+		Currency: account1.Currency,
+	}
+
+	account2, err := testQueries.CreateAccount(context.Background(), arg)
+	require.Error(t, err)
+	require.Empty(t, account2)
+
+	pqErr, ok := err.(*pq.Error)
+	require.True(t, ok)
+	require.Equal(t, "unique_violation", pqErr.Code.Name())
+}
+
+func TestSearchAccountsByOwner(t *testing.T) {
+	account := createRandomAccount(t)
+
+	arg := SearchAccountsByOwnerParams{
+		Query:  strings.ToUpper(account.Owner[1:5]),
+		Limit:  10,
+		Offset: 0,
+	}
+
+	accounts, err := testQueries.SearchAccountsByOwner(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, accounts)
+
+	for _, a := range accounts {
+		require.Contains(t, a.Owner, account.Owner[1:5])
+	}
+
+	// an escaped wildcard only matches itself, and no username holds a %
+	arg.Query = `\%`
+	accounts, err = testQueries.SearchAccountsByOwner(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+}
+
 func TestGetAccountForUpdate(t *testing.T) {
 	account1 := createRandomAccount(t)
 	account2, err := testQueries.GetAccountForUpdate(context.Background(), account1.ID)
@@ -109,3 +244,195 @@ func TestListAccounts(t *testing.T) {
 		require.NotEmpty(t, account)
 	}
 }
+
+func TestListAccountsByOwner(t *testing.T) {
+	var lastAccount Account
+	for i := 0; i < 10; i++ {
+		lastAccount = createRandomAccount(t)
+	}
+
+	arg := ListAccountsParams{
+		Owner:  sql.NullString{String: lastAccount.Owner, Valid: true},
+		Limit:  5,
+		Offset: 0,
+	}
+
+	accounts, err := testQueries.ListAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, accounts)
+
+	for _, account := range accounts {
+		require.Equal(t, lastAccount.Owner, account.Owner)
+	}
+}
+
+func TestListAccountIDsByOwner(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomAccount(t)
+
+	var want []int64
+	for _, currency := range []string{util.USD, util.EUR} {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Currency: currency,
+		})
+		require.NoError(t, err)
+		want = append(want, account.ID)
+	}
+
+	ids, err := testQueries.ListAccountIDsByOwner(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, want, ids)
+
+	ids, err = testQueries.ListAccountIDsByOwner(context.Background(), util.RandomOwner())
+	require.NoError(t, err)
+	require.Empty(t, ids)
+}
+
+func TestListAccountsCreatedRange(t *testing.T) {
+	// push the accounts far into the past so rows from other tests never
+	// fall inside the range
+	base := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var accounts []Account
+	for i := 0; i < 5; i++ {
+		account := createRandomAccount(t)
+		createdAt := base.Add(time.Duration(i) * time.Hour)
+
+		_, err := testDB.ExecContext(context.Background(), "UPDATE accounts SET created_at = $1 WHERE id = $2", createdAt, account.ID)
+		require.NoError(t, err)
+
+		account.CreatedAt = createdAt
+		accounts = append(accounts, account)
+	}
+
+	arg := ListAccountsParams{
+		CreatedAfter:  util.NewNullTime(accounts[1].CreatedAt),
+		CreatedBefore: util.NewNullTime(accounts[4].CreatedAt),
+		Limit:         10,
+		Offset:        0,
+	}
+
+	result, err := testQueries.ListAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+
+	for i, account := range result {
+		require.Equal(t, accounts[i+1].ID, account.ID)
+		require.False(t, account.CreatedAt.Before(arg.CreatedAfter.Time))
+		require.True(t, account.CreatedAt.Before(arg.CreatedBefore.Time))
+	}
+}
+
+func TestUpdateAccountDetails(t *testing.T) {
+	account1 := createRandomAccount(t)
+	require.Empty(t, account1.Nickname)
+
+	arg := UpdateAccountDetailsParams{
+		ID:       account1.ID,
+		Nickname: sql.NullString{String: util.RandomString(8), Valid: true},
+	}
+
+	account2, err := testQueries.UpdateAccountDetails(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Nickname.String, account2.Nickname)
+	require.Equal(t, account1.Balance, account2.Balance)
+
+	// an unset nickname leaves the stored one alone
+	account3, err := testQueries.UpdateAccountDetails(context.Background(), UpdateAccountDetailsParams{
+		ID: account1.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account2.Nickname, account3.Nickname)
+}
+
+func TestUpdateAccountLimitOverrides(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	account2, err := testQueries.UpdateAccountLimitOverrides(context.Background(), UpdateAccountLimitOverridesParams{
+		ID:                  account1.ID,
+		DailyLimitOverride:  util.NewNullInt64(5000),
+		MaxTransferOverride: util.NewNullInt64(2000),
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.NewNullInt64(5000), account2.DailyLimitOverride)
+	require.Equal(t, util.NewNullInt64(2000), account2.MaxTransferOverride)
+	require.Equal(t, account1.Balance, account2.Balance)
+
+	// null clears an override
+	account3, err := testQueries.UpdateAccountLimitOverrides(context.Background(), UpdateAccountLimitOverridesParams{
+		ID:                 account1.ID,
+		DailyLimitOverride: util.NewNullInt64(5000),
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.NewNullInt64(5000), account3.DailyLimitOverride)
+	require.False(t, account3.MaxTransferOverride.Valid)
+}
+
+func TestUpdateAccountMetadata(t *testing.T) {
+	account1 := createRandomAccount(t)
+	require.JSONEq(t, `{}`, string(account1.Metadata))
+
+	arg := UpdateAccountDetailsParams{
+		ID:       account1.ID,
+		Metadata: json.RawMessage(`{"crm_id": "C-1042", "tier": 2}`),
+	}
+
+	account2, err := testQueries.UpdateAccountDetails(context.Background(), arg)
+	require.NoError(t, err)
+	require.JSONEq(t, string(arg.Metadata), string(account2.Metadata))
+	require.Equal(t, account1.Nickname, account2.Nickname)
+
+	// unset metadata leaves the stored object alone
+	account3, err := testQueries.UpdateAccountDetails(context.Background(), UpdateAccountDetailsParams{
+		ID:       account1.ID,
+		Nickname: sql.NullString{String: util.RandomString(8), Valid: true},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, string(arg.Metadata), string(account3.Metadata))
+}
+
+func TestGetAccountsByMetadataKey(t *testing.T) {
+	key := "key_" + util.RandomString(8)
+
+	account1 := createRandomAccount(t)
+	createRandomAccount(t)
+	_, err := testQueries.UpdateAccountDetails(context.Background(), UpdateAccountDetailsParams{
+		ID:       account1.ID,
+		Metadata: json.RawMessage(fmt.Sprintf(`{%q: "x"}`, key)),
+	})
+	require.NoError(t, err)
+
+	accounts, err := testQueries.GetAccountsByMetadataKey(context.Background(), GetAccountsByMetadataKeyParams{
+		Key:   key,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account1.ID, accounts[0].ID)
+}
+
+func TestUpdateAccountOwner(t *testing.T) {
+	account1 := createRandomAccount(t)
+	user := createRandomUser(t)
+
+	account2, err := testQueries.UpdateAccountOwner(context.Background(), UpdateAccountOwnerParams{
+		ID:    account1.ID,
+		Owner: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, user.Username, account2.Owner)
+	require.Equal(t, account1.Balance, account2.Balance)
+
+	// an unknown user matches no row, leaving the account alone
+	_, err = testQueries.UpdateAccountOwner(context.Background(), UpdateAccountOwnerParams{
+		ID:    account1.ID,
+		Owner: util.RandomOwner(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	account3, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, account3.Owner)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAuditLog(t *testing.T) AuditLog {
+	user := createRandomUser(t)
+
+	arg := CreateAuditLogParams{
+		Username:      user.Username,
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1, 1000),
+		Amount:        util.RandomMoney(),
+		Reason:        ErrAccountFrozen.Error(),
+	}
+
+	log, err := testQueries.CreateAuditLog(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, log)
+
+	require.Equal(t, arg.Username, log.Username)
+	require.Equal(t, arg.FromAccountID, log.FromAccountID)
+	require.Equal(t, arg.ToAccountID, log.ToAccountID)
+	require.Equal(t, arg.Amount, log.Amount)
+	require.Equal(t, arg.Reason, log.Reason)
+	require.NotZero(t, log.ID)
+	require.NotZero(t, log.CreatedAt)
+
+	return log
+}
+
+func TestCreateAuditLog(t *testing.T) {
+	createRandomAuditLog(t)
+}
+
+func TestListAuditLogs(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		createRandomAuditLog(t)
+	}
+
+	arg := ListAuditLogsParams{
+		Limit:  5,
+		Offset: 5,
+	}
+
+	logs, err := testQueries.ListAuditLogs(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, logs, 5)
+
+	for _, log := range logs {
+		require.NotEmpty(t, log)
+	}
+}
@@ -0,0 +1,103 @@
+package db
+
+import "sync"
+
+// balanceSubscriberBuffer is how many updates a subscriber may fall behind
+// before further updates to it are dropped.
+const balanceSubscriberBuffer = 16
+
+// BalanceUpdate is the new balance of an account after a transfer moved
+// money in or out of it, with the ledger entry that did so.
+type BalanceUpdate struct {
+	AccountID  int64  `json:"account_id"`
+	Balance    int64  `json:"balance"`
+	Currency   string `json:"currency"`
+	TransferID int64  `json:"transfer_id"`
+	Entry      Entry  `json:"entry"`
+}
+
+// BalanceBroker fans balance updates out to in-process subscribers, keyed
+// by account ID.
+type BalanceBroker struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan BalanceUpdate]struct{}
+}
+
+func NewBalanceBroker() *BalanceBroker {
+	return &BalanceBroker{
+		subscribers: make(map[int64]map[chan BalanceUpdate]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving the updates of an account, and a
+// function that unsubscribes and closes the channel.
+func (broker *BalanceBroker) Subscribe(accountID int64) (<-chan BalanceUpdate, func()) {
+	ch := make(chan BalanceUpdate, balanceSubscriberBuffer)
+
+	broker.mu.Lock()
+	if broker.subscribers[accountID] == nil {
+		broker.subscribers[accountID] = make(map[chan BalanceUpdate]struct{})
+	}
+	broker.subscribers[accountID][ch] = struct{}{}
+	broker.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			broker.mu.Lock()
+			defer broker.mu.Unlock()
+
+			delete(broker.subscribers[accountID], ch)
+			if len(broker.subscribers[accountID]) == 0 {
+				delete(broker.subscribers, accountID)
+			}
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish hands the update to every subscriber of its account without
+// blocking. A subscriber whose buffer is full misses the update.
+func (broker *BalanceBroker) Publish(update BalanceUpdate) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for ch := range broker.subscribers[update.AccountID] {
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+}
+
+// SubscribeBalance subscribes to the balance updates of an account. See
+// BalanceBroker.Subscribe.
+func (store *SQLStore) SubscribeBalance(accountID int64) (<-chan BalanceUpdate, func()) {
+	return store.balances.Subscribe(accountID)
+}
+
+// publishTransfer announces the entries a committed transfer wrote and the
+// balances it left behind. A fee is announced as an entry of its own.
+func (store *SQLStore) publishTransfer(result TransferTxResult) {
+	if store.balances == nil {
+		return
+	}
+
+	publish := func(account Account, entry Entry) {
+		store.balances.Publish(BalanceUpdate{
+			AccountID:  account.ID,
+			Balance:    account.Balance,
+			Currency:   account.Currency,
+			TransferID: result.Transfer.ID,
+			Entry:      entry,
+		})
+	}
+
+	publish(result.FromAccount, result.FromEntry)
+	if result.Fee > 0 {
+		publish(result.FromAccount, result.FeeEntry)
+	}
+	publish(result.ToAccount, result.ToEntry)
+}
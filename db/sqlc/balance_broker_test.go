@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceBroker(t *testing.T) {
+	broker := NewBalanceBroker()
+
+	updates, unsubscribe := broker.Subscribe(1)
+	others, unsubscribeOthers := broker.Subscribe(2)
+	defer unsubscribeOthers()
+
+	update := BalanceUpdate{AccountID: 1, Balance: 90, Currency: "USD", TransferID: 7}
+	broker.Publish(update)
+
+	require.Equal(t, update, <-updates)
+	require.Empty(t, others)
+
+	// a subscriber that falls behind misses updates rather than blocking
+	for i := 0; i < balanceSubscriberBuffer+5; i++ {
+		broker.Publish(update)
+	}
+	require.Len(t, updates, balanceSubscriberBuffer)
+
+	unsubscribe()
+	unsubscribe()
+	for range updates {
+	}
+	broker.Publish(update)
+
+	broker.mu.Lock()
+	require.NotContains(t, broker.subscribers, int64(1))
+	broker.mu.Unlock()
+}
+
+func TestTransferTxPublishesBalances(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
+
+	updates1, unsubscribe1 := store.SubscribeBalance(account1.ID)
+	defer unsubscribe1()
+	updates2, unsubscribe2 := store.SubscribeBalance(account2.ID)
+	defer unsubscribe2()
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		updates <-chan BalanceUpdate
+		account Account
+		entry   Entry
+	}{
+		{updates: updates1, account: result.FromAccount, entry: result.FromEntry},
+		{updates: updates2, account: result.ToAccount, entry: result.ToEntry},
+	} {
+		select {
+		case update := <-tc.updates:
+			require.Equal(t, tc.account.ID, update.AccountID)
+			require.Equal(t, tc.account.Balance, update.Balance)
+			require.Equal(t, result.Transfer.ID, update.TransferID)
+			require.Equal(t, tc.entry.ID, update.Entry.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("no balance update for account %d", tc.account.ID)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/qwerqy/mock_bank/util"
+)
+
+// Connect opens the connection pool described by config and wraps it in a
+// Store. Pool limits left at zero keep the database/sql defaults.
+func Connect(config util.Config) (*sql.DB, Store, error) {
+	fee := TransferFee{
+		Flat:        config.TransferFeeFlat,
+		BasisPoints: config.TransferFeeBasisPoints,
+	}
+	feeAccounts, err := util.ParseFeeAccounts(config.FeeAccounts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fee.Flat > 0 || fee.BasisPoints > 0 {
+		for _, currency := range util.SupportedCurrencies() {
+			if _, ok := feeAccounts[currency.Code]; !ok {
+				return nil, nil, fmt.Errorf("a transfer fee needs a fee account in FEE_ACCOUNTS for %s", currency.Code)
+			}
+		}
+	}
+
+	balanceCaps, err := util.ParseBalanceCaps(config.BalanceCaps)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transferIsolation, err := ParseIsolationLevel(config.TransferIsolationLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := openPool(config, config.DBSource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	store := &SQLStore{
+		db:                 conn,
+		maxTxAttempts:      config.TxMaxAttempts,
+		transferIsolation:  transferIsolation,
+		dailyTransferLimit: config.DailyTransferLimit,
+		balanceCaps:        balanceCaps,
+		slowQueryThreshold: config.SlowQueryThreshold,
+		transferFee:        fee,
+		feeAccounts:        feeAccounts,
+		holdTTL:            config.HoldTTL,
+		maxHoldsPerAccount: config.MaxHoldsPerAccount,
+		balances:           NewBalanceBroker(),
+	}
+	store.Queries = store.newQueries(conn)
+
+	err = checkFeeAccounts(context.Background(), store.Queries, feeAccounts)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	if config.ReplicaDBSource != "" {
+		replica, err := openPool(config, config.ReplicaDBSource)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		store.replica = store.newQueries(replica)
+	}
+
+	return conn, store, nil
+}
+
+// checkFeeAccounts makes sure every fee account exists and holds the currency
+// it collects fees in.
+func checkFeeAccounts(ctx context.Context, q *Queries, feeAccounts map[string]int64) error {
+	for currency, id := range feeAccounts {
+		account, err := q.GetAccount(ctx, id)
+		if err != nil {
+			return fmt.Errorf("fee account %d for %s: %w", id, currency, err)
+		}
+		if account.Currency != currency {
+			return fmt.Errorf("fee account %d for %s holds %s", id, currency, account.Currency)
+		}
+	}
+	return nil
+}
+
+// openPool opens a connection pool to source with the pool limits of config.
+func openPool(config util.Config, source string) (*sql.DB, error) {
+	if config.DBDriver == "postgres" {
+		var err error
+		source, err = utcSource(source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := sql.Open(config.DBDriver, source)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetMaxOpenConns(config.MaxOpenConns)
+	if config.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	conn.SetConnMaxLifetime(config.ConnMaxLifetime)
+	return conn, nil
+}
+
+// utcSource pins the session time zone of every connection to UTC, so
+// timestamptz columns come back in UTC whatever the server's default is.
+// Both the URL and the key/value forms of a Postgres DSN are understood.
+func utcSource(source string) (string, error) {
+	if !strings.HasPrefix(source, "postgres://") && !strings.HasPrefix(source, "postgresql://") {
+		return strings.TrimSpace(source + " timezone=UTC"), nil
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("timezone", "UTC")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
@@ -5,37 +5,42 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  type
 ) VALUES (
-  $1, $2
+  $1, $2, $3
 )
-RETURNING id, account_id, amount, created_at
+RETURNING id, account_id, amount, created_at, type
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID int64  `json:"account_id"`
+	Amount    int64  `json:"amount"`
+	Type      string `json:"type"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount, arg.Type)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Type,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, type FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -47,26 +52,76 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Type,
 	)
 	return i, err
 }
 
+const listEntriesAfter = `-- name: ListEntriesAfter :many
+SELECT id, account_id, amount, created_at, type FROM entries
+WHERE account_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListEntriesAfterParams struct {
+	AccountID int64 `json:"account_id"`
+	AfterID   int64 `json:"after_id"`
+	Limit     int32 `json:"limit"`
+}
+
+func (q *Queries) ListEntriesAfter(ctx context.Context, arg ListEntriesAfterParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listEntriesAfter, arg.AccountID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Type,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntry = `-- name: ListEntry :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, type FROM entries
 WHERE account_id = $1
+  AND ($2::varchar IS NULL OR type = $2)
 ORDER BY id
-LIMIT $2
-OFFSET $3
+LIMIT $3
+OFFSET $4
 `
 
 type ListEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Limit     int32 `json:"limit"`
-	Offset    int32 `json:"offset"`
+	AccountID int64          `json:"account_id"`
+	Type      sql.NullString `json:"type"`
+	Limit     int32          `json:"limit"`
+	Offset    int32          `json:"offset"`
 }
 
 func (q *Queries) ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntry, arg.AccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listEntry,
+		arg.AccountID,
+		arg.Type,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -79,6 +134,7 @@ func (q *Queries) ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry,
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Type,
 		); err != nil {
 			return nil, err
 		}
@@ -92,3 +148,38 @@ func (q *Queries) ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry,
 	}
 	return items, nil
 }
+
+const sumEntries = `-- name: SumEntries :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM entries
+WHERE account_id = $1
+`
+
+func (q *Queries) SumEntries(ctx context.Context, accountID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumEntries, accountID)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const sumFeesCharged = `-- name: SumFeesCharged :one
+SELECT COALESCE(SUM(-amount), 0)::bigint AS total FROM entries
+WHERE
+  account_id = $1 AND
+  type = 'fee' AND
+  amount < 0 AND
+  created_at >= $2 AND
+  created_at < $3
+`
+
+type SumFeesChargedParams struct {
+	AccountID int64     `json:"account_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+func (q *Queries) SumFeesCharged(ctx context.Context, arg SumFeesChargedParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumFeesCharged, arg.AccountID, arg.StartTime, arg.EndTime)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
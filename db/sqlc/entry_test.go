@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -10,9 +11,14 @@ import (
 )
 
 func createRandomEntry(t *testing.T, AccountId int64) Entry {
+	return createRandomEntryOfType(t, AccountId, EntryTypeAdjustment)
+}
+
+func createRandomEntryOfType(t *testing.T, AccountId int64, entryType string) Entry {
 	arg := CreateEntryParams{
 		AccountID: AccountId,
 		Amount:    util.RandomMoney(),
+		Type:      entryType,
 	}
 
 	entry, err := testQueries.CreateEntry(context.Background(), arg)
@@ -21,6 +27,7 @@ func createRandomEntry(t *testing.T, AccountId int64) Entry {
 
 	require.Equal(t, entry.AccountID, arg.AccountID)
 	require.Equal(t, entry.Amount, arg.Amount)
+	require.Equal(t, entry.Type, arg.Type)
 
 	return entry
 }
@@ -72,3 +79,105 @@ func TestListEntry(t *testing.T) {
 		require.NotEmpty(t, entry)
 	}
 }
+
+func TestListEntryByType(t *testing.T) {
+	account := createRandomAccount(t)
+
+	var fees []Entry
+	for i := 0; i < 3; i++ {
+		createRandomEntryOfType(t, account.ID, EntryTypeTransferCredit)
+		fees = append(fees, createRandomEntryOfType(t, account.ID, EntryTypeFee))
+	}
+
+	entries, err := testQueries.ListEntry(context.Background(), ListEntryParams{
+		AccountID: account.ID,
+		Type:      sql.NullString{String: EntryTypeFee, Valid: true},
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Equal(t, fees, entries)
+
+	// without a type every entry is listed
+	entries, err = testQueries.ListEntry(context.Background(), ListEntryParams{
+		AccountID: account.ID,
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+}
+
+func TestListEntriesAfter(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	var entries []Entry
+	for i := 0; i < 5; i++ {
+		entries = append(entries, createRandomEntry(t, account1.ID))
+	}
+
+	arg := ListEntriesAfterParams{
+		AccountID: account1.ID,
+		AfterID:   entries[1].ID,
+		Limit:     2,
+	}
+
+	result, err := testQueries.ListEntriesAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	require.Equal(t, entries[2].ID, result[0].ID)
+	require.Equal(t, entries[3].ID, result[1].ID)
+}
+
+func TestSumEntries(t *testing.T) {
+	account := createRandomAccount(t)
+
+	total, err := testQueries.SumEntries(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Zero(t, total)
+
+	var want int64
+	for i := 0; i < 5; i++ {
+		want += createRandomEntry(t, account.ID).Amount
+	}
+
+	total, err = testQueries.SumEntries(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, want, total)
+}
+
+func TestSumFeesCharged(t *testing.T) {
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+	start := time.Now().Add(-time.Minute)
+
+	for _, arg := range []CreateEntryParams{
+		{AccountID: account.ID, Amount: -5, Type: EntryTypeFee},
+		{AccountID: account.ID, Amount: -7, Type: EntryTypeFee},
+		// a fee the account collected is not one it was charged
+		{AccountID: account.ID, Amount: 3, Type: EntryTypeFee},
+		// nor is any other debit, or another account's fee
+		{AccountID: account.ID, Amount: -100, Type: EntryTypeTransferDebit},
+		{AccountID: other.ID, Amount: -11, Type: EntryTypeFee},
+	} {
+		_, err := testQueries.CreateEntry(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	total, err := testQueries.SumFeesCharged(context.Background(), SumFeesChargedParams{
+		AccountID: account.ID,
+		StartTime: start,
+		EndTime:   time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(12), total)
+
+	// the fees fall outside a range that ends before they were charged
+	total, err = testQueries.SumFeesCharged(context.Background(), SumFeesChargedParams{
+		AccountID: account.ID,
+		StartTime: start.Add(-time.Hour),
+		EndTime:   start,
+	})
+	require.NoError(t, err)
+	require.Zero(t, total)
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes the store tells apart.
+const (
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+)
+
+// ErrRecordNotFound is returned when a query finds no row. It is
+// sql.ErrNoRows, so callers outside this package need not import
+// database/sql to check for it.
+var ErrRecordNotFound = sql.ErrNoRows
+
+var (
+	ErrUniqueViolation     = errors.New("record already exists")
+	ErrForeignKeyViolation = errors.New("record references a row that does not exist")
+)
+
+// ErrorCode classifies err into one of the sentinel errors above, or returns
+// nil when it is none of them. Wrapped errors are unwrapped.
+func ErrorCode(err error) error {
+	if errors.Is(err, ErrRecordNotFound) {
+		return ErrRecordNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case UniqueViolation:
+			return ErrUniqueViolation
+		case ForeignKeyViolation:
+			return ErrForeignKeyViolation
+		}
+	}
+
+	return nil
+}
+
+// ErrorConstraint returns the name of the constraint a Postgres error
+// violated, or "" when err is not a constraint violation.
+func ErrorConstraint(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCode(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "NoRows", err: sql.ErrNoRows, want: ErrRecordNotFound},
+		{name: "WrappedNoRows", err: fmt.Errorf("get account: %w", sql.ErrNoRows), want: ErrRecordNotFound},
+		{name: "UniqueViolation", err: &pq.Error{Code: "23505"}, want: ErrUniqueViolation},
+		{name: "WrappedUniqueViolation", err: fmt.Errorf("tx err: %w", &pq.Error{Code: "23505"}), want: ErrUniqueViolation},
+		{name: "ForeignKeyViolation", err: &pq.Error{Code: "23503"}, want: ErrForeignKeyViolation},
+		{name: "OtherPostgresError", err: &pq.Error{Code: "40001"}, want: nil},
+		{name: "ConnDone", err: sql.ErrConnDone, want: nil},
+		{name: "Other", err: errors.New("boom"), want: nil},
+		{name: "Nil", err: nil, want: nil},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, ErrorCode(tc.err))
+		})
+	}
+}
+
+func TestErrorConstraint(t *testing.T) {
+	err := fmt.Errorf("tx err: %w", &pq.Error{Code: "23503", Constraint: "transfers_to_account_id_fkey"})
+	require.Equal(t, "transfers_to_account_id_fkey", ErrorConstraint(err))
+
+	require.Empty(t, ErrorConstraint(sql.ErrNoRows))
+	require.Empty(t, ErrorConstraint(nil))
+}
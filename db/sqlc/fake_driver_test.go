@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+)
+
+// fakeTxDriver is a database/sql connector for testing how the store handles
+// transactions without a database. It records each transaction it begins
+// and how it ended, and answers every query with the single row query
+// returns.
+type fakeTxDriver struct {
+	// beginErr, when set, fails every transaction as it is begun
+	beginErr error
+	// commitErr, when set, fails every commit
+	commitErr error
+	query     func(query string) ([]driver.Value, error)
+
+	begun      []driver.TxOptions
+	committed  int
+	rolledBack int
+}
+
+func (d *fakeTxDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeTxConn{driver: d}, nil
+}
+
+func (d *fakeTxDriver) Driver() driver.Driver {
+	return nil
+}
+
+type fakeTxConn struct {
+	driver *fakeTxDriver
+}
+
+func (c *fakeTxConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeTxConn) Close() error {
+	return nil
+}
+
+func (c *fakeTxConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeTxConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.driver.begun = append(c.driver.begun, opts)
+	if c.driver.beginErr != nil {
+		return nil, c.driver.beginErr
+	}
+	return fakeTx{driver: c.driver}, nil
+}
+
+func (c *fakeTxConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.driver.query == nil {
+		return nil, errors.New("not supported")
+	}
+	row, err := c.driver.query(query)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{row: row}, nil
+}
+
+type fakeTx struct {
+	driver *fakeTxDriver
+}
+
+func (tx fakeTx) Commit() error {
+	if tx.driver.commitErr != nil {
+		return tx.driver.commitErr
+	}
+	tx.driver.committed++
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.driver.rolledBack++
+	return nil
+}
+
+// fakeRows holds a single row. Its columns are unnamed, Scan only needs to
+// know how many there are.
+type fakeRows struct {
+	row  []driver.Value
+	done bool
+}
+
+func (r *fakeRows) Columns() []string {
+	return make([]string, len(r.row))
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	copy(dest, r.row)
+	r.done = true
+	return nil
+}
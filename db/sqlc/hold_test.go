@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomHold(t *testing.T, fromAccount, toAccount Account, amount int64, expiresAt time.Time) Hold {
+	arg := CreateHoldParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		Description:   "card payment",
+		ExpiresAt:     expiresAt,
+	}
+
+	hold, err := testQueries.CreateHold(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, hold)
+
+	require.Equal(t, arg.FromAccountID, hold.FromAccountID)
+	require.Equal(t, arg.ToAccountID, hold.ToAccountID)
+	require.Equal(t, arg.Amount, hold.Amount)
+	require.Equal(t, arg.Description, hold.Description)
+	require.Equal(t, HoldStatusHeld, hold.Status)
+	require.False(t, hold.TransferID.Valid)
+	require.WithinDuration(t, arg.ExpiresAt, hold.ExpiresAt, time.Second)
+	require.NotZero(t, hold.ID)
+	require.NotZero(t, hold.CreatedAt)
+
+	return hold
+}
+
+func TestCreateHold(t *testing.T) {
+	createRandomHold(t, createRandomAccount(t), createRandomAccount(t), 10, time.Now().Add(time.Hour))
+}
+
+func TestGetHold(t *testing.T) {
+	hold1 := createRandomHold(t, createRandomAccount(t), createRandomAccount(t), 10, time.Now().Add(time.Hour))
+
+	hold2, err := testQueries.GetHold(context.Background(), hold1.ID)
+	require.NoError(t, err)
+	require.Equal(t, hold1.ID, hold2.ID)
+	require.Equal(t, hold1.Amount, hold2.Amount)
+	require.WithinDuration(t, hold1.ExpiresAt, hold2.ExpiresAt, time.Second)
+}
+
+func TestSumActiveHolds(t *testing.T) {
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	createRandomHold(t, account, other, 10, time.Now().Add(time.Hour))
+	createRandomHold(t, account, other, 20, time.Now().Add(time.Hour))
+	// neither an expired nor a voided hold counts
+	createRandomHold(t, account, other, 40, time.Now().Add(-time.Second))
+	voided := createRandomHold(t, account, other, 80, time.Now().Add(time.Hour))
+	_, err := testQueries.UpdateHoldStatus(context.Background(), UpdateHoldStatusParams{
+		Status: HoldStatusVoided,
+		ID:     voided.ID,
+	})
+	require.NoError(t, err)
+
+	total, err := testQueries.SumActiveHolds(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(30), total)
+}
+
+func TestCountActiveHolds(t *testing.T) {
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	createRandomHold(t, account, other, 10, time.Now().Add(time.Hour))
+	createRandomHold(t, account, other, 20, time.Now().Add(time.Hour))
+	// neither an expired nor a voided hold counts
+	createRandomHold(t, account, other, 40, time.Now().Add(-time.Second))
+	voided := createRandomHold(t, account, other, 80, time.Now().Add(time.Hour))
+	_, err := testQueries.UpdateHoldStatus(context.Background(), UpdateHoldStatusParams{
+		Status: HoldStatusVoided,
+		ID:     voided.ID,
+	})
+	require.NoError(t, err)
+
+	count, err := testQueries.CountActiveHolds(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+}
+
+func TestUpdateHoldStatus(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	hold := createRandomHold(t, account1, account2, 10, time.Now().Add(time.Hour))
+	transfer := createRandomTransfer(t, account1.ID, account2.ID)
+
+	updated, err := testQueries.UpdateHoldStatus(context.Background(), UpdateHoldStatusParams{
+		Status:     HoldStatusCaptured,
+		TransferID: util.NewNullInt64(transfer.ID),
+		ID:         hold.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, updated.Status)
+	require.Equal(t, transfer.ID, updated.TransferID.Int64)
+}
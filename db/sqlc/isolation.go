@@ -0,0 +1,29 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ParseIsolationLevel reads a transaction isolation level written as
+// "read committed", "repeatable read" or "serializable", in any case and with
+// underscores or dashes in place of the space. Empty means read committed,
+// Postgres's own default.
+//
+// Repeatable read and serializable transactions fail with a serialization
+// error when they conflict, so only run them under retryTx.
+func ParseIsolationLevel(s string) (sql.IsolationLevel, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(name)
+
+	switch name {
+	case "", "read committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	}
+	return 0, fmt.Errorf("unsupported transaction isolation level %q", s)
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIsolationLevel(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  sql.IsolationLevel
+	}{
+		{input: "", want: sql.LevelReadCommitted},
+		{input: "read committed", want: sql.LevelReadCommitted},
+		{input: "READ_COMMITTED", want: sql.LevelReadCommitted},
+		{input: "repeatable-read", want: sql.LevelRepeatableRead},
+		{input: " Serializable ", want: sql.LevelSerializable},
+	}
+
+	for _, tc := range testCases {
+		level, err := ParseIsolationLevel(tc.input)
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.want, level, tc.input)
+	}
+
+	for _, input := range []string{"read uncommitted", "snapshot", "linearizable"} {
+		_, err := ParseIsolationLevel(input)
+		require.Error(t, err, input)
+	}
+}
+
+// errBeginRecorded stops a transaction as soon as it has been begun, so a
+// test can inspect how it was begun without a database.
+var errBeginRecorded = errors.New("begin recorded")
+
+func TestTransferTxIsolationLevel(t *testing.T) {
+	recorder := &fakeTxDriver{beginErr: errBeginRecorded}
+	conn := sql.OpenDB(recorder)
+	defer conn.Close()
+
+	for _, level := range []sql.IsolationLevel{sql.LevelReadCommitted, sql.LevelSerializable} {
+		store := NewStore(conn).(*SQLStore)
+		store.maxTxAttempts = 1
+		store.transferIsolation = level
+
+		recorder.begun = nil
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: 1,
+			ToAccountID:   2,
+			Amount:        10,
+		})
+		require.ErrorIs(t, err, errBeginRecorded)
+
+		require.Len(t, recorder.begun, 1)
+		require.Equal(t, driver.IsolationLevel(level), recorder.begun[0].Isolation)
+		require.False(t, recorder.begun[0].ReadOnly)
+	}
+}
+
+func TestNewStoreReadCommitted(t *testing.T) {
+	store := NewStore(nil).(*SQLStore)
+	require.Equal(t, sql.LevelReadCommitted, store.transferIsolation)
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/qwerqy/mock_bank/db/migration"
+)
+
+// SchemaVersion is the migration this build expects the database to be at.
+// Bump it with every new migration.
+const SchemaVersion = 22
+
+// migrationLockID keys the advisory lock that keeps two servers starting at
+// once from applying the same migration twice.
+const migrationLockID = 7_384_112_909
+
+var migrationFileName = regexp.MustCompile(`^([0-9]+)_.+\.up\.sql$`)
+
+type migrationFile struct {
+	version int64
+	name    string
+}
+
+// RunMigrations applies the embedded up migrations that the database at dsn
+// has not seen yet. It shares the schema_migrations table with the migrate
+// CLI behind `make migrateup`, so either can pick up where the other left
+// off, and it does nothing when the schema is already current.
+func RunMigrations(dsn string) error {
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return runMigrations(context.Background(), conn, migration.FS)
+}
+
+func runMigrations(ctx context.Context, conn *sql.DB, files fs.FS) error {
+	migrations, err := listMigrations(files)
+	if err != nil {
+		return err
+	}
+
+	// advisory locks belong to a session, so lock and unlock on one connection
+	lockConn, err := conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer lockConn.Close()
+
+	if _, err := lockConn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("cannot lock migrations: %w", err)
+	}
+	defer lockConn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	_, err = lockConn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version bigint NOT NULL PRIMARY KEY,
+  dirty boolean NOT NULL
+)`)
+	if err != nil {
+		return err
+	}
+
+	var current int64
+	var dirty bool
+	err = lockConn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&current, &dirty)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("database is dirty at migration %d, fix it by hand before migrating", current)
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		query, err := fs.ReadFile(files, m.name)
+		if err != nil {
+			return err
+		}
+
+		// postgres DDL is transactional, so a failed migration leaves no trace
+		tx, err := lockConn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, string(query)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", m.version); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		log.Printf("applied migration %s", m.name)
+		current = m.version
+		applied++
+	}
+
+	if applied == 0 {
+		log.Printf("database schema is up to date at migration %d", current)
+	}
+	return nil
+}
+
+// listMigrations returns the up migrations in files ordered by version.
+func listMigrations(files fs.FS) ([]migrationFile, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migrationFile, 0, len(names))
+	for _, name := range names {
+		match := migrationFileName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("bad migration file name %q", name)
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad migration file name %q: %w", name, err)
+		}
+		migrations = append(migrations, migrationFile{version: version, name: name})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+// MigrationStatus is the migration the database schema is at, as recorded in
+// schema_migrations. Dirty means a migration failed partway through.
+type MigrationStatus struct {
+	Version int64 `json:"version"`
+	Dirty   bool  `json:"dirty"`
+}
+
+// MigrationStatus reads the migration the database has been brought up to.
+// A database no migration has run against is at version 0.
+func (store *SQLStore) MigrationStatus(ctx context.Context) (MigrationStatus, error) {
+	var status MigrationStatus
+	err := store.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&status.Version, &status.Dirty)
+	if err == sql.ErrNoRows {
+		return MigrationStatus{}, nil
+	}
+	return status, err
+}
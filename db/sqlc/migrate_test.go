@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/qwerqy/mock_bank/db/migration"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMigrations(t *testing.T) {
+	files := fstest.MapFS{
+		"000010_add_account_status.up.sql":   {Data: []byte("SELECT 10")},
+		"000002_add_attachments.up.sql":      {Data: []byte("SELECT 2")},
+		"000002_add_attachments.down.sql":    {Data: []byte("SELECT -2")},
+		"000001_init_schema.up.sql":          {Data: []byte("SELECT 1")},
+		"000001_init_schema.down.sql":        {Data: []byte("SELECT -1")},
+		"000010_add_account_status.down.sql": {Data: []byte("SELECT -10")},
+	}
+
+	migrations, err := listMigrations(files)
+	require.NoError(t, err)
+	require.Equal(t, []migrationFile{
+		{version: 1, name: "000001_init_schema.up.sql"},
+		{version: 2, name: "000002_add_attachments.up.sql"},
+		{version: 10, name: "000010_add_account_status.up.sql"},
+	}, migrations)
+}
+
+func TestRunMigrations(t *testing.T) {
+	// migrate a throwaway database so the shared test schema is left alone
+	name := "mock_bank_migrate_" + strings.ToLower(util.RandomString(8))
+	_, err := testDB.Exec(fmt.Sprintf("CREATE DATABASE %s", name))
+	require.NoError(t, err)
+	defer testDB.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", name))
+
+	config, err := util.LoadConfig("../..")
+	require.NoError(t, err)
+
+	dsn, err := url.Parse(config.DBSource)
+	require.NoError(t, err)
+	dsn.Path = "/" + name
+
+	err = RunMigrations(dsn.String())
+	require.NoError(t, err)
+
+	// a second run finds nothing left to do
+	err = RunMigrations(dsn.String())
+	require.NoError(t, err)
+
+	conn, err := sql.Open(config.DBDriver, dsn.String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var accounts sql.NullString
+	err = conn.QueryRowContext(context.Background(), "SELECT to_regclass('accounts')::text").Scan(&accounts)
+	require.NoError(t, err)
+	require.True(t, accounts.Valid)
+
+	migrations, err := listMigrations(migration.FS)
+	require.NoError(t, err)
+
+	var version int64
+	var dirty bool
+	err = conn.QueryRowContext(context.Background(), "SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty)
+	require.NoError(t, err)
+	require.Equal(t, migrations[len(migrations)-1].version, version)
+	require.False(t, dirty)
+
+	status, err := NewStore(conn).MigrationStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, MigrationStatus{Version: SchemaVersion}, status)
+}
+
+func TestSchemaVersionIsLatestMigration(t *testing.T) {
+	migrations, err := listMigrations(migration.FS)
+	require.NoError(t, err)
+	require.Equal(t, migrations[len(migrations)-1].version, int64(SchemaVersion))
+}
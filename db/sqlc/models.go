@@ -3,7 +3,11 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/qwerqy/mock_bank/util"
 )
 
 type Account struct {
@@ -12,6 +16,43 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	Nickname  string    `json:"nickname"`
+	// active, frozen or closed
+	Status string `json:"status"`
+	// client-defined JSON object
+	Metadata json.RawMessage `json:"metadata"`
+	// replaces the global daily transfer limit when set
+	DailyLimitOverride util.NullInt64 `json:"daily_limit_override"`
+	// replaces the global maximum transfer amount when set
+	MaxTransferOverride util.NullInt64 `json:"max_transfer_override"`
+	// addresses the account in the API when ACCOUNT_ID_MODE is uuid
+	PublicID uuid.UUID `json:"public_id"`
+}
+
+type AccountLabel struct {
+	AccountID int64     `json:"account_id"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountStatusHistory struct {
+	ID         int64     `json:"id"`
+	AccountID  int64     `json:"account_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	ChangedBy  string    `json:"changed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID            int64     `json:"id"`
+	Username      string    `json:"username"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -20,6 +61,64 @@ type Entry struct {
 	// an be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// transfer_debit, transfer_credit, fee, interest, deposit or adjustment
+	Type string `json:"type"`
+}
+
+type Hold struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	// must be positive
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+	// held, captured or voided
+	Status     string         `json:"status"`
+	TransferID util.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type PendingApproval struct {
+	ID            int64  `json:"id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Description   string `json:"description"`
+	// pending_approval or approved
+	Status      string          `json:"status"`
+	InitiatedBy string          `json:"initiated_by"`
+	ApprovedBy  util.NullString `json:"approved_by"`
+	TransferID  util.NullInt64  `json:"transfer_id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ApprovedAt  util.NullTime   `json:"approved_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Description   string `json:"description"`
+	// pending, executed, failed or cancelled
+	Status     string         `json:"status"`
+	Error      string         `json:"error"`
+	TransferID util.NullInt64 `json:"transfer_id"`
+	CreatedBy  string         `json:"created_by"`
+	ExecuteAt  time.Time      `json:"execute_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	ExecutedAt util.NullTime  `json:"executed_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Transfer struct {
@@ -27,6 +126,54 @@ type Transfer struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// can be negative or positive
-	Amount    int64     `json:"amount"`
+	Amount      int64          `json:"amount"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Description string         `json:"description"`
+	ReversalOf  util.NullInt64 `json:"reversal_of"`
+	Reference   string         `json:"reference"`
+}
+
+type TransferAttachment struct {
+	ID          int64     `json:"id"`
+	TransferID  int64     `json:"transfer_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Data        []byte    `json:"data"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type TransferJob struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64 `json:"amount"`
+	// pending, completed or failed
+	Status      string         `json:"status"`
+	Error       string         `json:"error"`
+	TransferID  util.NullInt64 `json:"transfer_id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	ProcessedAt util.NullTime  `json:"processed_at"`
+	Description string         `json:"description"`
+}
+
+type User struct {
+	Username string `json:"username"`
+	// depositor, banker or admin
+	Role              string    `json:"role"`
+	HashedPassword    string    `json:"hashed_password"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+}
+
+type VerificationToken struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Token    string `json:"token"`
+	// a token verifies an email once
+	IsUsed    bool      `json:"is_used"`
+	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -4,22 +4,90 @@ package db
 
 import (
 	"context"
+
+	"github.com/google/uuid"
+	"github.com/qwerqy/mock_bank/util"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountLabel(ctx context.Context, arg AddAccountLabelParams) (AccountLabel, error)
+	ApprovePendingApproval(ctx context.Context, arg ApprovePendingApprovalParams) (PendingApproval, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (Session, error)
+	BlockUserSessions(ctx context.Context, arg BlockUserSessionsParams) error
+	CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	CountActiveHolds(ctx context.Context, fromAccountID int64) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountStatusChange(ctx context.Context, arg CreateAccountStatusChangeParams) (AccountStatusHistory, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreatePendingApproval(ctx context.Context, arg CreatePendingApprovalParams) (PendingApproval, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferAttachment(ctx context.Context, arg CreateTransferAttachmentParams) (TransferAttachment, error)
+	CreateTransferJob(ctx context.Context, arg CreateTransferJobParams) (TransferJob, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVerificationToken(ctx context.Context, arg CreateVerificationTokenParams) (VerificationToken, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	FindRecentIdenticalTransfer(ctx context.Context, arg FindRecentIdenticalTransferParams) (Transfer, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByOwnerCurrency(ctx context.Context, arg GetAccountByOwnerCurrencyParams) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountWithEntries(ctx context.Context, arg GetAccountWithEntriesParams) (GetAccountWithEntriesRow, error)
+	GetAccountsByMetadataKey(ctx context.Context, arg GetAccountsByMetadataKeyParams) ([]Account, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetNextDueScheduledTransfer(ctx context.Context) (ScheduledTransfer, error)
+	GetNextExpiredHold(ctx context.Context) (Hold, error)
+	GetNextPendingTransferJob(ctx context.Context) (TransferJob, error)
+	GetPendingApproval(ctx context.Context, id int64) (PendingApproval, error)
+	GetPendingApprovalForUpdate(ctx context.Context, id int64) (PendingApproval, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferAttachment(ctx context.Context, arg GetTransferAttachmentParams) (TransferAttachment, error)
+	GetTransferByReference(ctx context.Context, reference string) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
+	GetTransferJob(ctx context.Context, id int64) (TransferJob, error)
+	GetTransferReversal(ctx context.Context, reversalOf util.NullInt64) (Transfer, error)
+	GetUser(ctx context.Context, username string) (User, error)
+	GetVerificationTokenForUpdate(ctx context.Context, token string) (VerificationToken, error)
+	ListAccountIDsByOwner(ctx context.Context, owner string) ([]int64, error)
+	ListAccountIDsByPublicID(ctx context.Context, publicIds []uuid.UUID) ([]ListAccountIDsByPublicIDRow, error)
+	ListAccountLabels(ctx context.Context, accountID int64) ([]AccountLabel, error)
+	ListAccountPublicIDs(ctx context.Context, ids []int64) ([]ListAccountPublicIDsRow, error)
+	ListAccountStatusHistory(ctx context.Context, accountID int64) ([]AccountStatusHistory, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error)
+	ListEntriesAfter(ctx context.Context, arg ListEntriesAfterParams) ([]Entry, error)
 	ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry, error)
+	ListFailedTransferJobs(ctx context.Context, arg ListFailedTransferJobsParams) ([]TransferJob, error)
+	ListLargestTransfers(ctx context.Context, arg ListLargestTransfersParams) ([]Transfer, error)
 	ListTransfer(ctx context.Context, arg ListTransferParams) ([]Transfer, error)
+	ListTransfersForAccounts(ctx context.Context, arg ListTransfersForAccountsParams) ([]Transfer, error)
+	ListTransfersFrom(ctx context.Context, arg ListTransfersFromParams) ([]Transfer, error)
+	ListTransfersTo(ctx context.Context, arg ListTransfersToParams) ([]Transfer, error)
+	MarkEmailVerified(ctx context.Context, username string) (User, error)
+	MarkVerificationTokenUsed(ctx context.Context, id int64) (VerificationToken, error)
+	RemoveAccountLabel(ctx context.Context, arg RemoveAccountLabelParams) (AccountLabel, error)
+	RequeueTransferJob(ctx context.Context, id int64) (TransferJob, error)
+	SearchAccountsByOwner(ctx context.Context, arg SearchAccountsByOwnerParams) ([]Account, error)
+	SumActiveHolds(ctx context.Context, fromAccountID int64) (int64, error)
+	SumEntries(ctx context.Context, accountID int64) (int64, error)
+	SumFeesCharged(ctx context.Context, arg SumFeesChargedParams) (int64, error)
+	SumOutboundTransfersSince(ctx context.Context, arg SumOutboundTransfersSinceParams) (int64, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountDetails(ctx context.Context, arg UpdateAccountDetailsParams) (Account, error)
+	UpdateAccountLimitOverrides(ctx context.Context, arg UpdateAccountLimitOverridesParams) (Account, error)
+	UpdateAccountOwner(ctx context.Context, arg UpdateAccountOwnerParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateHoldStatus(ctx context.Context, arg UpdateHoldStatusParams) (Hold, error)
+	UpdateScheduledTransferStatus(ctx context.Context, arg UpdateScheduledTransferStatusParams) (ScheduledTransfer, error)
+	UpdateTransferJobStatus(ctx context.Context, arg UpdateTransferJobStatusParams) (TransferJob, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// referenceAlphabet leaves out 0, 1, I and O, which are easily confused when
+// a reference is read out or typed in.
+const referenceAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+const referenceSuffixLength = 6
+
+// maxReferenceAttempts bounds how often a transfer draws a new reference
+// after drawing one that is already taken.
+const maxReferenceAttempts = 5
+
+var ErrReferenceExhausted = errors.New("could not find an unused transfer reference")
+
+// newTransferReference returns a reference like TRX-20240101-7KQ2ZD for a
+// transfer made at now. The suffix comes from crypto/rand, so separate
+// processes do not draw the same sequence.
+func newTransferReference(now time.Time) (string, error) {
+	suffix := make([]byte, referenceSuffixLength)
+	max := big.NewInt(int64(len(referenceAlphabet)))
+	for i := range suffix {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		suffix[i] = referenceAlphabet[n.Int64()]
+	}
+
+	return "TRX-" + now.UTC().Format("20060102") + "-" + string(suffix), nil
+}
+
+// insertTransfer inserts the transfer under a fresh reference. A reference
+// that is already taken makes the insert do nothing rather than fail, which
+// would abort the surrounding transaction, so another one can be drawn.
+func insertTransfer(ctx context.Context, q *Queries, arg CreateTransferParams) (Transfer, error) {
+	for attempt := 0; attempt < maxReferenceAttempts; attempt++ {
+		reference, err := newTransferReference(time.Now())
+		if err != nil {
+			return Transfer{}, err
+		}
+
+		arg.Reference = reference
+		transfer, err := q.CreateTransfer(ctx, arg)
+		if err != sql.ErrNoRows {
+			return transfer, err
+		}
+	}
+
+	return Transfer{}, ErrReferenceExhausted
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransferReference(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 23, 30, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+
+	reference, err := newTransferReference(now)
+	require.NoError(t, err)
+	// the date is the UTC one
+	require.Regexp(t, regexp.MustCompile(`^TRX-20240102-[2-9A-HJ-NP-Z]{6}$`), reference)
+}
+
+func TestTransferTxUniqueReferences(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
+
+	n := 20
+	errs := make(chan error)
+	references := make(chan string)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        1,
+			})
+
+			errs <- err
+			references <- result.Transfer.Reference
+		}()
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+
+		reference := <-references
+		require.NotEmpty(t, reference)
+		require.False(t, seen[reference])
+		seen[reference] = true
+
+		transfer, err := testQueries.GetTransferByReference(context.Background(), reference)
+		require.NoError(t, err)
+		require.Equal(t, reference, transfer.Reference)
+	}
+}
@@ -0,0 +1,36 @@
+package db
+
+import "context"
+
+// The queries below are the read-only ones worth offloading to a replica.
+// They may see the primary's writes with a short delay; transactions and
+// every other query keep using the primary.
+
+// reads is where read-only queries go: the replica when one is configured,
+// the primary otherwise.
+func (store *SQLStore) reads() *Queries {
+	if store.replica != nil {
+		return store.replica
+	}
+	return store.Queries
+}
+
+func (store *SQLStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	return store.reads().GetAccount(ctx, id)
+}
+
+func (store *SQLStore) GetAccountWithEntries(ctx context.Context, arg GetAccountWithEntriesParams) (GetAccountWithEntriesRow, error) {
+	return store.reads().GetAccountWithEntries(ctx, arg)
+}
+
+func (store *SQLStore) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	return store.reads().ListAccounts(ctx, arg)
+}
+
+func (store *SQLStore) ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry, error) {
+	return store.reads().ListEntry(ctx, arg)
+}
+
+func (store *SQLStore) ListTransfer(ctx context.Context, arg ListTransferParams) ([]Transfer, error) {
+	return store.reads().ListTransfer(ctx, arg)
+}
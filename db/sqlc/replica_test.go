@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDriver is a fakeTxDriver that notes the name of every query it
+// is sent and fails it, which is all the routing tests need.
+func recordingDriver(names *[]string) *fakeTxDriver {
+	return &fakeTxDriver{
+		query: func(query string) ([]driver.Value, error) {
+			*names = append(*names, queryName(query))
+			return nil, errors.New("recorded")
+		},
+	}
+}
+
+func TestReplicaRouting(t *testing.T) {
+	var primaryQueries, replicaQueries []string
+	primary := sql.OpenDB(recordingDriver(&primaryQueries))
+	defer primary.Close()
+	replica := sql.OpenDB(recordingDriver(&replicaQueries))
+	defer replica.Close()
+
+	store := NewStore(primary).(*SQLStore)
+	store.replica = store.newQueries(replica)
+
+	ctx := context.Background()
+	store.GetAccount(ctx, 1)
+	store.GetAccountWithEntries(ctx, GetAccountWithEntriesParams{ID: 1, EntryLimit: 5})
+	store.ListAccounts(ctx, ListAccountsParams{Limit: 5})
+	store.ListEntry(ctx, ListEntryParams{AccountID: 1, Limit: 5})
+	store.ListTransfer(ctx, ListTransferParams{FromAccountID: 1, ToAccountID: 1, Limit: 5})
+	store.CreateEntry(ctx, CreateEntryParams{AccountID: 1, Amount: 10, Type: EntryTypeDeposit})
+	store.GetAccountForUpdate(ctx, 1)
+
+	require.Equal(t, []string{"GetAccount", "GetAccountWithEntries", "ListAccounts", "ListEntry", "ListTransfer"}, replicaQueries)
+	require.Equal(t, []string{"CreateEntry", "GetAccountForUpdate"}, primaryQueries)
+}
+
+func TestReplicaFallsBackToPrimary(t *testing.T) {
+	var primaryQueries []string
+	primary := sql.OpenDB(recordingDriver(&primaryQueries))
+	defer primary.Close()
+
+	store := NewStore(primary)
+
+	ctx := context.Background()
+	store.GetAccount(ctx, 1)
+	store.ListEntry(ctx, ListEntryParams{AccountID: 1, Limit: 5})
+
+	require.Equal(t, []string{"GetAccount", "ListEntry"}, primaryQueries)
+}
+
+func TestReplicaNotUsedInTransactions(t *testing.T) {
+	var primaryQueries, replicaQueries []string
+	primary := sql.OpenDB(recordingDriver(&primaryQueries))
+	defer primary.Close()
+	replica := sql.OpenDB(recordingDriver(&replicaQueries))
+	defer replica.Close()
+
+	store := NewStore(primary).(*SQLStore)
+	store.replica = store.newQueries(replica)
+
+	err := store.execTx(context.Background(), func(q *Queries) error {
+		_, err := q.GetAccount(context.Background(), 1)
+		return err
+	})
+	require.Error(t, err)
+
+	require.Equal(t, []string{"GetAccount"}, primaryQueries)
+	require.Empty(t, replicaQueries)
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	defaultMaxTxAttempts = 3
+	txRetryBaseDelay     = 10 * time.Millisecond
+)
+
+// isRetryableTxError reports whether err is a serialization failure or a
+// deadlock, both of which may succeed when the transaction is run again.
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40001", "40P01":
+			return true
+		}
+	}
+	return false
+}
+
+// retryTx runs fn, running it again after a short jittered backoff for as
+// long as it fails with a retryable error and attempts remain. Any other
+// error is returned immediately.
+func retryTx(ctx context.Context, maxAttempts int, fn func() error) error {
+	if maxAttempts < 1 {
+		maxAttempts = defaultMaxTxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= maxAttempts || !isRetryableTxError(err) {
+			return err
+		}
+
+		backoff := time.Duration(attempt)*txRetryBaseDelay + time.Duration(rand.Int63n(int64(txRetryBaseDelay)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryTxSucceedsAfterSerializationFailures(t *testing.T) {
+	attempts := 0
+	var result TransferTxResult
+
+	err := retryTx(context.Background(), 3, func() error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("tx err: %w, rb err: %v", &pq.Error{Code: "40001"}, nil)
+		}
+		result.Transfer.ID = 42
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, int64(42), result.Transfer.ID)
+}
+
+func TestRetryTxRetriesDeadlocks(t *testing.T) {
+	attempts := 0
+
+	err := retryTx(context.Background(), 3, func() error {
+		attempts++
+		if attempts == 1 {
+			return &pq.Error{Code: "40P01"}
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+}
+
+func TestRetryTxGivesUp(t *testing.T) {
+	attempts := 0
+
+	err := retryTx(context.Background(), 3, func() error {
+		attempts++
+		return &pq.Error{Code: "40001"}
+	})
+
+	require.Error(t, err)
+	require.True(t, isRetryableTxError(err))
+	require.Equal(t, 3, attempts)
+}
+
+func TestRetryTxNonRetryableError(t *testing.T) {
+	attempts := 0
+
+	err := retryTx(context.Background(), 3, func() error {
+		attempts++
+		return sql.ErrConnDone
+	})
+
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Equal(t, 1, attempts)
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomScheduledTransfer(t *testing.T, fromAccount Account, toAccount Account, executeAt time.Time) ScheduledTransfer {
+	arg := CreateScheduledTransferParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        util.RandomInt(1, 1000),
+		CreatedBy:     fromAccount.Owner,
+		ExecuteAt:     executeAt,
+	}
+
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, scheduled)
+
+	require.Equal(t, arg.FromAccountID, scheduled.FromAccountID)
+	require.Equal(t, arg.ToAccountID, scheduled.ToAccountID)
+	require.Equal(t, arg.Amount, scheduled.Amount)
+	require.Equal(t, arg.CreatedBy, scheduled.CreatedBy)
+	require.WithinDuration(t, arg.ExecuteAt, scheduled.ExecuteAt, time.Second)
+	require.Equal(t, ScheduledTransferStatusPending, scheduled.Status)
+	require.False(t, scheduled.TransferID.Valid)
+	require.False(t, scheduled.ExecutedAt.Valid)
+
+	require.NotZero(t, scheduled.ID)
+	require.NotZero(t, scheduled.CreatedAt)
+
+	return scheduled
+}
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	createRandomScheduledTransfer(t, createRandomAccount(t), createRandomAccount(t), time.Now().Add(time.Hour))
+}
+
+func TestGetScheduledTransfer(t *testing.T) {
+	scheduled1 := createRandomScheduledTransfer(t, createRandomAccount(t), createRandomAccount(t), time.Now().Add(time.Hour))
+
+	scheduled2, err := testQueries.GetScheduledTransfer(context.Background(), scheduled1.ID)
+	require.NoError(t, err)
+	require.Equal(t, scheduled1.ID, scheduled2.ID)
+	require.Equal(t, scheduled1.Amount, scheduled2.Amount)
+	require.Equal(t, scheduled1.Status, scheduled2.Status)
+	require.WithinDuration(t, scheduled1.ExecuteAt, scheduled2.ExecuteAt, time.Second)
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	scheduled := createRandomScheduledTransfer(t, createRandomAccount(t), createRandomAccount(t), time.Now().Add(time.Hour))
+
+	cancelled, err := testQueries.CancelScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferStatusCancelled, cancelled.Status)
+
+	// only a pending transfer can be cancelled
+	_, err = testQueries.CancelScheduledTransfer(context.Background(), scheduled.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"regexp"
+	"time"
+)
+
+// slowQueryDB sits between Queries and the connection and logs every query
+// that runs longer than threshold. Working at this level covers each sqlc
+// query, inside transactions too, without having to list them.
+type slowQueryDB struct {
+	DBTX
+	threshold time.Duration
+	out       io.Writer
+}
+
+func newSlowQueryDB(db DBTX, threshold time.Duration, out io.Writer) DBTX {
+	if threshold <= 0 {
+		return db
+	}
+	return &slowQueryDB{DBTX: db, threshold: threshold, out: out}
+}
+
+func (db *slowQueryDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer db.observe(query, time.Now())
+	return db.DBTX.ExecContext(ctx, query, args...)
+}
+
+func (db *slowQueryDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer db.observe(query, time.Now())
+	return db.DBTX.QueryContext(ctx, query, args...)
+}
+
+func (db *slowQueryDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer db.observe(query, time.Now())
+	return db.DBTX.QueryRowContext(ctx, query, args...)
+}
+
+type slowQueryEntry struct {
+	Level      string  `json:"level"`
+	Message    string  `json:"msg"`
+	Query      string  `json:"query"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+func (db *slowQueryDB) observe(query string, start time.Time) {
+	duration := time.Since(start)
+	if duration < db.threshold {
+		return
+	}
+
+	data, err := json.Marshal(slowQueryEntry{
+		Level:      "warn",
+		Message:    "slow query",
+		Query:      queryName(query),
+		DurationMS: float64(duration) / float64(time.Millisecond),
+	})
+	if err != nil {
+		return
+	}
+	db.out.Write(append(data, '\n'))
+}
+
+var queryNamePattern = regexp.MustCompile(`^-- name: (\w+)`)
+
+// queryName picks the sqlc query name out of the leading comment of query.
+func queryName(query string) string {
+	match := queryNamePattern.FindStringSubmatch(query)
+	if match == nil {
+		return "unnamed"
+	}
+	return match[1]
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sleepDB is a DBTX that takes delay to answer every query.
+type sleepDB struct {
+	DBTX
+	delay time.Duration
+}
+
+func (db sleepDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	time.Sleep(db.delay)
+	return nil, nil
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	const query = "-- name: GetAccount :one\nSELECT 1"
+	threshold := 20 * time.Millisecond
+
+	testCases := []struct {
+		name     string
+		delay    time.Duration
+		checkLog func(t *testing.T, out *bytes.Buffer)
+	}{
+		{
+			name:  "AboveThreshold",
+			delay: 2 * threshold,
+			checkLog: func(t *testing.T, out *bytes.Buffer) {
+				var entry slowQueryEntry
+				err := json.Unmarshal(out.Bytes(), &entry)
+				require.NoError(t, err)
+				require.Equal(t, "warn", entry.Level)
+				require.Equal(t, "GetAccount", entry.Query)
+				require.GreaterOrEqual(t, entry.DurationMS, float64(threshold/time.Millisecond))
+			},
+		},
+		{
+			name:  "BelowThreshold",
+			delay: 0,
+			checkLog: func(t *testing.T, out *bytes.Buffer) {
+				require.Zero(t, out.Len())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			db := newSlowQueryDB(sleepDB{delay: tc.delay}, threshold, &out)
+
+			_, err := db.ExecContext(context.Background(), query)
+			require.NoError(t, err)
+			tc.checkLog(t, &out)
+		})
+	}
+}
+
+func TestSlowQueryLogDisabled(t *testing.T) {
+	db := sleepDB{}
+	require.Equal(t, DBTX(db), newSlowQueryDB(db, 0, &bytes.Buffer{}))
+}
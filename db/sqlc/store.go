@@ -3,48 +3,178 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/qwerqy/mock_bank/util"
+)
+
+var (
+	ErrTransferAlreadyReversed = errors.New("transfer has already been reversed")
+	ErrInsufficientFunds       = errors.New("insufficient funds")
+	ErrApprovalNotPending      = errors.New("transfer is not pending approval")
+	ErrSelfApproval            = errors.New("a transfer cannot be approved by its initiator")
+	ErrAccountFrozen           = errors.New("account is frozen")
+	ErrAccountClosed           = errors.New("account is closed")
+	ErrDailyLimitExceeded      = errors.New("daily transfer limit exceeded")
+	ErrBalanceCapExceeded      = errors.New("transfer would exceed the account's balance cap")
+	ErrNegativeDeposit         = errors.New("initial deposit must not be negative")
+	ErrAccountNotEmpty         = errors.New("account balance is not zero")
+	ErrHoldNotActive           = errors.New("hold has already been captured or voided")
+	ErrHoldExpired             = errors.New("hold has expired")
+	ErrTooManyHolds            = errors.New("account has too many active holds")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrVerificationTokenUsed   = errors.New("verification token has already been used")
+	ErrVerificationExpired     = errors.New("verification token has expired")
+	ErrInvalidAmount           = errors.New("transfer amount must be positive")
+	ErrCurrencyMismatch        = errors.New("accounts do not hold the transfer currency")
+	ErrFeeAccountMismatch      = errors.New("no fee account holds the transfer currency")
+)
+
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+// Entry types say why an entry moved money.
+const (
+	EntryTypeTransferDebit  = "transfer_debit"
+	EntryTypeTransferCredit = "transfer_credit"
+	EntryTypeFee            = "fee"
+	EntryTypeInterest       = "interest"
+	EntryTypeDeposit        = "deposit"
+	EntryTypeAdjustment     = "adjustment"
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	PreviewTransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	SplitTransferTx(ctx context.Context, arg SplitTransferTxParams) (SplitTransferTxResult, error)
+	ReverseTransferTx(ctx context.Context, transferID int64) (TransferTxResult, error)
+	ApproveTransferTx(ctx context.Context, arg ApproveTransferTxParams) (ApproveTransferTxResult, error)
+	ProcessTransferJobTx(ctx context.Context) (TransferJob, error)
+	ExecuteScheduledTransferTx(ctx context.Context) (ScheduledTransfer, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (User, error)
+	VerifyEmailTx(ctx context.Context, token string) (User, error)
+	OpenAccountTx(ctx context.Context, arg OpenAccountTxParams) (OpenAccountTxResult, error)
+	ImportAccountsTx(ctx context.Context, arg ImportAccountsTxParams) ([]ImportAccountResult, error)
+	CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (Account, error)
+	ReassignAccountOwnerTx(ctx context.Context, arg ReassignAccountOwnerTxParams) (Account, error)
+	AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error)
+	ReconcileAccountTx(ctx context.Context, accountID int64) (AccountReconciliation, error)
+	AuthorizeHoldTx(ctx context.Context, arg AuthorizeHoldTxParams) (Hold, error)
+	CaptureHoldTx(ctx context.Context, holdID int64) (CaptureHoldTxResult, error)
+	VoidHoldTx(ctx context.Context, holdID int64) (Hold, error)
+	VoidExpiredHoldTx(ctx context.Context) (Hold, error)
+	SubscribeBalance(accountID int64) (<-chan BalanceUpdate, func())
+	MigrationStatus(ctx context.Context) (MigrationStatus, error)
 }
 
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	db            *sql.DB
+	maxTxAttempts int
+	// transferIsolation is the isolation level TransferTx runs at.
+	transferIsolation sql.IsolationLevel
+
+	// dailyTransferLimit caps what an account may send in 24 hours. Zero
+	// means no limit.
+	dailyTransferLimit int64
+	// balanceCaps is the most an account may hold, by currency. A currency
+	// without an entry is unbounded.
+	balanceCaps map[string]int64
+	// slowQueryThreshold logs queries that take longer than this. Zero
+	// turns the logging off.
+	slowQueryThreshold time.Duration
+	// transferFee is charged on every transfer and credited to the account
+	// feeAccounts holds for the transfer's currency.
+	transferFee TransferFee
+	feeAccounts map[string]int64
+	// holdTTL is how long an authorization hold lasts before it lapses.
+	// Zero means defaultHoldTTL.
+	holdTTL time.Duration
+	// maxHoldsPerAccount is how many active holds an account may have at
+	// once. Zero means no limit.
+	maxHoldsPerAccount int64
+	// replica, when set, runs the read-only queries listed in replica.go
+	// instead of the primary.
+	replica *Queries
+	// balances is told about every balance a committed transfer changed.
+	balances *BalanceBroker
+	// afterCommit, when set, is called once after every transaction execTx
+	// commits, and never for one that rolls back or fails to commit. Tests
+	// set it to observe post-commit side effects deterministically.
+	afterCommit func()
+	// beforeLock, when set, is called by executeTransfer once a transfer has
+	// passed its prechecks, right before it takes its first row lock.
+	beforeLock func()
 }
 
 func NewStore(db *sql.DB) Store {
 	return &SQLStore{
-		db:      db,
-		Queries: New(db),
+		db:                db,
+		Queries:           New(db),
+		maxTxAttempts:     defaultMaxTxAttempts,
+		transferIsolation: sql.LevelReadCommitted,
+		balances:          NewBalanceBroker(),
 	}
 }
 
+// newQueries runs queries on db, logging the slow ones when a threshold is
+// configured.
+func (store *SQLStore) newQueries(db DBTX) *Queries {
+	return New(newSlowQueryDB(db, store.slowQueryThreshold, os.Stderr))
+}
+
 func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
+	return store.execTxWithOptions(ctx, nil, fn)
+}
+
+// execTxWithOptions is execTx with the transaction begun under opts.
+func (store *SQLStore) execTxWithOptions(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
-	q := New(tx)
+	q := store.newQueries(tx)
 	err = fn(q)
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
 		}
 		return err
 	}
 
-	return tx.Commit()
+	err = tx.Commit()
+	if err == nil && store.afterCommit != nil {
+		store.afterCommit()
+	}
+	return err
 }
 
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Description   string `json:"description"`
+
+	// Currency, when set, must be the currency of both accounts. Transfer
+	// jobs and scheduled transfers leave it empty.
+	Currency string `json:"currency"`
+
+	// reversalOf links a compensating transfer to the one it undoes. It is
+	// only ever set by ReverseTransferTx.
+	reversalOf util.NullInt64
 }
 
 type TransferTxResult struct {
@@ -53,60 +183,1428 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	Fee         int64    `json:"fee"`
+	FeeEntry    Entry    `json:"fee_entry"`
 }
 
+// TransferTx moves money between two accounts at the configured transfer
+// isolation level. A serialization failure, which a stricter level makes
+// more likely, is retried like a deadlock.
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
+	opts := &sql.TxOptions{Isolation: store.transferIsolation}
+	err := retryTx(ctx, store.maxTxAttempts, func() error {
+		return store.execTxWithOptions(ctx, opts, func(q *Queries) error {
+			var err error
+			result, err = store.executeTransfer(ctx, q, arg)
+			return err
+		})
+	})
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
+	if err == nil {
+		store.publishTransfer(result)
+	}
+	return result, err
+}
+
+// errPreviewRollback ends the transaction of a preview that succeeded, so
+// that nothing it wrote is committed.
+var errPreviewRollback = errors.New("transfer preview rolled back")
+
+// PreviewTransferTx runs a transfer with all the checks of TransferTx, then
+// rolls it back. The result holds the balances and fee the transfer would
+// leave; the transfer and entries in it were never committed, so their IDs
+// refer to nothing.
+func (store *SQLStore) PreviewTransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := retryTx(ctx, store.maxTxAttempts, func() error {
+		return store.execTx(ctx, func(q *Queries) error {
+			var err error
+			result, err = store.executeTransfer(ctx, q, arg)
+			if err != nil {
+				return err
+			}
+			return errPreviewRollback
 		})
+	})
 
-		if err != nil {
-			return err
-		}
+	if errors.Is(err, errPreviewRollback) {
+		err = nil
+	}
+	return result, err
+}
+
+type SplitTarget struct {
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+}
+
+type SplitTransferTxParams struct {
+	FromAccountID int64         `json:"from_account_id"`
+	Targets       []SplitTarget `json:"targets"`
+	Description   string        `json:"description"`
+}
+
+type SplitTransferTxResult struct {
+	// FromAccount is the source as the whole split left it.
+	FromAccount Account `json:"from_account"`
+	// Transfers holds one transfer per target, in the order they were
+	// given. The source in each is a snapshot from partway through.
+	Transfers []TransferTxResult `json:"transfers"`
+}
+
+// SplitTransferTx sends money from one account to several in a single
+// transaction: either every target is paid or none is. The source is locked
+// once, up front, and must cover the total and its fees with what its holds
+// leave available, or the split fails with ErrInsufficientFunds. Each target
+// gets a transfer of its own, charged and checked like one from TransferTx.
+func (store *SQLStore) SplitTransferTx(ctx context.Context, arg SplitTransferTxParams) (SplitTransferTxResult, error) {
+	var result SplitTransferTxResult
+
+	// crediting the targets in ID order keeps concurrent splits to the
+	// same accounts from deadlocking
+	order := make([]int, len(arg.Targets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return arg.Targets[order[i]].ToAccountID < arg.Targets[order[j]].ToAccountID
+	})
+
+	opts := &sql.TxOptions{Isolation: store.transferIsolation}
+	err := retryTx(ctx, store.maxTxAttempts, func() error {
+		return store.execTxWithOptions(ctx, opts, func(q *Queries) error {
+			result = SplitTransferTxResult{Transfers: make([]TransferTxResult, len(arg.Targets))}
+
+			source, err := q.GetAccountForUpdate(ctx, arg.FromAccountID)
+			if err != nil {
+				return err
+			}
+
+			var total int64
+			for _, target := range arg.Targets {
+				total += target.Amount + store.transferFee.For(target.Amount)
+			}
+
+			held, err := q.SumActiveHolds(ctx, source.ID)
+			if err != nil {
+				return err
+			}
+			if source.Balance-held < total {
+				return ErrInsufficientFunds
+			}
+
+			for _, i := range order {
+				result.Transfers[i], err = transfer(ctx, q, TransferTxParams{
+					FromAccountID: arg.FromAccountID,
+					ToAccountID:   arg.Targets[i].ToAccountID,
+					Amount:        arg.Targets[i].Amount,
+					Description:   arg.Description,
+				})
+				if err != nil {
+					return err
+				}
+				result.FromAccount = result.Transfers[i].FromAccount
+			}
+
+			// the fees come after every target is credited, so the fee
+			// account is still the last lock taken
+			for _, i := range order {
+				leg := &result.Transfers[i]
+				err = store.chargeFee(ctx, q, leg)
+				if err != nil {
+					return err
+				}
+				if leg.Fee > 0 {
+					result.FromAccount = leg.FromAccount
+				}
+
+				err = store.checkBalanceCap(leg.ToAccount)
+				if err != nil {
+					return err
+				}
+			}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
+			return store.checkDailyLimit(ctx, q, result.FromAccount)
 		})
+	})
 
-		if err != nil {
-			return err
+	if err == nil {
+		for _, leg := range result.Transfers {
+			store.publishTransfer(leg)
 		}
+	}
+	return result, err
+}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+// executeTransfer is the body of TransferTx: it moves the money, charges the
+// fee and enforces holds, the balance cap and the daily limit, all within q's
+// transaction. A transfer that fails its prechecks never locks a row.
+func (store *SQLStore) executeTransfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	err := precheckTransfer(ctx, q, arg)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	if store.beforeLock != nil {
+		store.beforeLock()
+	}
+
+	result, err := transfer(ctx, q, arg)
+	if err != nil {
+		return result, err
+	}
+
+	err = store.chargeFee(ctx, q, &result)
+	if err != nil {
+		return result, err
+	}
+
+	err = checkHeldFunds(ctx, q, result.FromAccount)
+	if err != nil {
+		return result, err
+	}
+
+	err = store.checkBalanceCap(result.ToAccount)
+	if err != nil {
+		return result, err
+	}
+
+	return result, store.checkDailyLimit(ctx, q, result.FromAccount)
+}
+
+// precheckTransfer rejects a transfer that is bound to fail using plain
+// reads, which take no row locks. The account statuses may still change
+// before the rows are locked, so transfer checks them again; the currency of
+// an account never changes once it is opened.
+func precheckTransfer(ctx context.Context, q *Queries, arg TransferTxParams) error {
+	if arg.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return err
+	}
+
+	toAccount, err := q.GetAccount(ctx, arg.ToAccountID)
+	if err != nil {
+		return err
+	}
+
+	if arg.Currency != "" && (fromAccount.Currency != arg.Currency || toAccount.Currency != arg.Currency) {
+		return ErrCurrencyMismatch
+	}
+	if fromAccount.Status == AccountStatusFrozen {
+		return ErrAccountFrozen
+	}
+	if fromAccount.Status == AccountStatusClosed || toAccount.Status == AccountStatusClosed {
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// TransferFee is what TransferTx charges the sender on top of the amount: a
+// flat part plus BasisPoints hundredths of a percent of the amount.
+type TransferFee struct {
+	Flat        int64
+	BasisPoints int64
+}
+
+// For returns the fee on a transfer of amount. The percentage part is rounded
+// to the nearest minor unit as util.ApplyPercentage does.
+func (fee TransferFee) For(amount int64) int64 {
+	return fee.Flat + util.ApplyPercentage(amount, fee.BasisPoints)
+}
+
+// chargeFee debits the transfer fee from the sender and credits it to the
+// fee account for the sender's currency, as two entries of their own. The
+// sender must cover amount and fee, or the transfer fails with
+// ErrInsufficientFunds. A fee is never credited in another currency; without
+// a fee account holding the sender's currency the transfer fails with
+// ErrFeeAccountMismatch.
+func (store *SQLStore) chargeFee(ctx context.Context, q *Queries, result *TransferTxResult) error {
+	fee := store.transferFee.For(result.Transfer.Amount)
+	if fee <= 0 {
+		return nil
+	}
+
+	feeAccountID, ok := store.feeAccounts[result.FromAccount.Currency]
+	if !ok {
+		return ErrFeeAccountMismatch
+	}
+
+	var err error
+	result.Fee = fee
+	result.FeeEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: result.Transfer.FromAccountID,
+		Amount:    -fee,
+		Type:      EntryTypeFee,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: feeAccountID,
+		Amount:    fee,
+		Type:      EntryTypeFee,
+	})
+	if err != nil {
+		return err
+	}
+
+	// both transfer accounts are locked already and the fee account always
+	// comes last, so every transaction takes the locks in the same order
+	result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     result.Transfer.FromAccountID,
+		Amount: -fee,
+	})
+	if err != nil {
+		return err
+	}
+
+	feeAccount, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     feeAccountID,
+		Amount: fee,
+	})
+	if err != nil {
+		return err
+	}
+	if feeAccount.Currency != result.FromAccount.Currency {
+		return ErrFeeAccountMismatch
+	}
+	if feeAccount.ID == result.ToAccount.ID {
+		result.ToAccount = feeAccount
+	}
+
+	if result.FromAccount.Balance < 0 {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+// transfer moves money between two accounts using the given queries, so it
+// can be composed into any transaction that needs to perform a transfer.
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	result.Transfer, err = insertTransfer(ctx, q, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Description:   arg.Description,
+		ReversalOf:    arg.reversalOf,
+	})
+
+	if err != nil {
+		return result, err
+	}
+
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -arg.Amount,
+		Type:      EntryTypeTransferDebit,
+	})
+
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount:    arg.Amount,
+		Type:      EntryTypeTransferCredit,
+	})
+
+	if err != nil {
+		return result, err
+	}
+
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// the balance updates hold both row locks, so the statuses read back here
+	// cannot change before the transaction commits
+	if result.FromAccount.Status == AccountStatusFrozen {
+		return result, ErrAccountFrozen
+	}
+	if result.FromAccount.Status == AccountStatusClosed || result.ToAccount.Status == AccountStatusClosed {
+		return result, ErrAccountClosed
+	}
+
+	return result, nil
+}
+
+// checkBalanceCap fails with ErrBalanceCapExceeded when a credit has left
+// account holding more than the cap for its currency.
+func (store *SQLStore) checkBalanceCap(account Account) error {
+	limit, ok := store.balanceCaps[account.Currency]
+	if ok && account.Balance > limit {
+		return ErrBalanceCapExceeded
+	}
+	return nil
+}
+
+// checkDailyLimit fails with ErrDailyLimitExceeded when the account has sent
+// more than its daily limit over the last 24 hours. The limit is the
+// account's own override when it has one, the global limit otherwise. It must
+// run after the transfer has debited the account: the new transfer is then
+// part of the sum, and the lock on the account row keeps concurrent transfers
+// from slipping past the limit together.
+func (store *SQLStore) checkDailyLimit(ctx context.Context, q *Queries, account Account) error {
+	limit := store.dailyTransferLimit
+	if account.DailyLimitOverride.Valid {
+		limit = account.DailyLimitOverride.Int64
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	total, err := q.SumOutboundTransfersSince(ctx, SumOutboundTransfersSinceParams{
+		AccountID: account.ID,
+		Since:     time.Now().Add(-24 * time.Hour),
+	})
+	if err != nil {
+		return err
+	}
+
+	if total > limit {
+		return ErrDailyLimitExceeded
+	}
+	return nil
+}
+
+// checkHeldFunds fails with ErrInsufficientFunds when a debit has left the
+// account with less than its active holds cover, which for an account without
+// holds means overdrawn. It must run while the account row is locked.
+func checkHeldFunds(ctx context.Context, q *Queries, account Account) error {
+	held, err := q.SumActiveHolds(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+
+	if account.Balance < held {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+// ReverseTransferTx undoes a transfer by moving the same amount from its
+// destination back to its source. The compensating transfer is linked to the
+// original, which can only ever be reversed once. Like any credit, the
+// reversal may not take the source over its balance cap.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, transferID int64) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := retryTx(ctx, store.maxTxAttempts, func() error {
+		return store.execTx(ctx, func(q *Queries) error {
+			// locking the original serializes concurrent reversals of it
+			original, err := q.GetTransferForUpdate(ctx, transferID)
+			if err != nil {
+				return err
+			}
+
+			_, err = q.GetTransferReversal(ctx, util.NewNullInt64(original.ID))
+			if err == nil {
+				return ErrTransferAlreadyReversed
+			}
+			if err != sql.ErrNoRows {
+				return err
+			}
+
+			result, err = transfer(ctx, q, TransferTxParams{
+				FromAccountID: original.ToAccountID,
+				ToAccountID:   original.FromAccountID,
+				Amount:        original.Amount,
+				Description:   fmt.Sprintf("reversal of transfer %d", original.ID),
+				reversalOf:    util.NewNullInt64(original.ID),
+			})
+			if err != nil {
+				return err
+			}
+
+			if result.FromAccount.Balance < 0 {
+				return ErrInsufficientFunds
+			}
+			return store.checkBalanceCap(result.ToAccount)
 		})
+	})
 
-		if err != nil {
-			return err
-		}
+	if err == nil {
+		store.publishTransfer(result)
+	}
+	return result, err
+}
+
+const (
+	PendingApprovalStatusPending  = "pending_approval"
+	PendingApprovalStatusApproved = "approved"
+)
+
+type ApproveTransferTxParams struct {
+	ID         int64  `json:"id"`
+	ApprovedBy string `json:"approved_by"`
+}
+
+type ApproveTransferTxResult struct {
+	TransferTxResult
+	Approval PendingApproval `json:"approval"`
+}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+// ApproveTransferTx executes a transfer that was held for approval the way
+// TransferTx would. The approver must not be the user who initiated it, and
+// the transfer and the approval are committed together so it can only ever be
+// executed once.
+func (store *SQLStore) ApproveTransferTx(ctx context.Context, arg ApproveTransferTxParams) (ApproveTransferTxResult, error) {
+	var result ApproveTransferTxResult
+
+	err := retryTx(ctx, store.maxTxAttempts, func() error {
+		return store.execTx(ctx, func(q *Queries) error {
+			approval, err := q.GetPendingApprovalForUpdate(ctx, arg.ID)
 			if err != nil {
 				return err
 			}
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+
+			if approval.Status != PendingApprovalStatusPending {
+				return ErrApprovalNotPending
+			}
+			if approval.InitiatedBy == arg.ApprovedBy {
+				return ErrSelfApproval
+			}
+
+			result.TransferTxResult, err = store.executeTransfer(ctx, q, TransferTxParams{
+				FromAccountID: approval.FromAccountID,
+				ToAccountID:   approval.ToAccountID,
+				Amount:        approval.Amount,
+				Description:   approval.Description,
+			})
 			if err != nil {
 				return err
 			}
-		}
 
-		return nil
+			result.Approval, err = q.ApprovePendingApproval(ctx, ApprovePendingApprovalParams{
+				ApprovedBy: util.NewNullString(arg.ApprovedBy),
+				TransferID: util.NewNullInt64(result.Transfer.ID),
+				ID:         approval.ID,
+			})
+			return err
+		})
 	})
 
+	if err == nil {
+		store.publishTransfer(result.TransferTxResult)
+	}
 	return result, err
 }
 
+const (
+	TransferJobStatusPending   = "pending"
+	TransferJobStatusCompleted = "completed"
+	TransferJobStatusFailed    = "failed"
+)
+
+// ProcessTransferJobTx claims the oldest pending transfer job and executes it
+// the way TransferTx would, at the same isolation level and with the same
+// retries. The transfer and the job's completion are committed together, so
+// a job that is picked up again after a crash can never move money twice.
+// A job is only marked failed when its transfer fails for good; one that is
+// still hitting serialization failures or deadlocks once the retries run out
+// stays pending for the next run.
+// It returns ErrRecordNotFound when there is no pending job.
+func (store *SQLStore) ProcessTransferJobTx(ctx context.Context) (TransferJob, error) {
+	var job TransferJob
+	var result TransferTxResult
+	var transferErr error
+
+	opts := &sql.TxOptions{Isolation: store.transferIsolation}
+	err := retryTx(ctx, store.maxTxAttempts, func() error {
+		transferErr = nil
+
+		return store.execTxWithOptions(ctx, opts, func(q *Queries) error {
+			var err error
+
+			job, err = q.GetNextPendingTransferJob(ctx)
+			if err != nil {
+				return err
+			}
+
+			result, err = store.executeTransfer(ctx, q, TransferTxParams{
+				FromAccountID: job.FromAccountID,
+				ToAccountID:   job.ToAccountID,
+				Amount:        job.Amount,
+				Description:   job.Description,
+			})
+			if err != nil {
+				transferErr = err
+				return err
+			}
+
+			job, err = q.UpdateTransferJobStatus(ctx, UpdateTransferJobStatusParams{
+				ID:         job.ID,
+				Status:     TransferJobStatusCompleted,
+				TransferID: util.NewNullInt64(result.Transfer.ID),
+			})
+			return err
+		})
+	})
+
+	if transferErr != nil && !isRetryableTxError(transferErr) {
+		// the transfer was rolled back, record why outside of that transaction
+		return store.UpdateTransferJobStatus(ctx, UpdateTransferJobStatusParams{
+			ID:     job.ID,
+			Status: TransferJobStatusFailed,
+			Error:  transferErr.Error(),
+		})
+	}
+
+	if err == nil {
+		store.publishTransfer(result)
+	}
+	return job, err
+}
+
+const (
+	ScheduledTransferStatusPending   = "pending"
+	ScheduledTransferStatusExecuted  = "executed"
+	ScheduledTransferStatusFailed    = "failed"
+	ScheduledTransferStatusCancelled = "cancelled"
+)
+
+// ExecuteScheduledTransferTx claims the scheduled transfer that fell due
+// first and executes it the way TransferTx would. As with transfer jobs, the
+// transfer and the status change commit together, and a transfer that fails
+// is marked failed with the reason.
+// It returns ErrRecordNotFound when no scheduled transfer is due.
+func (store *SQLStore) ExecuteScheduledTransferTx(ctx context.Context) (ScheduledTransfer, error) {
+	var scheduled ScheduledTransfer
+	var result TransferTxResult
+	var transferErr error
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		scheduled, err = q.GetNextDueScheduledTransfer(ctx)
+		if err != nil {
+			return err
+		}
+
+		result, err = store.executeTransfer(ctx, q, TransferTxParams{
+			FromAccountID: scheduled.FromAccountID,
+			ToAccountID:   scheduled.ToAccountID,
+			Amount:        scheduled.Amount,
+			Description:   scheduled.Description,
+		})
+		if err != nil {
+			transferErr = err
+			return err
+		}
+
+		scheduled, err = q.UpdateScheduledTransferStatus(ctx, UpdateScheduledTransferStatusParams{
+			ID:         scheduled.ID,
+			Status:     ScheduledTransferStatusExecuted,
+			TransferID: util.NewNullInt64(result.Transfer.ID),
+		})
+		return err
+	})
+
+	if transferErr != nil {
+		// the transfer was rolled back, record why outside of that transaction
+		return store.UpdateScheduledTransferStatus(ctx, UpdateScheduledTransferStatusParams{
+			ID:     scheduled.ID,
+			Status: ScheduledTransferStatusFailed,
+			Error:  transferErr.Error(),
+		})
+	}
+
+	if err == nil {
+		store.publishTransfer(result)
+	}
+	return scheduled, err
+}
+
+type CreateUserTxParams struct {
+	CreateUserParams
+	// DefaultCurrency, when set, opens an empty account in that currency
+	// for the new user.
+	DefaultCurrency string `json:"default_currency"`
+	// VerificationToken, when set, is stored for the user to verify their
+	// email with until VerificationExpiresAt.
+	VerificationToken     string    `json:"verification_token"`
+	VerificationExpiresAt time.Time `json:"verification_expires_at"`
+}
+
+type CreateUserTxResult struct {
+	User              User               `json:"user"`
+	Account           *Account           `json:"account,omitempty"`
+	VerificationToken *VerificationToken `json:"verification_token,omitempty"`
+}
+
+// CreateUserTx creates a user and, if asked to, their first account and an
+// email verification token in the same transaction, so a failure of any of
+// them leaves none behind.
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.VerificationToken != "" {
+			token, err := q.CreateVerificationToken(ctx, CreateVerificationTokenParams{
+				Username:  result.User.Username,
+				Token:     arg.VerificationToken,
+				ExpiresAt: arg.VerificationExpiresAt,
+			})
+			if err != nil {
+				return err
+			}
+			result.VerificationToken = &token
+		}
+
+		if arg.DefaultCurrency == "" {
+			return nil
+		}
+
+		account, err := q.CreateAccount(ctx, CreateAccountParams{
+			Owner:    result.User.Username,
+			Currency: arg.DefaultCurrency,
+		})
+		if err != nil {
+			return err
+		}
+		result.Account = &account
+		return nil
+	})
+
+	if err != nil {
+		return CreateUserTxResult{}, err
+	}
+	return result, nil
+}
+
+type ChangePasswordTxParams struct {
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+	// KeepSessionID is the session the change was made from. Every other
+	// session of the user is blocked.
+	KeepSessionID uuid.UUID `json:"keep_session_id"`
+}
+
+// ChangePasswordTx stores a new password hash and blocks the user's other
+// sessions, so a stolen refresh token stops working along with the old
+// password.
+func (store *SQLStore) ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:       arg.Username,
+			HashedPassword: arg.HashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.BlockUserSessions(ctx, BlockUserSessionsParams{
+			Username: arg.Username,
+			ID:       arg.KeepSessionID,
+		})
+	})
+
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// VerifyEmailTx consumes a verification token and marks the email of its
+// user verified. A token works once and only until it expires.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, token string) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		verification, err := q.GetVerificationTokenForUpdate(ctx, token)
+		if err != nil {
+			return err
+		}
+		if verification.IsUsed {
+			return ErrVerificationTokenUsed
+		}
+		if !verification.ExpiresAt.After(time.Now()) {
+			return ErrVerificationExpired
+		}
+
+		_, err = q.MarkVerificationTokenUsed(ctx, verification.ID)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.MarkEmailVerified(ctx, verification.Username)
+		return err
+	})
+
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+type OpenAccountTxParams struct {
+	Owner          string `json:"owner"`
+	Currency       string `json:"currency"`
+	InitialDeposit int64  `json:"initial_deposit"`
+	// Metadata is stored on the account when set, otherwise it starts as {}.
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+type OpenAccountTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+// OpenAccountTx creates an account holding the initial deposit, together with
+// the entry that credits it, so the balance always matches the ledger. No
+// entry is written for a zero deposit. A deposit over the balance cap fails
+// with ErrBalanceCapExceeded.
+func (store *SQLStore) OpenAccountTx(ctx context.Context, arg OpenAccountTxParams) (OpenAccountTxResult, error) {
+	var result OpenAccountTxResult
+
+	err := store.checkDeposit(arg)
+	if err != nil {
+		return result, err
+	}
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = openAccount(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// checkDeposit rejects an initial deposit that is negative or over the
+// balance cap for the account's currency.
+func (store *SQLStore) checkDeposit(arg OpenAccountTxParams) error {
+	if arg.InitialDeposit < 0 {
+		return ErrNegativeDeposit
+	}
+	return store.checkBalanceCap(Account{Currency: arg.Currency, Balance: arg.InitialDeposit})
+}
+
+func openAccount(ctx context.Context, q *Queries, arg OpenAccountTxParams) (OpenAccountTxResult, error) {
+	var result OpenAccountTxResult
+	var err error
+
+	result.Account, err = q.CreateAccount(ctx, CreateAccountParams{
+		Owner:    arg.Owner,
+		Balance:  arg.InitialDeposit,
+		Currency: arg.Currency,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if len(arg.Metadata) > 0 {
+		result.Account, err = q.UpdateAccountDetails(ctx, UpdateAccountDetailsParams{
+			ID:       result.Account.ID,
+			Metadata: arg.Metadata,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if arg.InitialDeposit == 0 {
+		return result, nil
+	}
+
+	result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: result.Account.ID,
+		Amount:    arg.InitialDeposit,
+		Type:      EntryTypeDeposit,
+	})
+	return result, err
+}
+
+type ImportAccountsTxParams struct {
+	Accounts []OpenAccountTxParams `json:"accounts"`
+	// Atomic creates either every account or, if any of them fails, none.
+	Atomic bool `json:"atomic"`
+}
+
+// ImportAccountResult is the outcome for one of the accounts to import.
+// Account is only set when it was created.
+type ImportAccountResult struct {
+	Account *Account `json:"account,omitempty"`
+	Err     error    `json:"-"`
+}
+
+// ErrImportAborted is returned by an atomic import in which an account could
+// not be created, so none were.
+var ErrImportAborted = errors.New("import aborted, no accounts were created")
+
+// ImportAccountsTx opens every account in arg, each with its initial deposit,
+// and returns one result per account in the same order. An account that
+// breaks a constraint, such as an owner who does not exist, is rolled back to
+// a savepoint and reported without disturbing the others. In atomic mode any
+// such failure rolls back the whole import and returns ErrImportAborted along
+// with the results saying which accounts failed.
+func (store *SQLStore) ImportAccountsTx(ctx context.Context, arg ImportAccountsTxParams) ([]ImportAccountResult, error) {
+	results := make([]ImportAccountResult, len(arg.Accounts))
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		failed := false
+
+		for i, account := range arg.Accounts {
+			if err := store.checkDeposit(account); err != nil {
+				results[i].Err = err
+				failed = true
+				continue
+			}
+
+			if _, err := q.db.ExecContext(ctx, "SAVEPOINT import_account"); err != nil {
+				return err
+			}
+
+			result, err := openAccount(ctx, q, account)
+			if err != nil {
+				// anything but a constraint violation is a problem with the
+				// database rather than the row, and ends the import
+				if ErrorCode(err) == nil {
+					return err
+				}
+				if _, err := q.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_account"); err != nil {
+					return err
+				}
+				results[i].Err = err
+				failed = true
+				continue
+			}
+
+			if _, err := q.db.ExecContext(ctx, "RELEASE SAVEPOINT import_account"); err != nil {
+				return err
+			}
+			results[i].Account = &result.Account
+		}
+
+		if arg.Atomic && failed {
+			return ErrImportAborted
+		}
+		return nil
+	})
+
+	if err != nil {
+		// nothing was committed, whatever the results said
+		for i := range results {
+			results[i].Account = nil
+		}
+	}
+	return results, err
+}
+
+type CloseAccountTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// SweepToAccountID receives whatever balance is left. It may only be
+	// zero when the account is already empty.
+	SweepToAccountID int64 `json:"sweep_to_account_id"`
+	// ClosedBy is the user closing the account, recorded in its status
+	// history.
+	ClosedBy string `json:"closed_by"`
+}
+
+type CloseAccountTxResult struct {
+	Account Account `json:"account"`
+	// Sweep is the transfer that emptied the account, when one was needed.
+	Sweep *TransferTxResult `json:"sweep,omitempty"`
+}
+
+// CloseAccountTx moves the remaining balance of an account to the sweep
+// account and marks it closed, so the account is never closed with money
+// still in it. An account without a sweep account must already be empty, and
+// an overdrawn one cannot be closed at all: both fail with ErrAccountNotEmpty.
+// A sweep that would take the sweep account over its balance cap fails with
+// ErrBalanceCapExceeded.
+func (store *SQLStore) CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error) {
+	var result CloseAccountTxResult
+
+	err := retryTx(ctx, store.maxTxAttempts, func() error {
+		return store.execTx(ctx, func(q *Queries) error {
+			result = CloseAccountTxResult{}
+
+			// the lock keeps transfers from changing the balance between the
+			// check and the sweep
+			account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+			if err != nil {
+				return err
+			}
+
+			if account.Status == AccountStatusClosed {
+				return ErrAccountClosed
+			}
+			if account.Balance < 0 || (account.Balance > 0 && arg.SweepToAccountID == 0) {
+				return ErrAccountNotEmpty
+			}
+
+			if account.Balance > 0 {
+				sweep, err := transfer(ctx, q, TransferTxParams{
+					FromAccountID: account.ID,
+					ToAccountID:   arg.SweepToAccountID,
+					Amount:        account.Balance,
+					Description:   fmt.Sprintf("balance of closed account %d", account.ID),
+				})
+				if err != nil {
+					return err
+				}
+				err = store.checkBalanceCap(sweep.ToAccount)
+				if err != nil {
+					return err
+				}
+				result.Sweep = &sweep
+			}
+
+			result.Account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+				ID:     account.ID,
+				Status: AccountStatusClosed,
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = q.CreateAccountStatusChange(ctx, CreateAccountStatusChangeParams{
+				AccountID:  account.ID,
+				FromStatus: account.Status,
+				ToStatus:   AccountStatusClosed,
+				Reason:     "account closed",
+				ChangedBy:  arg.ClosedBy,
+			})
+			return err
+		})
+	})
+
+	if err == nil && result.Sweep != nil {
+		store.publishTransfer(*result.Sweep)
+	}
+	return result, err
+}
+
+type UpdateAccountStatusTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+	// ChangedBy is the user making the change, recorded in the status
+	// history with the reason.
+	ChangedBy string `json:"changed_by"`
+}
+
+// UpdateAccountStatusTx moves an account between active and frozen and
+// records the change in its status history. Setting the status an account
+// already has changes and records nothing. A closed account stays closed and
+// fails with ErrAccountClosed.
+func (store *SQLStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.Status == AccountStatusClosed {
+			return ErrAccountClosed
+		}
+		if account.Status == arg.Status {
+			return nil
+		}
+
+		previous := account.Status
+		account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:     arg.AccountID,
+			Status: arg.Status,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateAccountStatusChange(ctx, CreateAccountStatusChangeParams{
+			AccountID:  account.ID,
+			FromStatus: previous,
+			ToStatus:   account.Status,
+			Reason:     arg.Reason,
+			ChangedBy:  arg.ChangedBy,
+		})
+		return err
+	})
+
+	return account, err
+}
+
+type ReassignAccountOwnerTxParams struct {
+	AccountID int64  `json:"account_id"`
+	NewOwner  string `json:"new_owner"`
+	// ChangedBy is the admin making the change, recorded in the audit log.
+	ChangedBy string `json:"changed_by"`
+}
+
+// ReassignAccountOwnerTx hands an account over to another existing user and
+// writes the change to the audit log in the same transaction. It fails with
+// ErrUserNotFound when the new owner does not exist.
+func (store *SQLStore) ReassignAccountOwnerTx(ctx context.Context, arg ReassignAccountOwnerTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		previous, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		account, err = q.UpdateAccountOwner(ctx, UpdateAccountOwnerParams{
+			ID:    arg.AccountID,
+			Owner: arg.NewOwner,
+		})
+		if err != nil {
+			// the account row is locked, so no row means no such user
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		_, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			Username:      arg.ChangedBy,
+			FromAccountID: account.ID,
+			ToAccountID:   account.ID,
+			Reason:        fmt.Sprintf("owner changed from %s to %s", previous.Owner, account.Owner),
+		})
+		return err
+	})
+
+	return account, err
+}
+
+type AdjustBalanceTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Amount is credited when positive and debited when negative.
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason"`
+	// AllowNegative lets a debit take the balance below zero.
+	AllowNegative bool `json:"allow_negative"`
+	// AdjustedBy is the admin making the adjustment, recorded in the audit
+	// log with the reason.
+	AdjustedBy string `json:"adjusted_by"`
+}
+
+type AdjustBalanceTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+// AdjustBalanceTx corrects an account's balance by hand, writing an
+// adjustment entry and an audit log record in the same transaction. A debit
+// that would overdraw the account fails with ErrInsufficientFunds unless
+// AllowNegative is set, and a credit over the balance cap fails with
+// ErrBalanceCapExceeded. A closed account fails with ErrAccountClosed.
+func (store *SQLStore) AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error) {
+	var result AdjustBalanceTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.Status == AccountStatusClosed {
+			return ErrAccountClosed
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: arg.AccountID,
+			Amount:    arg.Amount,
+			Type:      EntryTypeAdjustment,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.Amount < 0 && result.Account.Balance < 0 && !arg.AllowNegative {
+			return ErrInsufficientFunds
+		}
+		if arg.Amount > 0 {
+			err = store.checkBalanceCap(result.Account)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			Username:      arg.AdjustedBy,
+			FromAccountID: arg.AccountID,
+			ToAccountID:   arg.AccountID,
+			Amount:        arg.Amount,
+			Reason:        "balance adjusted: " + arg.Reason,
+		})
+		return err
+	})
+
+	if err == nil && store.balances != nil {
+		store.balances.Publish(BalanceUpdate{
+			AccountID: result.Account.ID,
+			Balance:   result.Account.Balance,
+			Currency:  result.Account.Currency,
+			Entry:     result.Entry,
+		})
+	}
+	return result, err
+}
+
+// AccountReconciliation compares the balance stored on an account with the
+// balance its ledger entries add up to.
+type AccountReconciliation struct {
+	AccountID       int64 `json:"account_id"`
+	Balance         int64 `json:"balance"`
+	ExpectedBalance int64 `json:"expected_balance"`
+	Balanced        bool  `json:"balanced"`
+}
+
+// ReconcileAccountTx sums the entries of an account and compares the total
+// with its stored balance. It changes nothing, but locks the account while it
+// reads, so a transfer committing in between cannot show up as drift.
+func (store *SQLStore) ReconcileAccountTx(ctx context.Context, accountID int64) (AccountReconciliation, error) {
+	var result AccountReconciliation
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		total, err := q.SumEntries(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		result = AccountReconciliation{
+			AccountID:       account.ID,
+			Balance:         account.Balance,
+			ExpectedBalance: total,
+			Balanced:        account.Balance == total,
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+const (
+	HoldStatusHeld     = "held"
+	HoldStatusCaptured = "captured"
+	HoldStatusVoided   = "voided"
+)
+
+const defaultHoldTTL = 7 * 24 * time.Hour
+
+type AuthorizeHoldTxParams struct {
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Description   string `json:"description"`
+}
+
+// AuthorizeHoldTx reserves part of the sender's balance for a transfer that
+// is captured later. The balance itself does not change, but the held amount
+// is no longer available to transfers or other holds until the hold is
+// captured, voided or expires. An account that already has the most active
+// holds it may have fails with ErrTooManyHolds.
+func (store *SQLStore) AuthorizeHoldTx(ctx context.Context, arg AuthorizeHoldTxParams) (Hold, error) {
+	var hold Hold
+
+	ttl := store.holdTTL
+	if ttl <= 0 {
+		ttl = defaultHoldTTL
+	}
+
+	err := retryTx(ctx, store.maxTxAttempts, func() error {
+		return store.execTx(ctx, func(q *Queries) error {
+			// locking the sender serializes its holds and transfers, so two
+			// of them cannot both spend the same available balance
+			account, err := q.GetAccountForUpdate(ctx, arg.FromAccountID)
+			if err != nil {
+				return err
+			}
+
+			switch account.Status {
+			case AccountStatusFrozen:
+				return ErrAccountFrozen
+			case AccountStatusClosed:
+				return ErrAccountClosed
+			}
+
+			if store.maxHoldsPerAccount > 0 {
+				count, err := q.CountActiveHolds(ctx, account.ID)
+				if err != nil {
+					return err
+				}
+				if count >= store.maxHoldsPerAccount {
+					return ErrTooManyHolds
+				}
+			}
+
+			held, err := q.SumActiveHolds(ctx, account.ID)
+			if err != nil {
+				return err
+			}
+			if account.Balance-held < arg.Amount {
+				return ErrInsufficientFunds
+			}
+
+			hold, err = q.CreateHold(ctx, CreateHoldParams{
+				FromAccountID: arg.FromAccountID,
+				ToAccountID:   arg.ToAccountID,
+				Amount:        arg.Amount,
+				Description:   arg.Description,
+				ExpiresAt:     time.Now().Add(ttl),
+			})
+			return err
+		})
+	})
+
+	return hold, err
+}
+
+type CaptureHoldTxResult struct {
+	TransferTxResult
+	Hold Hold `json:"hold"`
+}
+
+// CaptureHoldTx executes the transfer a hold was placed for the way TransferTx
+// would and releases the hold, both in one transaction, so a hold can only ever be captured once.
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, holdID int64) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	err := retryTx(ctx, store.maxTxAttempts, func() error {
+		return store.execTx(ctx, func(q *Queries) error {
+			hold, err := q.GetHoldForUpdate(ctx, holdID)
+			if err != nil {
+				return err
+			}
+
+			if hold.Status != HoldStatusHeld {
+				return ErrHoldNotActive
+			}
+			if !hold.ExpiresAt.After(time.Now()) {
+				return ErrHoldExpired
+			}
+
+			// release the hold first, so the transfer only has to leave
+			// the rest of the account's holds covered
+			_, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+				Status: HoldStatusCaptured,
+				ID:     hold.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			result.TransferTxResult, err = store.executeTransfer(ctx, q, TransferTxParams{
+				FromAccountID: hold.FromAccountID,
+				ToAccountID:   hold.ToAccountID,
+				Amount:        hold.Amount,
+				Description:   hold.Description,
+			})
+			if err != nil {
+				return err
+			}
+
+			result.Hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+				Status:     HoldStatusCaptured,
+				TransferID: util.NewNullInt64(result.Transfer.ID),
+				ID:         hold.ID,
+			})
+			return err
+		})
+	})
+
+	if err == nil {
+		store.publishTransfer(result.TransferTxResult)
+	}
+	return result, err
+}
+
+// VoidHoldTx releases a hold without moving any money.
+func (store *SQLStore) VoidHoldTx(ctx context.Context, holdID int64) (Hold, error) {
+	var hold Hold
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		hold, err = q.GetHoldForUpdate(ctx, holdID)
+		if err != nil {
+			return err
+		}
+
+		if hold.Status != HoldStatusHeld {
+			return ErrHoldNotActive
+		}
+
+		hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			Status: HoldStatusVoided,
+			ID:     hold.ID,
+		})
+		return err
+	})
+
+	return hold, err
+}
+
+// VoidExpiredHoldTx voids the hold that expired longest ago. An expired hold
+// already no longer counts against the balance; voiding it records that the
+// funds were released. Holds that were captured or voided in the meantime are
+// never picked, and one locked by a capture in flight is skipped.
+// It returns ErrRecordNotFound when no hold has expired.
+func (store *SQLStore) VoidExpiredHoldTx(ctx context.Context) (Hold, error) {
+	var hold Hold
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		hold, err = q.GetNextExpiredHold(ctx)
+		if err != nil {
+			return err
+		}
+
+		hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			Status: HoldStatusVoided,
+			ID:     hold.ID,
+		})
+		return err
+	})
+
+	return hold, err
+}
+
 func addMoney(
 	ctx context.Context,
 	q *Queries,
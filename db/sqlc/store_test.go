@@ -2,16 +2,26 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/qwerqy/mock_bank/util"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
 	// run n concurrent transfer transactions
 	n := 5
 	amount := int64(10)
@@ -56,6 +66,7 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, fromEntry)
 		require.Equal(t, account1.ID, fromEntry.AccountID)
 		require.Equal(t, -amount, fromEntry.Amount)
+		require.Equal(t, EntryTypeTransferDebit, fromEntry.Type)
 		require.NotZero(t, fromEntry.ID)
 		require.NotZero(t, fromEntry.CreatedAt)
 
@@ -66,6 +77,7 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, toEntry)
 		require.Equal(t, account2.ID, toEntry.AccountID)
 		require.Equal(t, amount, toEntry.Amount)
+		require.Equal(t, EntryTypeTransferCredit, toEntry.Type)
 		require.NotZero(t, toEntry.ID)
 		require.NotZero(t, toEntry.CreatedAt)
 
@@ -104,8 +116,8 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
 	// run n concurrent transfer transactions
 	n := 10
 	amount := int64(10)
@@ -144,3 +156,2081 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestProcessTransferJobTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
+	job := createRandomTransferJob(t, account1.ID, account2.ID)
+
+	// drain the queue, other tests may have left pending jobs behind
+	var processed TransferJob
+	for {
+		result, err := store.ProcessTransferJobTx(context.Background())
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+
+		if result.ID == job.ID {
+			processed = result
+		}
+	}
+
+	require.Equal(t, job.ID, processed.ID)
+	require.Equal(t, TransferJobStatusCompleted, processed.Status)
+	require.True(t, processed.TransferID.Valid)
+	require.True(t, processed.ProcessedAt.Valid)
+
+	transfer, err := store.GetTransfer(context.Background(), processed.TransferID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, job.Amount, transfer.Amount)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-job.Amount, updatedAccount1.Balance)
+
+	// a completed job is not picked up again
+	_, err = store.ProcessTransferJobTx(context.Background())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	updatedAccount1, err = store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-job.Amount, updatedAccount1.Balance)
+}
+
+func TestProcessTransferJobTxRetry(t *testing.T) {
+	errClosed := errors.New("account is closed")
+
+	testCases := []struct {
+		name        string
+		transferErr error
+		wantBegun   int
+		wantFailed  bool
+	}{
+		{
+			name:        "SerializationFailure",
+			transferErr: &pq.Error{Code: "40001"},
+			wantBegun:   3,
+		},
+		{
+			name:        "Deadlock",
+			transferErr: &pq.Error{Code: "40P01"},
+			wantBegun:   3,
+		},
+		{
+			name:        "TransferFailed",
+			transferErr: errClosed,
+			wantBegun:   1,
+			wantFailed:  true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var failed []string
+			fake := &fakeTxDriver{
+				query: func(query string) ([]driver.Value, error) {
+					job := []driver.Value{int64(1), int64(1), int64(2), int64(10), TransferJobStatusPending, "", nil, time.Now(), nil, ""}
+
+					switch queryName(query) {
+					case "GetNextPendingTransferJob":
+						return job, nil
+					case "UpdateTransferJobStatus":
+						failed = append(failed, query)
+						job[4] = TransferJobStatusFailed
+						return job, nil
+					}
+					return nil, tc.transferErr
+				},
+			}
+			store := NewStore(sql.OpenDB(fake)).(*SQLStore)
+			store.transferIsolation = sql.LevelSerializable
+
+			job, err := store.ProcessTransferJobTx(context.Background())
+			require.Len(t, fake.begun, tc.wantBegun)
+			for _, opts := range fake.begun {
+				require.Equal(t, driver.IsolationLevel(sql.LevelSerializable), opts.Isolation)
+			}
+			require.Zero(t, fake.committed)
+
+			if tc.wantFailed {
+				require.NoError(t, err)
+				require.Len(t, failed, 1)
+				require.Equal(t, TransferJobStatusFailed, job.Status)
+				return
+			}
+
+			// the job stays pending for the next run
+			require.ErrorIs(t, err, tc.transferErr)
+			require.Empty(t, failed)
+		})
+	}
+}
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
+	amount := int64(10)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ReverseTransferTx(context.Background(), original.Transfer.ID)
+	require.NoError(t, err)
+
+	reversal := result.Transfer
+	require.Equal(t, account2.ID, reversal.FromAccountID)
+	require.Equal(t, account1.ID, reversal.ToAccountID)
+	require.Equal(t, amount, reversal.Amount)
+	require.True(t, reversal.ReversalOf.Valid)
+	require.Equal(t, original.Transfer.ID, reversal.ReversalOf.Int64)
+
+	// balances are back to where they started
+	require.Equal(t, account1.Balance, result.ToAccount.Balance)
+	require.Equal(t, account2.Balance, result.FromAccount.Balance)
+
+	// a transfer can only be reversed once
+	_, err = store.ReverseTransferTx(context.Background(), original.Transfer.ID)
+	require.ErrorIs(t, err, ErrTransferAlreadyReversed)
+}
+
+func TestReverseTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
+	account3 := fundedAccount(t, 1000)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// drain the destination so it can no longer pay the money back
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account3.ID,
+		Amount:        original.ToAccount.Balance,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReverseTransferTx(context.Background(), original.Transfer.ID)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// nothing was reversed, so the balances are untouched
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedAccount2.Balance)
+
+	_, err = testQueries.GetTransferReversal(context.Background(), util.NewNullInt64(original.Transfer.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestReverseTransferTxNotFound(t *testing.T) {
+	store := NewStore(testDB)
+
+	_, err := store.ReverseTransferTx(context.Background(), -1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestApproveTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
+	approver := createRandomUser(t)
+	amount := int64(10)
+
+	approval, err := store.CreatePendingApproval(context.Background(), CreatePendingApprovalParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+		InitiatedBy:   account1.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PendingApprovalStatusPending, approval.Status)
+	require.False(t, approval.TransferID.Valid)
+
+	// the initiator cannot approve their own transfer
+	_, err = store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		ID:         approval.ID,
+		ApprovedBy: account1.Owner,
+	})
+	require.ErrorIs(t, err, ErrSelfApproval)
+
+	// nothing has moved while the transfer waits for approval
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	result, err := store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		ID:         approval.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account1.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account2.ID, result.Transfer.ToAccountID)
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.Equal(t, account1.Balance-amount, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+amount, result.ToAccount.Balance)
+
+	require.Equal(t, PendingApprovalStatusApproved, result.Approval.Status)
+	require.Equal(t, approver.Username, result.Approval.ApprovedBy.String)
+	require.Equal(t, result.Transfer.ID, result.Approval.TransferID.Int64)
+	require.True(t, result.Approval.ApprovedAt.Valid)
+
+	// an approved transfer is never executed twice
+	_, err = store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		ID:         approval.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.ErrorIs(t, err, ErrApprovalNotPending)
+}
+
+func TestTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+
+	_, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// the debit was rolled back
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	// a frozen account can still be credited
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: AccountStatusActive,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-arg.Amount+10, result.FromAccount.Balance)
+}
+
+func TestTransferTxClosedAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account2.ID,
+		Status: AccountStatusClosed,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestTransferTxPrecheck(t *testing.T) {
+	// errWrite stands in for everything after the prechecks: the first write
+	// of the transfer is where it starts taking row locks
+	errWrite := errors.New("write reached")
+
+	account := func(id int64, currency string, status string) []driver.Value {
+		return []driver.Value{id, "owner", int64(100), currency, time.Now(), "", status, []byte("{}"), nil, nil, uuid.New().String()}
+	}
+	usd := account(1, util.USD, AccountStatusActive)
+	eur := account(2, util.EUR, AccountStatusActive)
+
+	testCases := []struct {
+		name string
+		arg  TransferTxParams
+		// from and to answer the two account reads in turn, nil when the
+		// account does not exist
+		from    []driver.Value
+		to      []driver.Value
+		wantErr error
+	}{
+		{
+			name:    "Valid",
+			arg:     TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: util.USD},
+			from:    usd,
+			to:      account(2, util.USD, AccountStatusActive),
+			wantErr: errWrite,
+		},
+		{
+			name:    "CurrencyMismatch",
+			arg:     TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: util.USD},
+			from:    usd,
+			to:      eur,
+			wantErr: ErrCurrencyMismatch,
+		},
+		{
+			name:    "ZeroAmount",
+			arg:     TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 0},
+			wantErr: ErrInvalidAmount,
+		},
+		{
+			name:    "MissingAccount",
+			arg:     TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 10},
+			from:    usd,
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "FrozenAccount",
+			arg:     TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 10},
+			from:    account(1, util.USD, AccountStatusFrozen),
+			to:      eur,
+			wantErr: ErrAccountFrozen,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var queries []string
+			fake := &fakeTxDriver{
+				query: func(query string) ([]driver.Value, error) {
+					name := queryName(query)
+					queries = append(queries, name)
+					if name != "GetAccount" {
+						return nil, errWrite
+					}
+
+					row := tc.from
+					if len(queries) > 1 {
+						row = tc.to
+					}
+					if row == nil {
+						return nil, sql.ErrNoRows
+					}
+					return row, nil
+				},
+			}
+			store := NewStore(sql.OpenDB(fake)).(*SQLStore)
+			store.maxTxAttempts = 1
+
+			locked := false
+			store.beforeLock = func() { locked = true }
+
+			_, err := store.TransferTx(context.Background(), tc.arg)
+			require.ErrorIs(t, err, tc.wantErr)
+			require.Zero(t, fake.committed)
+			require.Equal(t, 1, fake.rolledBack)
+
+			if tc.wantErr == errWrite {
+				require.True(t, locked)
+				return
+			}
+
+			// nothing but the plain account reads ran
+			require.False(t, locked)
+			for _, query := range queries {
+				require.Equal(t, "GetAccount", query)
+			}
+		})
+	}
+}
+
+func TestTransferTxBalanceCap(t *testing.T) {
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
+
+	// account2 may take in 10 more before it reaches its cap
+	store := &SQLStore{
+		db:            testDB,
+		Queries:       New(testDB),
+		maxTxAttempts: defaultMaxTxAttempts,
+		balanceCaps:   map[string]int64{account2.Currency: account2.Balance + 10},
+	}
+
+	// a credit that lands exactly on the cap is allowed
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+10, result.ToAccount.Balance)
+
+	// one more would take it past the cap
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrBalanceCapExceeded)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+10, updatedAccount2.Balance)
+}
+
+// newCappedStore returns a store that lets account take in at most room more.
+func newCappedStore(account Account, room int64) *SQLStore {
+	return &SQLStore{
+		db:            testDB,
+		Queries:       New(testDB),
+		maxTxAttempts: defaultMaxTxAttempts,
+		balanceCaps:   map[string]int64{account.Currency: account.Balance + room},
+	}
+}
+
+func TestProcessTransferJobTxBalanceCap(t *testing.T) {
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	store := newCappedStore(account2, 5)
+
+	job, err := testQueries.CreateTransferJob(context.Background(), CreateTransferJobParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// drain the queue, other tests may have left pending jobs behind
+	for {
+		_, err := store.ProcessTransferJobTx(context.Background())
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	failed, err := testQueries.GetTransferJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferJobStatusFailed, failed.Status)
+	require.Equal(t, ErrBalanceCapExceeded.Error(), failed.Error)
+	require.False(t, failed.TransferID.Valid)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestApproveTransferTxBalanceCap(t *testing.T) {
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	approver := createRandomUser(t)
+	store := newCappedStore(account2, 5)
+
+	approval, err := store.CreatePendingApproval(context.Background(), CreatePendingApprovalParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		InitiatedBy:   account1.Owner,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		ID:         approval.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.ErrorIs(t, err, ErrBalanceCapExceeded)
+
+	// the approval stays pending and nothing moved
+	pending, err := testQueries.GetPendingApproval(context.Background(), approval.ID)
+	require.NoError(t, err)
+	require.Equal(t, PendingApprovalStatusPending, pending.Status)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestReverseTransferTxBalanceCap(t *testing.T) {
+	account1 := fundedAccount(t, 100)
+	account2 := fundedAccount(t, 100)
+
+	result, err := NewStore(testDB).TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// account1 is back where it started after the reversal, one short of that
+	// is all the cap allows
+	store := newCappedStore(result.FromAccount, 9)
+	_, err = store.ReverseTransferTx(context.Background(), result.Transfer.ID)
+	require.ErrorIs(t, err, ErrBalanceCapExceeded)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(90), updatedAccount1.Balance)
+}
+
+func TestAdjustBalanceTxBalanceCap(t *testing.T) {
+	account := fundedAccount(t, 100)
+	admin := createRandomUser(t)
+	store := newCappedStore(account, 10)
+
+	_, err := store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID:  account.ID,
+		Amount:     11,
+		Reason:     "correction",
+		AdjustedBy: admin.Username,
+	})
+	require.ErrorIs(t, err, ErrBalanceCapExceeded)
+
+	// debits are never held back by the cap
+	result, err := store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID:  account.ID,
+		Amount:     -10,
+		Reason:     "correction",
+		AdjustedBy: admin.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(90), result.Account.Balance)
+}
+
+func TestOpenAccountTxBalanceCap(t *testing.T) {
+	user := createRandomUser(t)
+	store := newCappedStore(Account{Currency: util.USD}, 100)
+
+	_, err := store.OpenAccountTx(context.Background(), OpenAccountTxParams{
+		Owner:          user.Username,
+		Currency:       util.USD,
+		InitialDeposit: 101,
+	})
+	require.ErrorIs(t, err, ErrBalanceCapExceeded)
+
+	result, err := store.OpenAccountTx(context.Background(), OpenAccountTxParams{
+		Owner:          user.Username,
+		Currency:       util.USD,
+		InitialDeposit: 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), result.Account.Balance)
+}
+
+func TestSplitTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	source := fundedAccount(t, 100)
+	target1 := createRandomAccount(t)
+	target2 := createRandomAccount(t)
+
+	result, err := store.SplitTransferTx(context.Background(), SplitTransferTxParams{
+		FromAccountID: source.ID,
+		Targets: []SplitTarget{
+			{ToAccountID: target2.ID, Amount: 30},
+			{ToAccountID: target1.ID, Amount: 20},
+		},
+		Description: "dinner",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(50), result.FromAccount.Balance)
+
+	// the transfers come back in the order the targets were given
+	require.Len(t, result.Transfers, 2)
+	for i, target := range []Account{target2, target1} {
+		leg := result.Transfers[i]
+		require.Equal(t, source.ID, leg.Transfer.FromAccountID)
+		require.Equal(t, target.ID, leg.Transfer.ToAccountID)
+		require.Equal(t, "dinner", leg.Transfer.Description)
+		require.Equal(t, -leg.Transfer.Amount, leg.FromEntry.Amount)
+		require.Equal(t, leg.Transfer.Amount, leg.ToEntry.Amount)
+		require.Equal(t, target.Balance+leg.Transfer.Amount, leg.ToAccount.Balance)
+	}
+
+	// 60 more would overdraw the 50 left, so neither target is paid
+	_, err = store.SplitTransferTx(context.Background(), SplitTransferTxParams{
+		FromAccountID: source.ID,
+		Targets: []SplitTarget{
+			{ToAccountID: target1.ID, Amount: 30},
+			{ToAccountID: target2.ID, Amount: 30},
+		},
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	for _, want := range []Account{result.FromAccount, result.Transfers[0].ToAccount, result.Transfers[1].ToAccount} {
+		account, err := testQueries.GetAccount(context.Background(), want.ID)
+		require.NoError(t, err)
+		require.Equal(t, want.Balance, account.Balance)
+	}
+}
+
+func TestTransferTxDailyLimit(t *testing.T) {
+	store := &SQLStore{
+		db:                 testDB,
+		Queries:            New(testDB),
+		maxTxAttempts:      defaultMaxTxAttempts,
+		dailyTransferLimit: 30,
+	}
+
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+
+	// three transfers of 10 reach the limit of 30 exactly
+	for i := 0; i < 3; i++ {
+		_, err := store.TransferTx(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	// the fourth would push the total to 40
+	_, err := store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrDailyLimitExceeded)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-30, updatedAccount1.Balance)
+
+	total, err := testQueries.SumOutboundTransfersSince(context.Background(), SumOutboundTransfersSinceParams{
+		AccountID: account1.ID,
+		Since:     time.Now().Add(-24 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(30), total)
+
+	// the limit is per source account, the receiver can still send
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        30,
+	})
+	require.NoError(t, err)
+}
+
+func TestTransferTxDailyLimitOverride(t *testing.T) {
+	store := &SQLStore{
+		db:                 testDB,
+		Queries:            New(testDB),
+		maxTxAttempts:      defaultMaxTxAttempts,
+		dailyTransferLimit: 30,
+	}
+
+	raised := fundedAccount(t, 1000)
+	lowered := fundedAccount(t, 1000)
+	receiver := fundedAccount(t, 1000)
+
+	_, err := testQueries.UpdateAccountLimitOverrides(context.Background(), UpdateAccountLimitOverridesParams{
+		ID:                 raised.ID,
+		DailyLimitOverride: util.NewNullInt64(50),
+	})
+	require.NoError(t, err)
+	_, err = testQueries.UpdateAccountLimitOverrides(context.Background(), UpdateAccountLimitOverridesParams{
+		ID:                 lowered.ID,
+		DailyLimitOverride: util.NewNullInt64(10),
+	})
+	require.NoError(t, err)
+
+	// over the global limit of 30, within the override of 50
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: raised.ID,
+		ToAccountID:   receiver.ID,
+		Amount:        40,
+	})
+	require.NoError(t, err)
+
+	// within the global limit, over the override of 10
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: lowered.ID,
+		ToAccountID:   receiver.ID,
+		Amount:        20,
+	})
+	require.ErrorIs(t, err, ErrDailyLimitExceeded)
+}
+
+func TestTransferFee(t *testing.T) {
+	testCases := []struct {
+		name   string
+		fee    TransferFee
+		amount int64
+		want   int64
+	}{
+		{name: "Zero", fee: TransferFee{}, amount: 1000, want: 0},
+		{name: "Flat", fee: TransferFee{Flat: 25}, amount: 1000, want: 25},
+		{name: "Percentage", fee: TransferFee{BasisPoints: 150}, amount: 1000, want: 15},
+		{name: "FlatAndPercentage", fee: TransferFee{Flat: 25, BasisPoints: 150}, amount: 1000, want: 40},
+		{name: "RoundsDown", fee: TransferFee{BasisPoints: 150}, amount: 99, want: 1},
+		{name: "RoundsUp", fee: TransferFee{BasisPoints: 150}, amount: 999, want: 15},
+		{name: "RoundsHalfUp", fee: TransferFee{Flat: 25, BasisPoints: 150}, amount: 100, want: 27},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.fee.For(tc.amount))
+		})
+	}
+}
+
+func TestTransferTxFee(t *testing.T) {
+	testCases := []struct {
+		name string
+		fee  TransferFee
+		want int64
+	}{
+		{name: "ZeroFee", fee: TransferFee{}, want: 0},
+		{name: "FlatFee", fee: TransferFee{Flat: 5}, want: 5},
+		{name: "PercentageFee", fee: TransferFee{BasisPoints: 1000}, want: 1},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			account1 := fundedAccount(t, 100)
+			account2 := createRandomAccount(t)
+			store, feeAccount := newFeeStore(t, tc.fee, account1.Currency)
+
+			result, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        10,
+			})
+			require.NoError(t, err)
+
+			require.Equal(t, tc.want, result.Fee)
+			require.Equal(t, account1.Balance-10-tc.want, result.FromAccount.Balance)
+			require.Equal(t, account2.Balance+10, result.ToAccount.Balance)
+			if tc.want > 0 {
+				require.Equal(t, EntryTypeFee, result.FeeEntry.Type)
+				require.Equal(t, account1.ID, result.FeeEntry.AccountID)
+				require.Equal(t, -tc.want, result.FeeEntry.Amount)
+			} else {
+				require.Empty(t, result.FeeEntry)
+			}
+
+			updatedFeeAccount, err := testQueries.GetAccount(context.Background(), feeAccount.ID)
+			require.NoError(t, err)
+			require.Equal(t, feeAccount.Balance+tc.want, updatedFeeAccount.Balance)
+		})
+	}
+}
+
+func TestTransferTxFeeInsufficientFunds(t *testing.T) {
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	store, feeAccount := newFeeStore(t, TransferFee{Flat: 10}, account1.Currency)
+
+	// 95 is covered, 95 plus the fee of 10 is not
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        95,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedFeeAccount, err := testQueries.GetAccount(context.Background(), feeAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, feeAccount.Balance, updatedFeeAccount.Balance)
+}
+
+func TestTransferTxFeeAccountMismatch(t *testing.T) {
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+
+	other := util.USD
+	if account1.Currency == util.USD {
+		other = util.EUR
+	}
+	store, otherFeeAccount := newFeeStore(t, TransferFee{Flat: 5}, other)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+
+	// no fee account collects the sender's currency
+	_, err := store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrFeeAccountMismatch)
+
+	// the fee account for the sender's currency holds another one
+	store.feeAccounts = map[string]int64{account1.Currency: otherFeeAccount.ID}
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrFeeAccountMismatch)
+
+	for _, account := range []Account{account1, otherFeeAccount} {
+		updated, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, updated.Balance)
+	}
+}
+
+// newFeeStore returns a store charging fee into a fresh fee account that
+// collects it in currency.
+func newFeeStore(t *testing.T, fee TransferFee, currency string) (*SQLStore, Account) {
+	feeAccount, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    createRandomUser(t).Username,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+
+	store := &SQLStore{
+		db:            testDB,
+		Queries:       New(testDB),
+		maxTxAttempts: defaultMaxTxAttempts,
+		transferFee:   fee,
+		feeAccounts:   map[string]int64{currency: feeAccount.ID},
+	}
+	return store, feeAccount
+}
+
+// requireFeeCharged checks that account paid a fee of 5 as an entry of its
+// own and that the fee account received it.
+func requireFeeCharged(t *testing.T, account Account, feeAccount Account) {
+	entries, err := testQueries.ListEntry(context.Background(), ListEntryParams{
+		AccountID: account.ID,
+		Type:      sql.NullString{String: EntryTypeFee, Valid: true},
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, int64(-5), entries[0].Amount)
+
+	updatedFeeAccount, err := testQueries.GetAccount(context.Background(), feeAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, feeAccount.Balance+5, updatedFeeAccount.Balance)
+}
+
+func TestProcessTransferJobTxFee(t *testing.T) {
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	store, feeAccount := newFeeStore(t, TransferFee{Flat: 5}, account1.Currency)
+	job, err := testQueries.CreateTransferJob(context.Background(), CreateTransferJobParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// drain the queue, other tests may have left pending jobs behind
+	for {
+		_, err := store.ProcessTransferJobTx(context.Background())
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	processed, err := testQueries.GetTransferJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferJobStatusCompleted, processed.Status)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100-10-5), updatedAccount1.Balance)
+	requireFeeCharged(t, account1, feeAccount)
+}
+
+func TestApproveTransferTxFee(t *testing.T) {
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	store, feeAccount := newFeeStore(t, TransferFee{Flat: 5}, account1.Currency)
+	approver := createRandomUser(t)
+
+	approval, err := store.CreatePendingApproval(context.Background(), CreatePendingApprovalParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		InitiatedBy:   account1.Owner,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		ID:         approval.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(5), result.Fee)
+	require.Equal(t, int64(100-10-5), result.FromAccount.Balance)
+	requireFeeCharged(t, account1, feeAccount)
+}
+
+func TestCaptureHoldTxFee(t *testing.T) {
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	store, feeAccount := newFeeStore(t, TransferFee{Flat: 5}, account1.Currency)
+
+	hold, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        60,
+	})
+	require.NoError(t, err)
+
+	result, err := store.CaptureHoldTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, result.Hold.Status)
+	require.Equal(t, result.Transfer.ID, result.Hold.TransferID.Int64)
+	require.Equal(t, int64(5), result.Fee)
+	require.Equal(t, int64(100-60-5), result.FromAccount.Balance)
+	requireFeeCharged(t, account1, feeAccount)
+}
+
+func TestPreviewTransferTx(t *testing.T) {
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	store, feeAccount := newFeeStore(t, TransferFee{Flat: 5}, account1.Currency)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+
+	preview, err := store.PreviewTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), preview.Fee)
+	require.Equal(t, account1.Balance-15, preview.FromAccount.Balance)
+	require.Equal(t, account2.Balance+10, preview.ToAccount.Balance)
+
+	// the preview left nothing behind
+	for _, account := range []Account{account1, account2, feeAccount} {
+		updated, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, updated.Balance)
+
+		sum, err := testQueries.SumEntries(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Zero(t, sum)
+	}
+	transfers, err := testQueries.ListTransfersForAccounts(context.Background(), ListTransfersForAccountsParams{
+		AccountIds: []int64{account1.ID},
+		Limit:      10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+
+	// and it matches what the transfer itself does
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, preview.Fee, result.Fee)
+	require.Equal(t, preview.FromAccount.Balance, result.FromAccount.Balance)
+	require.Equal(t, preview.ToAccount.Balance, result.ToAccount.Balance)
+}
+
+func TestPreviewTransferTxInsufficientFunds(t *testing.T) {
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	store, _ := newFeeStore(t, TransferFee{Flat: 10}, account1.Currency)
+
+	_, err := store.PreviewTransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        95,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestOpenAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	testCases := []struct {
+		name    string
+		deposit int64
+	}{
+		{name: "NoDeposit", deposit: 0},
+		{name: "Deposit", deposit: 100},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			user := createRandomUser(t)
+
+			result, err := store.OpenAccountTx(context.Background(), OpenAccountTxParams{
+				Owner:          user.Username,
+				Currency:       util.RandomCurrency(),
+				InitialDeposit: tc.deposit,
+			})
+			require.NoError(t, err)
+			require.Equal(t, user.Username, result.Account.Owner)
+			require.Equal(t, tc.deposit, result.Account.Balance)
+			require.NotZero(t, result.Account.CreatedAt)
+
+			entries, err := store.ListEntry(context.Background(), ListEntryParams{
+				AccountID: result.Account.ID,
+				Limit:     5,
+				Offset:    0,
+			})
+			require.NoError(t, err)
+
+			if tc.deposit == 0 {
+				require.Empty(t, entries)
+				require.Zero(t, result.Entry.ID)
+				return
+			}
+
+			require.Len(t, entries, 1)
+			require.Equal(t, result.Entry, entries[0])
+			require.Equal(t, tc.deposit, result.Entry.Amount)
+			require.Equal(t, EntryTypeDeposit, result.Entry.Type)
+		})
+	}
+}
+
+func TestOpenAccountTxNegativeDeposit(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	_, err := store.OpenAccountTx(context.Background(), OpenAccountTxParams{
+		Owner:          user.Username,
+		Currency:       util.RandomCurrency(),
+		InitialDeposit: -1,
+	})
+	require.ErrorIs(t, err, ErrNegativeDeposit)
+}
+
+func TestOpenAccountTxMetadata(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	metadata := json.RawMessage(`{"crm_id": "C-1042"}`)
+	result, err := store.OpenAccountTx(context.Background(), OpenAccountTxParams{
+		Owner:    user.Username,
+		Currency: util.RandomCurrency(),
+		Metadata: metadata,
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, string(metadata), string(result.Account.Metadata))
+
+	account, err := store.GetAccount(context.Background(), result.Account.ID)
+	require.NoError(t, err)
+	require.JSONEq(t, string(metadata), string(account.Metadata))
+}
+
+func TestOpenAccountTxEntryFails(t *testing.T) {
+	errEntry := errors.New("cannot insert entry")
+
+	fake := &fakeTxDriver{
+		query: func(query string) ([]driver.Value, error) {
+			switch {
+			case strings.Contains(query, "INSERT INTO accounts"):
+				return []driver.Value{int64(1), "owner", int64(100), util.USD, time.Now(), "", AccountStatusActive, []byte("{}"), nil, nil, uuid.New().String()}, nil
+			case strings.Contains(query, "INSERT INTO entries"):
+				return nil, errEntry
+			}
+			return nil, fmt.Errorf("unexpected query %q", query)
+		},
+	}
+	store := NewStore(sql.OpenDB(fake))
+
+	_, err := store.OpenAccountTx(context.Background(), OpenAccountTxParams{
+		Owner:          "owner",
+		Currency:       util.USD,
+		InitialDeposit: 100,
+	})
+	require.ErrorIs(t, err, errEntry)
+
+	// the account was inserted in the same transaction, which never committed
+	require.Len(t, fake.begun, 1)
+	require.Zero(t, fake.committed)
+	require.Equal(t, 1, fake.rolledBack)
+}
+
+func TestExecTxAfterCommit(t *testing.T) {
+	errFn := errors.New("fn failed")
+	errCommit := errors.New("commit failed")
+
+	testCases := []struct {
+		name      string
+		fn        func(*Queries) error
+		commitErr error
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "Committed",
+			fn:        func(*Queries) error { return nil },
+			wantCalls: 1,
+		},
+		{
+			name:    "RolledBack",
+			fn:      func(*Queries) error { return errFn },
+			wantErr: errFn,
+		},
+		{
+			name:      "CommitFailed",
+			fn:        func(*Queries) error { return nil },
+			commitErr: errCommit,
+			wantErr:   errCommit,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeTxDriver{commitErr: tc.commitErr}
+			store := NewStore(sql.OpenDB(fake)).(*SQLStore)
+
+			calls := 0
+			store.afterCommit = func() { calls++ }
+
+			err := store.execTx(context.Background(), tc.fn)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantCalls, calls)
+		})
+	}
+}
+
+func TestOpenAccountTxAfterCommit(t *testing.T) {
+	fake := &fakeTxDriver{
+		query: func(query string) ([]driver.Value, error) {
+			switch {
+			case strings.Contains(query, "INSERT INTO accounts"):
+				return []driver.Value{int64(1), "owner", int64(100), util.USD, time.Now(), "", AccountStatusActive, []byte("{}"), nil, nil, uuid.New().String()}, nil
+			case strings.Contains(query, "INSERT INTO entries"):
+				return []driver.Value{int64(1), int64(1), int64(100), time.Now(), EntryTypeDeposit}, nil
+			}
+			return nil, fmt.Errorf("unexpected query %q", query)
+		},
+	}
+	store := NewStore(sql.OpenDB(fake)).(*SQLStore)
+
+	calls := 0
+	store.afterCommit = func() {
+		// by the time it runs the transaction is over
+		require.Equal(t, 1, fake.committed)
+		calls++
+	}
+
+	_, err := store.OpenAccountTx(context.Background(), OpenAccountTxParams{
+		Owner:          "owner",
+		Currency:       util.USD,
+		InitialDeposit: 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+
+	// a failed one does not fire it
+	fake.query = func(query string) ([]driver.Value, error) {
+		return nil, errors.New("cannot insert account")
+	}
+	_, err = store.OpenAccountTx(context.Background(), OpenAccountTxParams{
+		Owner:    "owner",
+		Currency: util.USD,
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+	require.Equal(t, 1, fake.rolledBack)
+}
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	t.Run("EmptyAccount", func(t *testing.T) {
+		account := createRandomAccount(t)
+		account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+			ID:      account.ID,
+			Balance: 0,
+		})
+		require.NoError(t, err)
+
+		result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+		require.NoError(t, err)
+		require.Equal(t, AccountStatusClosed, result.Account.Status)
+		require.Nil(t, result.Sweep)
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		account := createRandomAccount(t)
+		destination := createRandomAccount(t)
+		account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+			ID:      account.ID,
+			Balance: 100,
+		})
+		require.NoError(t, err)
+
+		result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+			AccountID:        account.ID,
+			SweepToAccountID: destination.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, AccountStatusClosed, result.Account.Status)
+		require.Zero(t, result.Account.Balance)
+
+		require.NotNil(t, result.Sweep)
+		require.Equal(t, account.Balance, result.Sweep.Transfer.Amount)
+		require.Equal(t, destination.Balance+account.Balance, result.Sweep.ToAccount.Balance)
+
+		// a closed account can no longer send money either
+		_, err = store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account.ID,
+			ToAccountID:   destination.ID,
+			Amount:        10,
+		})
+		require.ErrorIs(t, err, ErrAccountClosed)
+	})
+
+	t.Run("FundedWithoutDestination", func(t *testing.T) {
+		account := createRandomAccount(t)
+		account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+			ID:      account.ID,
+			Balance: 100,
+		})
+		require.NoError(t, err)
+
+		_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+		require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+		updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Status, updatedAccount.Status)
+		require.Equal(t, account.Balance, updatedAccount.Balance)
+	})
+
+	t.Run("AlreadyClosed", func(t *testing.T) {
+		account := createRandomAccount(t)
+		_, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+			ID:     account.ID,
+			Status: AccountStatusClosed,
+		})
+		require.NoError(t, err)
+
+		_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+		require.ErrorIs(t, err, ErrAccountClosed)
+	})
+}
+
+func fundedAccount(t *testing.T, balance int64) Account {
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      createRandomAccount(t).ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestAuthorizeHoldTxCapture(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+
+	hold, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        60,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusHeld, hold.Status)
+	require.True(t, hold.ExpiresAt.After(time.Now()))
+
+	// a hold leaves the balance itself alone
+	held, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, held.Balance)
+
+	result, err := store.CaptureHoldTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, result.Hold.Status)
+	require.Equal(t, result.Transfer.ID, result.Hold.TransferID.Int64)
+	require.Equal(t, int64(60), result.Transfer.Amount)
+	require.Equal(t, account1.Balance-60, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+60, result.ToAccount.Balance)
+
+	// a hold is captured only once
+	_, err = store.CaptureHoldTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotActive)
+	_, err = store.VoidHoldTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestAuthorizeHoldTxVoid(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+
+	hold, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	voided, err := store.VoidHoldTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusVoided, voided.Status)
+
+	_, err = store.CaptureHoldTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotActive)
+
+	// the voided hold no longer holds anything back
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+}
+
+func TestAuthorizeHoldTxLimit(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+	store.maxHoldsPerAccount = 2
+
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	arg := AuthorizeHoldTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+
+	// the second hold reaches the limit of 2 exactly
+	var holds []Hold
+	for i := 0; i < 2; i++ {
+		hold, err := store.AuthorizeHoldTx(context.Background(), arg)
+		require.NoError(t, err)
+		holds = append(holds, hold)
+	}
+
+	// the third would be one past it
+	_, err := store.AuthorizeHoldTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrTooManyHolds)
+
+	// a released hold makes room for another
+	_, err = store.VoidHoldTx(context.Background(), holds[0].ID)
+	require.NoError(t, err)
+	_, err = store.AuthorizeHoldTx(context.Background(), arg)
+	require.NoError(t, err)
+}
+
+func TestAuthorizeHoldTxLimitBoundary(t *testing.T) {
+	testCases := []struct {
+		name    string
+		active  int64
+		wantErr error
+	}{
+		{name: "BelowLimit", active: 2},
+		{name: "AtLimit", active: 3, wantErr: ErrTooManyHolds},
+		{name: "PastLimit", active: 4, wantErr: ErrTooManyHolds},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			created := false
+			fake := &fakeTxDriver{
+				query: func(query string) ([]driver.Value, error) {
+					switch queryName(query) {
+					case "GetAccountForUpdate":
+						return []driver.Value{int64(1), "owner", int64(100), util.USD, time.Now(), "", AccountStatusActive, []byte("{}"), nil, nil, uuid.New().String()}, nil
+					case "CountActiveHolds":
+						return []driver.Value{tc.active}, nil
+					case "SumActiveHolds":
+						return []driver.Value{int64(0)}, nil
+					case "CreateHold":
+						created = true
+						return []driver.Value{int64(1), int64(1), int64(2), int64(10), "", HoldStatusHeld, nil, time.Now().Add(time.Hour), time.Now()}, nil
+					}
+					return nil, errors.New("unexpected query")
+				},
+			}
+			store := NewStore(sql.OpenDB(fake)).(*SQLStore)
+			store.maxTxAttempts = 1
+			store.maxHoldsPerAccount = 3
+
+			_, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+				FromAccountID: 1,
+				ToAccountID:   2,
+				Amount:        10,
+			})
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				require.False(t, created)
+				require.Equal(t, 1, fake.rolledBack)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, created)
+			require.Equal(t, 1, fake.committed)
+		})
+	}
+}
+
+func TestTransferTxHeldFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+
+	_, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        70,
+	})
+	require.NoError(t, err)
+
+	// 40 is within the balance but not within what the hold left available
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        40,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        40,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        30,
+	})
+	require.NoError(t, err)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(70), updatedAccount1.Balance)
+}
+
+func TestCaptureHoldTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 100)
+	account2 := fundedAccount(t, 1000)
+	hold := createRandomHold(t, account1, account2, 10, time.Now().Add(-time.Second))
+
+	_, err := store.CaptureHoldTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrHoldExpired)
+
+	// an expired hold no longer counts against the balance
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+}
+
+func TestVoidExpiredHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	expired := createRandomHold(t, account1, account2, 10, time.Now().Add(-time.Second))
+	valid := createRandomHold(t, account1, account2, 20, time.Now().Add(time.Hour))
+
+	captured := createRandomHold(t, account1, account2, 30, time.Now().Add(-time.Second))
+	transfer := createRandomTransfer(t, account1.ID, account2.ID)
+	_, err := testQueries.UpdateHoldStatus(context.Background(), UpdateHoldStatusParams{
+		Status:     HoldStatusCaptured,
+		TransferID: util.NewNullInt64(transfer.ID),
+		ID:         captured.ID,
+	})
+	require.NoError(t, err)
+
+	// other tests leave expired holds behind too, so drain them all
+	for {
+		_, err := store.VoidExpiredHoldTx(context.Background())
+		if errors.Is(err, ErrRecordNotFound) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	hold, err := testQueries.GetHold(context.Background(), expired.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusVoided, hold.Status)
+
+	hold, err = testQueries.GetHold(context.Background(), valid.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusHeld, hold.Status)
+
+	hold, err = testQueries.GetHold(context.Background(), captured.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, hold.Status)
+	require.Equal(t, transfer.ID, hold.TransferID.Int64)
+
+	// nothing is left to void the second time round
+	_, err = store.VoidExpiredHoldTx(context.Background())
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestReassignAccountOwnerTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	newOwner := createRandomUser(t)
+	admin := createRandomUser(t)
+
+	result, err := store.ReassignAccountOwnerTx(context.Background(), ReassignAccountOwnerTxParams{
+		AccountID: account.ID,
+		NewOwner:  newOwner.Username,
+		ChangedBy: admin.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, result.ID)
+	require.Equal(t, newOwner.Username, result.Owner)
+
+	var reason string
+	err = testDB.QueryRow(
+		"SELECT reason FROM audit_logs WHERE username = $1 AND from_account_id = $2",
+		admin.Username, account.ID,
+	).Scan(&reason)
+	require.NoError(t, err)
+	require.Equal(t, "owner changed from "+account.Owner+" to "+newOwner.Username, reason)
+
+	_, err = store.ReassignAccountOwnerTx(context.Background(), ReassignAccountOwnerTxParams{
+		AccountID: account.ID,
+		NewOwner:  util.RandomOwner(),
+		ChangedBy: admin.Username,
+	})
+	require.ErrorIs(t, err, ErrUserNotFound)
+
+	_, err = store.ReassignAccountOwnerTx(context.Background(), ReassignAccountOwnerTxParams{
+		AccountID: account.ID + 1000000,
+		NewOwner:  newOwner.Username,
+		ChangedBy: admin.Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestAdjustBalanceTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	admin := createRandomUser(t)
+
+	// a credit adds an adjustment entry and an audit record
+	result, err := store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID:  account.ID,
+		Amount:     25,
+		Reason:     "goodwill credit",
+		AdjustedBy: admin.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+25, result.Account.Balance)
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, int64(25), result.Entry.Amount)
+	require.Equal(t, EntryTypeAdjustment, result.Entry.Type)
+
+	var reason string
+	err = testDB.QueryRow(
+		"SELECT reason FROM audit_logs WHERE username = $1 AND from_account_id = $2 AND amount = 25",
+		admin.Username, account.ID,
+	).Scan(&reason)
+	require.NoError(t, err)
+	require.Equal(t, "balance adjusted: goodwill credit", reason)
+
+	// a debit past zero is rejected and leaves nothing behind
+	overdraw := -(result.Account.Balance + 1)
+	_, err = store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID:  account.ID,
+		Amount:     overdraw,
+		Reason:     "chargeback",
+		AdjustedBy: admin.Username,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	unchanged, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Account.Balance, unchanged.Balance)
+
+	entries, err := testQueries.ListEntry(context.Background(), ListEntryParams{
+		AccountID: account.ID,
+		Type:      sql.NullString{String: EntryTypeAdjustment, Valid: true},
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// unless it is allowed to go negative
+	result, err = store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID:     account.ID,
+		Amount:        overdraw,
+		Reason:        "chargeback",
+		AllowNegative: true,
+		AdjustedBy:    admin.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), result.Account.Balance)
+
+	_, err = store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID:  account.ID + 1000000,
+		Amount:     25,
+		Reason:     "goodwill credit",
+		AdjustedBy: admin.Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestExecuteScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundedAccount(t, 1000)
+	account2 := fundedAccount(t, 1000)
+	due := createRandomScheduledTransfer(t, account1, account2, time.Now().Add(-time.Minute))
+	later := createRandomScheduledTransfer(t, account1, account2, time.Now().Add(time.Hour))
+
+	// execute everything due, other tests may have left some behind
+	var executed ScheduledTransfer
+	for {
+		result, err := store.ExecuteScheduledTransferTx(context.Background())
+		if errors.Is(err, ErrRecordNotFound) {
+			break
+		}
+		require.NoError(t, err)
+		require.NotEqual(t, later.ID, result.ID)
+
+		if result.ID == due.ID {
+			executed = result
+		}
+	}
+
+	require.Equal(t, due.ID, executed.ID)
+	require.Equal(t, ScheduledTransferStatusExecuted, executed.Status)
+	require.True(t, executed.TransferID.Valid)
+	require.True(t, executed.ExecutedAt.Valid)
+
+	transfer, err := store.GetTransfer(context.Background(), executed.TransferID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, due.Amount, transfer.Amount)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-due.Amount, updatedAccount1.Balance)
+
+	// the one that is not due yet is left alone
+	pending, err := store.GetScheduledTransfer(context.Background(), later.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferStatusPending, pending.Status)
+}
+
+func TestExecuteScheduledTransferTxFails(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	due := createRandomScheduledTransfer(t, account1, account2, time.Now().Add(-time.Minute))
+
+	_, err := store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	var failed ScheduledTransfer
+	for {
+		result, err := store.ExecuteScheduledTransferTx(context.Background())
+		if errors.Is(err, ErrRecordNotFound) {
+			break
+		}
+		require.NoError(t, err)
+
+		if result.ID == due.ID {
+			failed = result
+		}
+	}
+
+	require.Equal(t, ScheduledTransferStatusFailed, failed.Status)
+	require.Equal(t, ErrAccountFrozen.Error(), failed.Error)
+	require.False(t, failed.TransferID.Valid)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestReconcileAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	result, err := store.OpenAccountTx(context.Background(), OpenAccountTxParams{
+		Owner:          createRandomUser(t).Username,
+		Currency:       util.RandomCurrency(),
+		InitialDeposit: util.RandomMoney(),
+	})
+	require.NoError(t, err)
+	account := result.Account
+
+	reconciliation, err := store.ReconcileAccountTx(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, reconciliation.AccountID)
+	require.Equal(t, account.Balance, reconciliation.Balance)
+	require.Equal(t, account.Balance, reconciliation.ExpectedBalance)
+	require.True(t, reconciliation.Balanced)
+
+	// changing the balance without an entry is exactly the drift to catch
+	drifted, err := store.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: 10,
+	})
+	require.NoError(t, err)
+
+	reconciliation, err = store.ReconcileAccountTx(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, drifted.Balance, reconciliation.Balance)
+	require.Equal(t, account.Balance, reconciliation.ExpectedBalance)
+	require.False(t, reconciliation.Balanced)
+
+	_, err = store.ReconcileAccountTx(context.Background(), -1)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestImportAccountsTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	accounts := []OpenAccountTxParams{
+		{Owner: user.Username, Currency: util.USD, InitialDeposit: util.RandomMoney()},
+		// nobody by this name exists
+		{Owner: util.RandomOwner(), Currency: util.USD, InitialDeposit: 10},
+		{Owner: user.Username, Currency: util.EUR},
+	}
+
+	results, err := store.ImportAccountsTx(context.Background(), ImportAccountsTxParams{Accounts: accounts})
+	require.NoError(t, err)
+	require.Len(t, results, len(accounts))
+
+	require.NoError(t, results[0].Err)
+	require.NotNil(t, results[0].Account)
+	require.Equal(t, accounts[0].InitialDeposit, results[0].Account.Balance)
+
+	require.ErrorIs(t, ErrorCode(results[1].Err), ErrForeignKeyViolation)
+	require.Nil(t, results[1].Account)
+
+	require.NoError(t, results[2].Err)
+	require.NotNil(t, results[2].Account)
+	require.Equal(t, util.EUR, results[2].Account.Currency)
+
+	// the accounts around the failed one were committed
+	for _, i := range []int{0, 2} {
+		account, err := store.GetAccount(context.Background(), results[i].Account.ID)
+		require.NoError(t, err)
+		require.Equal(t, user.Username, account.Owner)
+	}
+}
+
+func TestImportAccountsTxAtomic(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	accounts := []OpenAccountTxParams{
+		{Owner: user.Username, Currency: util.USD},
+		// the owner already holds a USD account by now
+		{Owner: user.Username, Currency: util.USD},
+	}
+
+	results, err := store.ImportAccountsTx(context.Background(), ImportAccountsTxParams{
+		Accounts: accounts,
+		Atomic:   true,
+	})
+	require.ErrorIs(t, err, ErrImportAborted)
+	require.Len(t, results, len(accounts))
+	require.NoError(t, results[0].Err)
+	require.ErrorIs(t, ErrorCode(results[1].Err), ErrUniqueViolation)
+	for _, result := range results {
+		require.Nil(t, result.Account)
+	}
+
+	_, err = store.GetAccountByOwnerCurrency(context.Background(), GetAccountByOwnerCurrencyParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	newUserParams := func() CreateUserParams {
+		hashedPassword, err := util.HashPassword(util.RandomString(6))
+		require.NoError(t, err)
+
+		return CreateUserParams{
+			Username:       util.RandomOwner(),
+			Role:           util.DepositorRole,
+			HashedPassword: hashedPassword,
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		}
+	}
+
+	t.Run("WithAccount", func(t *testing.T) {
+		arg := CreateUserTxParams{
+			CreateUserParams: newUserParams(),
+			DefaultCurrency:  util.USD,
+		}
+
+		result, err := store.CreateUserTx(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, arg.Username, result.User.Username)
+		require.NotNil(t, result.Account)
+		require.Equal(t, arg.Username, result.Account.Owner)
+		require.Equal(t, util.USD, result.Account.Currency)
+		require.Zero(t, result.Account.Balance)
+
+		account, err := store.GetAccount(context.Background(), result.Account.ID)
+		require.NoError(t, err)
+		require.Equal(t, *result.Account, account)
+	})
+
+	t.Run("WithVerificationToken", func(t *testing.T) {
+		arg := CreateUserTxParams{
+			CreateUserParams:      newUserParams(),
+			VerificationToken:     util.RandomString(32),
+			VerificationExpiresAt: time.Now().Add(time.Hour),
+		}
+
+		result, err := store.CreateUserTx(context.Background(), arg)
+		require.NoError(t, err)
+		require.False(t, result.User.IsEmailVerified)
+		require.NotNil(t, result.VerificationToken)
+		require.Equal(t, arg.Username, result.VerificationToken.Username)
+		require.Equal(t, arg.VerificationToken, result.VerificationToken.Token)
+	})
+
+	t.Run("WithoutAccount", func(t *testing.T) {
+		arg := CreateUserTxParams{CreateUserParams: newUserParams()}
+
+		result, err := store.CreateUserTx(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, arg.Username, result.User.Username)
+		require.Nil(t, result.Account)
+		require.Nil(t, result.VerificationToken)
+
+		accounts, err := store.ListAccounts(context.Background(), ListAccountsParams{
+			Owner: sql.NullString{String: arg.Username, Valid: true},
+			Limit: 5,
+		})
+		require.NoError(t, err)
+		require.Empty(t, accounts)
+	})
+
+	t.Run("UserFails", func(t *testing.T) {
+		existing := createRandomUser(t)
+
+		arg := CreateUserTxParams{
+			CreateUserParams: newUserParams(),
+			DefaultCurrency:  util.USD,
+		}
+		arg.Username = existing.Username
+
+		result, err := store.CreateUserTx(context.Background(), arg)
+		require.ErrorIs(t, ErrorCode(err), ErrUniqueViolation)
+		require.Empty(t, result)
+
+		// the account was never opened for the user who already existed
+		_, err = store.GetAccountByOwnerCurrency(context.Background(), GetAccountByOwnerCurrencyParams{
+			Owner:    existing.Username,
+			Currency: util.USD,
+		})
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	})
+}
+
+func TestChangePasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	newSession := func() Session {
+		session, err := testQueries.CreateSession(context.Background(), CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     user.Username,
+			RefreshToken: util.RandomString(32),
+			ClientIp:     "127.0.0.1",
+			ExpiresAt:    time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		return session
+	}
+	current := newSession()
+	other := newSession()
+	// another user's session is left alone
+	stranger := createRandomSession(t)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	changed, err := store.ChangePasswordTx(context.Background(), ChangePasswordTxParams{
+		Username:       user.Username,
+		HashedPassword: hashedPassword,
+		KeepSessionID:  current.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, changed.HashedPassword)
+	require.True(t, changed.PasswordChangedAt.After(user.PasswordChangedAt))
+
+	for _, tc := range []struct {
+		session Session
+		blocked bool
+	}{
+		{session: current, blocked: false},
+		{session: other, blocked: true},
+		{session: stranger, blocked: false},
+	} {
+		session, err := testQueries.GetSession(context.Background(), tc.session.ID)
+		require.NoError(t, err)
+		require.Equal(t, tc.blocked, session.IsBlocked)
+	}
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	t.Run("OK", func(t *testing.T) {
+		token := createRandomVerificationToken(t, time.Now().Add(time.Hour))
+
+		user, err := store.VerifyEmailTx(context.Background(), token.Token)
+		require.NoError(t, err)
+		require.Equal(t, token.Username, user.Username)
+		require.True(t, user.IsEmailVerified)
+
+		// the token is spent
+		_, err = store.VerifyEmailTx(context.Background(), token.Token)
+		require.ErrorIs(t, err, ErrVerificationTokenUsed)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		token := createRandomVerificationToken(t, time.Now().Add(-time.Minute))
+
+		_, err := store.VerifyEmailTx(context.Background(), token.Token)
+		require.ErrorIs(t, err, ErrVerificationExpired)
+
+		user, err := store.GetUser(context.Background(), token.Username)
+		require.NoError(t, err)
+		require.False(t, user.IsEmailVerified)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := store.VerifyEmailTx(context.Background(), util.RandomString(32))
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	})
+}
+
+func TestUpdateAccountStatusTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	admin := util.RandomOwner()
+
+	freeze := UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    AccountStatusFrozen,
+		Reason:    "suspected fraud",
+		ChangedBy: admin,
+	}
+	frozen, err := store.UpdateAccountStatusTx(context.Background(), freeze)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, frozen.Status)
+
+	// freezing a frozen account changes nothing and records nothing
+	_, err = store.UpdateAccountStatusTx(context.Background(), freeze)
+	require.NoError(t, err)
+
+	unfrozen, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    AccountStatusActive,
+		Reason:    "cleared by review",
+		ChangedBy: admin,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, unfrozen.Status)
+
+	history, err := store.ListAccountStatusHistory(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	require.Equal(t, AccountStatusActive, history[0].FromStatus)
+	require.Equal(t, AccountStatusFrozen, history[0].ToStatus)
+	require.Equal(t, "suspected fraud", history[0].Reason)
+	require.Equal(t, admin, history[0].ChangedBy)
+
+	require.Equal(t, AccountStatusFrozen, history[1].FromStatus)
+	require.Equal(t, AccountStatusActive, history[1].ToStatus)
+	require.Equal(t, "cleared by review", history[1].Reason)
+	require.Equal(t, admin, history[1].ChangedBy)
+	require.False(t, history[1].CreatedAt.Before(history[0].CreatedAt))
+}
+
+func TestUpdateAccountStatusTxClosed(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	_, err := store.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: -account.Balance,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID: account.ID,
+		ClosedBy:  account.Owner,
+	})
+	require.NoError(t, err)
+
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    AccountStatusFrozen,
+		Reason:    "too late",
+		ChangedBy: util.RandomOwner(),
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	// only the close made it into the history
+	history, err := store.ListAccountStatusHistory(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, AccountStatusClosed, history[0].ToStatus)
+	require.Equal(t, account.Owner, history[0].ChangedBy)
+}
@@ -5,27 +5,84 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/qwerqy/mock_bank/util"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  description,
+  reversal_of,
+  reference
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 )
-RETURNING id, from_account_id, to_account_id, amount, created_at
+ON CONFLICT (reference) DO NOTHING
+RETURNING id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64          `json:"from_account_id"`
+	ToAccountID   int64          `json:"to_account_id"`
+	Amount        int64          `json:"amount"`
+	Description   string         `json:"description"`
+	ReversalOf    util.NullInt64 `json:"reversal_of"`
+	Reference     string         `json:"reference"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Description,
+		arg.ReversalOf,
+		arg.Reference,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.Description,
+		&i.ReversalOf,
+		&i.Reference,
+	)
+	return i, err
+}
+
+const findRecentIdenticalTransfer = `-- name: FindRecentIdenticalTransfer :one
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference FROM transfers
+WHERE
+  from_account_id = $1 AND
+  to_account_id = $2 AND
+  amount = $3 AND
+  created_at >= $4
+ORDER BY created_at DESC, id DESC
+LIMIT 1
+`
+
+type FindRecentIdenticalTransferParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Since         time.Time `json:"since"`
+}
+
+func (q *Queries) FindRecentIdenticalTransfer(ctx context.Context, arg FindRecentIdenticalTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, findRecentIdenticalTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Since,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -33,12 +90,15 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.ReversalOf,
+		&i.Reference,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -51,12 +111,133 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.ReversalOf,
+		&i.Reference,
+	)
+	return i, err
+}
+
+const getTransferByReference = `-- name: GetTransferByReference :one
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference FROM transfers
+WHERE reference = $1 LIMIT 1
+`
+
+func (q *Queries) GetTransferByReference(ctx context.Context, reference string) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferByReference, reference)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.Description,
+		&i.ReversalOf,
+		&i.Reference,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.Description,
+		&i.ReversalOf,
+		&i.Reference,
+	)
+	return i, err
+}
+
+const getTransferReversal = `-- name: GetTransferReversal :one
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference FROM transfers
+WHERE reversal_of = $1 LIMIT 1
+`
+
+func (q *Queries) GetTransferReversal(ctx context.Context, reversalOf util.NullInt64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferReversal, reversalOf)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.Description,
+		&i.ReversalOf,
+		&i.Reference,
 	)
 	return i, err
 }
 
+const listLargestTransfers = `-- name: ListLargestTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference FROM transfers
+WHERE
+  (from_account_id = $1 OR to_account_id = $1) AND
+  created_at >= $2 AND
+  created_at < $3
+ORDER BY amount DESC, id
+LIMIT $4
+`
+
+type ListLargestTransfersParams struct {
+	AccountID int64     `json:"account_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	RowLimit  int32     `json:"row_limit"`
+}
+
+func (q *Queries) ListLargestTransfers(ctx context.Context, arg ListLargestTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listLargestTransfers,
+		arg.AccountID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.ReversalOf,
+			&i.Reference,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfer = `-- name: ListTransfer :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference FROM transfers
 WHERE
   from_account_id = $1 OR
   to_account_id = $2
@@ -92,6 +273,9 @@ func (q *Queries) ListTransfer(ctx context.Context, arg ListTransferParams) ([]T
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Description,
+			&i.ReversalOf,
+			&i.Reference,
 		); err != nil {
 			return nil, err
 		}
@@ -105,3 +289,160 @@ func (q *Queries) ListTransfer(ctx context.Context, arg ListTransferParams) ([]T
 	}
 	return items, nil
 }
+
+const listTransfersForAccounts = `-- name: ListTransfersForAccounts :many
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference FROM transfers
+WHERE
+  from_account_id = ANY($1::bigint[]) OR
+  to_account_id = ANY($1::bigint[])
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListTransfersForAccountsParams struct {
+	AccountIds []int64 `json:"account_ids"`
+	Limit      int32   `json:"limit"`
+	Offset     int32   `json:"offset"`
+}
+
+func (q *Queries) ListTransfersForAccounts(ctx context.Context, arg ListTransfersForAccountsParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersForAccounts, pq.Array(arg.AccountIds), arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.ReversalOf,
+			&i.Reference,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersFrom = `-- name: ListTransfersFrom :many
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference FROM transfers
+WHERE from_account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListTransfersFromParams struct {
+	FromAccountID int64 `json:"from_account_id"`
+	Limit         int32 `json:"limit"`
+	Offset        int32 `json:"offset"`
+}
+
+func (q *Queries) ListTransfersFrom(ctx context.Context, arg ListTransfersFromParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersFrom, arg.FromAccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.ReversalOf,
+			&i.Reference,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersTo = `-- name: ListTransfersTo :many
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reversal_of, reference FROM transfers
+WHERE to_account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListTransfersToParams struct {
+	ToAccountID int64 `json:"to_account_id"`
+	Limit       int32 `json:"limit"`
+	Offset      int32 `json:"offset"`
+}
+
+func (q *Queries) ListTransfersTo(ctx context.Context, arg ListTransfersToParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersTo, arg.ToAccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.ReversalOf,
+			&i.Reference,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sumOutboundTransfersSince = `-- name: SumOutboundTransfersSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM transfers
+WHERE from_account_id = $1 AND created_at >= $2
+`
+
+type SumOutboundTransfersSinceParams struct {
+	AccountID int64     `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+func (q *Queries) SumOutboundTransfersSince(ctx context.Context, arg SumOutboundTransfersSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumOutboundTransfersSince, arg.AccountID, arg.Since)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
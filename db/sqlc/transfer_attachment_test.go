@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTransferAttachment(t *testing.T, transfer Transfer) TransferAttachment {
+	arg := CreateTransferAttachmentParams{
+		TransferID:  transfer.ID,
+		Filename:    util.RandomString(8) + ".pdf",
+		ContentType: "application/pdf",
+		Data:        []byte(util.RandomString(64)),
+	}
+
+	attachment, err := testQueries.CreateTransferAttachment(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, attachment)
+
+	require.Equal(t, arg.TransferID, attachment.TransferID)
+	require.Equal(t, arg.Filename, attachment.Filename)
+	require.Equal(t, arg.ContentType, attachment.ContentType)
+	require.Equal(t, arg.Data, attachment.Data)
+
+	require.NotZero(t, attachment.ID)
+	require.NotZero(t, attachment.CreatedAt)
+
+	return attachment
+}
+
+func TestCreateTransferAttachment(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	transfer := createRandomTransfer(t, account1.ID, account2.ID)
+	createRandomTransferAttachment(t, transfer)
+}
+
+func TestGetTransferAttachment(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	transfer := createRandomTransfer(t, account1.ID, account2.ID)
+	attachment1 := createRandomTransferAttachment(t, transfer)
+
+	attachment2, err := testQueries.GetTransferAttachment(context.Background(), GetTransferAttachmentParams{
+		ID:         attachment1.ID,
+		TransferID: transfer.ID,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, attachment2)
+
+	require.Equal(t, attachment1.ID, attachment2.ID)
+	require.Equal(t, attachment1.Filename, attachment2.Filename)
+	require.Equal(t, attachment1.Data, attachment2.Data)
+	require.WithinDuration(t, attachment1.CreatedAt, attachment2.CreatedAt, time.Second)
+
+	// an attachment is only reachable through the transfer it belongs to
+	otherTransfer := createRandomTransfer(t, account1.ID, account2.ID)
+	_, err = testQueries.GetTransferAttachment(context.Background(), GetTransferAttachmentParams{
+		ID:         attachment1.ID,
+		TransferID: otherTransfer.ID,
+	})
+	require.Error(t, err)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
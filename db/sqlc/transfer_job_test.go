@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTransferJob(t *testing.T, fromAccountID int64, toAccountID int64) TransferJob {
+	arg := CreateTransferJobParams{
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        util.RandomInt(1, 1000),
+	}
+
+	job, err := testQueries.CreateTransferJob(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, job)
+
+	require.Equal(t, arg.FromAccountID, job.FromAccountID)
+	require.Equal(t, arg.ToAccountID, job.ToAccountID)
+	require.Equal(t, arg.Amount, job.Amount)
+	require.Equal(t, TransferJobStatusPending, job.Status)
+	require.False(t, job.TransferID.Valid)
+	require.False(t, job.ProcessedAt.Valid)
+
+	require.NotZero(t, job.ID)
+	require.NotZero(t, job.CreatedAt)
+
+	return job
+}
+
+func TestCreateTransferJob(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	createRandomTransferJob(t, account1.ID, account2.ID)
+}
+
+func TestGetTransferJob(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	job1 := createRandomTransferJob(t, account1.ID, account2.ID)
+
+	job2, err := testQueries.GetTransferJob(context.Background(), job1.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, job2)
+
+	require.Equal(t, job1.ID, job2.ID)
+	require.Equal(t, job1.FromAccountID, job2.FromAccountID)
+	require.Equal(t, job1.ToAccountID, job2.ToAccountID)
+	require.Equal(t, job1.Amount, job2.Amount)
+	require.Equal(t, job1.Status, job2.Status)
+	require.WithinDuration(t, job1.CreatedAt, job2.CreatedAt, time.Second)
+}
+
+func TestUpdateTransferJobStatus(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	job1 := createRandomTransferJob(t, account1.ID, account2.ID)
+
+	arg := UpdateTransferJobStatusParams{
+		ID:     job1.ID,
+		Status: TransferJobStatusFailed,
+		Error:  "insufficient funds",
+	}
+
+	job2, err := testQueries.UpdateTransferJobStatus(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, TransferJobStatusFailed, job2.Status)
+	require.Equal(t, arg.Error, job2.Error)
+	require.True(t, job2.ProcessedAt.Valid)
+
+	// a job that already left the pending state is never updated again
+	_, err = testQueries.UpdateTransferJobStatus(context.Background(), arg)
+	require.Error(t, err)
+}
+
+func createFailedTransferJob(t *testing.T, reason string) TransferJob {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	job := createRandomTransferJob(t, account1.ID, account2.ID)
+
+	job, err := testQueries.UpdateTransferJobStatus(context.Background(), UpdateTransferJobStatusParams{
+		ID:     job.ID,
+		Status: TransferJobStatusFailed,
+		Error:  reason,
+	})
+	require.NoError(t, err)
+	return job
+}
+
+func TestListFailedTransferJobs(t *testing.T) {
+	failed := createFailedTransferJob(t, "insufficient funds")
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	pending := createRandomTransferJob(t, account1.ID, account2.ID)
+
+	jobs, err := testQueries.ListFailedTransferJobs(context.Background(), ListFailedTransferJobsParams{
+		Limit:  100,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, jobs)
+
+	var found bool
+	for i, job := range jobs {
+		require.Equal(t, TransferJobStatusFailed, job.Status)
+		require.NotEqual(t, pending.ID, job.ID)
+		if i > 0 {
+			require.Less(t, job.ID, jobs[i-1].ID)
+		}
+		if job.ID == failed.ID {
+			found = true
+			require.Equal(t, "insufficient funds", job.Error)
+		}
+	}
+	require.True(t, found)
+}
+
+func TestRequeueTransferJob(t *testing.T) {
+	failed := createFailedTransferJob(t, "insufficient funds")
+
+	job, err := testQueries.RequeueTransferJob(context.Background(), failed.ID)
+	require.NoError(t, err)
+	require.Equal(t, failed.ID, job.ID)
+	require.Equal(t, TransferJobStatusPending, job.Status)
+	require.Empty(t, job.Error)
+	require.False(t, job.ProcessedAt.Valid)
+
+	// only a failed job can be requeued
+	_, err = testQueries.RequeueTransferJob(context.Background(), failed.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
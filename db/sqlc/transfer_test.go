@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -14,8 +15,13 @@ func createRandomTransfer(t *testing.T, fromAccountId int64, toAccountId int64)
 		FromAccountID: fromAccountId,
 		ToAccountID:   toAccountId,
 		Amount:        util.RandomMoney(),
+		Description:   util.RandomString(12),
 	}
 
+	reference, err := newTransferReference(time.Now())
+	require.NoError(t, err)
+	arg.Reference = reference
+
 	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, transfer)
@@ -23,6 +29,8 @@ func createRandomTransfer(t *testing.T, fromAccountId int64, toAccountId int64)
 	require.Equal(t, transfer.FromAccountID, arg.FromAccountID)
 	require.Equal(t, transfer.ToAccountID, arg.ToAccountID)
 	require.Equal(t, transfer.Amount, arg.Amount)
+	require.Equal(t, transfer.Description, arg.Description)
+	require.Equal(t, transfer.Reference, arg.Reference)
 
 	return transfer
 }
@@ -49,6 +57,65 @@ func TestGetTransfer(t *testing.T) {
 	require.WithinDuration(t, transfer2.CreatedAt, transfer1.CreatedAt, time.Second)
 }
 
+func TestGetTransferByReference(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	transfer1 := createRandomTransfer(t, account1.ID, account2.ID)
+
+	transfer2, err := testQueries.GetTransferByReference(context.Background(), transfer1.Reference)
+	require.NoError(t, err)
+	require.Equal(t, transfer1.ID, transfer2.ID)
+	require.Equal(t, transfer1.Reference, transfer2.Reference)
+
+	_, err = testQueries.GetTransferByReference(context.Background(), "TRX-19700101-NOTHER")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateTransferReferenceTaken(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	transfer := createRandomTransfer(t, account1.ID, account2.ID)
+
+	// a taken reference inserts nothing instead of failing the transaction
+	_, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Reference:     transfer.Reference,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestFindRecentIdenticalTransfer(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	createRandomTransfer(t, account1.ID, account2.ID)
+	transfer := createRandomTransfer(t, account1.ID, account2.ID)
+
+	arg := FindRecentIdenticalTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        transfer.Amount,
+		Since:         transfer.CreatedAt.Add(-time.Minute),
+	}
+
+	found, err := testQueries.FindRecentIdenticalTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, transfer.ID, found.ID)
+	require.Equal(t, transfer.Reference, found.Reference)
+
+	// the transfer is older than the window
+	arg.Since = transfer.CreatedAt.Add(time.Minute)
+	_, err = testQueries.FindRecentIdenticalTransfer(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the same amount the other way round is a different transfer
+	arg.Since = transfer.CreatedAt.Add(-time.Minute)
+	arg.FromAccountID, arg.ToAccountID = account2.ID, account1.ID
+	_, err = testQueries.FindRecentIdenticalTransfer(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 func TestListTransfer(t *testing.T) {
 	account1 := createRandomAccount(t)
 	account2 := createRandomAccount(t)
@@ -72,3 +139,132 @@ func TestListTransfer(t *testing.T) {
 		require.NotEmpty(t, transfer)
 	}
 }
+
+func TestListTransfersForAccounts(t *testing.T) {
+	user := createRandomUser(t)
+
+	var own []Account
+	for _, currency := range []string{util.USD, util.EUR} {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Currency: currency,
+		})
+		require.NoError(t, err)
+		own = append(own, account)
+	}
+	stranger := createRandomAccount(t)
+
+	// out of one account, into the other, and between the two
+	want := []Transfer{
+		createRandomTransfer(t, own[0].ID, stranger.ID),
+		createRandomTransfer(t, stranger.ID, own[1].ID),
+		createRandomTransfer(t, own[0].ID, own[1].ID),
+	}
+	// none of the user's accounts are involved
+	createRandomTransfer(t, stranger.ID, createRandomAccount(t).ID)
+
+	arg := ListTransfersForAccountsParams{
+		AccountIds: []int64{own[0].ID, own[1].ID},
+		Limit:      10,
+		Offset:     0,
+	}
+
+	transfers, err := testQueries.ListTransfersForAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, len(want))
+	for i, transfer := range transfers {
+		// newest first
+		require.Equal(t, want[len(want)-1-i].ID, transfer.ID)
+	}
+
+	arg.Limit = 2
+	arg.Offset = 2
+	transfers, err = testQueries.ListTransfersForAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, want[0].ID, transfers[0].ID)
+}
+
+func TestListTransfersFrom(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	for i := 0; i < 3; i++ {
+		createRandomTransfer(t, account1.ID, account2.ID)
+		createRandomTransfer(t, account2.ID, account1.ID)
+	}
+
+	arg := ListTransfersFromParams{
+		FromAccountID: account1.ID,
+		Limit:         5,
+		Offset:        0,
+	}
+
+	transfers, err := testQueries.ListTransfersFrom(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 3)
+
+	for _, transfer := range transfers {
+		require.Equal(t, account1.ID, transfer.FromAccountID)
+	}
+}
+
+func TestListTransfersTo(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	for i := 0; i < 3; i++ {
+		createRandomTransfer(t, account1.ID, account2.ID)
+		createRandomTransfer(t, account2.ID, account1.ID)
+	}
+
+	arg := ListTransfersToParams{
+		ToAccountID: account1.ID,
+		Limit:       5,
+		Offset:      0,
+	}
+
+	transfers, err := testQueries.ListTransfersTo(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 3)
+
+	for _, transfer := range transfers {
+		require.Equal(t, account1.ID, transfer.ToAccountID)
+	}
+}
+
+func TestListLargestTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	for i := 0; i < 5; i++ {
+		createRandomTransfer(t, account1.ID, account2.ID)
+		createRandomTransfer(t, account2.ID, account1.ID)
+	}
+
+	arg := ListLargestTransfersParams{
+		AccountID: account1.ID,
+		StartTime: time.Now().Add(-time.Minute),
+		EndTime:   time.Now().Add(time.Minute),
+		RowLimit:  4,
+	}
+
+	transfers, err := testQueries.ListLargestTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 4)
+
+	for i, transfer := range transfers {
+		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
+		if i > 0 {
+			require.GreaterOrEqual(t, transfers[i-1].Amount, transfer.Amount)
+		}
+	}
+
+	// nothing falls inside a window that ended before the transfers were made
+	arg.EndTime = arg.StartTime
+	arg.StartTime = arg.StartTime.Add(-time.Hour)
+
+	transfers, err = testQueries.ListLargestTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomVerificationToken(t *testing.T, expiresAt time.Time) VerificationToken {
+	user := createRandomUser(t)
+
+	arg := CreateVerificationTokenParams{
+		Username:  user.Username,
+		Token:     util.RandomString(32),
+		ExpiresAt: expiresAt,
+	}
+
+	token, err := testQueries.CreateVerificationToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, token.ID)
+	require.Equal(t, arg.Username, token.Username)
+	require.Equal(t, arg.Token, token.Token)
+	require.False(t, token.IsUsed)
+	require.WithinDuration(t, arg.ExpiresAt, token.ExpiresAt, time.Second)
+	require.NotZero(t, token.CreatedAt)
+
+	return token
+}
+
+func TestCreateVerificationToken(t *testing.T) {
+	createRandomVerificationToken(t, time.Now().Add(time.Hour))
+}
+
+func TestMarkVerificationTokenUsed(t *testing.T) {
+	token1 := createRandomVerificationToken(t, time.Now().Add(time.Hour))
+
+	token2, err := testQueries.MarkVerificationTokenUsed(context.Background(), token1.ID)
+	require.NoError(t, err)
+	require.True(t, token2.IsUsed)
+
+	token3, err := testQueries.GetVerificationTokenForUpdate(context.Background(), token1.Token)
+	require.NoError(t, err)
+	require.Equal(t, token2, token3)
+}
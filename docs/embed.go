@@ -0,0 +1,8 @@
+// Package docs holds the OpenAPI spec of the HTTP API. swagger.json is
+// generated from the handler annotations with `make swagger`.
+package docs
+
+import _ "embed"
+
+//go:embed swagger.json
+var SwaggerJSON []byte
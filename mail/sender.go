@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Email is a plain-text message to a single recipient.
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers email. Delivery may wait on the network, so request
+// handlers are given a Sender that only queues the email for a background
+// worker.
+type Sender interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// NoopSender drops every email.
+type NoopSender struct{}
+
+func (NoopSender) Send(ctx context.Context, email Email) error {
+	return nil
+}
+
+// ConsoleSender writes each email to out instead of delivering it, for
+// development setups without a mail server.
+type ConsoleSender struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func NewConsoleSender(out io.Writer) *ConsoleSender {
+	return &ConsoleSender{out: out}
+}
+
+func (sender *ConsoleSender) Send(ctx context.Context, email Email) error {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	_, err := fmt.Fprintf(sender.out, "To: %s\nSubject: %s\n\n%s\n\n", email.To, email.Subject, email.Body)
+	return err
+}
@@ -0,0 +1,22 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleSender(t *testing.T) {
+	var out bytes.Buffer
+	sender := NewConsoleSender(&out)
+
+	err := sender.Send(context.Background(), Email{
+		To:      "alice@example.com",
+		Subject: "Hello",
+		Body:    "Welcome aboard.",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "To: alice@example.com\nSubject: Hello\n\nWelcome aboard.\n\n", out.String())
+}
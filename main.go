@@ -1,27 +1,68 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"log"
+	"os"
 
 	_ "github.com/lib/pq"
 	"github.com/qwerqy/mock_bank/api"
 	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/qwerqy/mock_bank/mail"
 	"github.com/qwerqy/mock_bank/util"
+	"github.com/qwerqy/mock_bank/worker"
 )
 
+// mailQueueSize is how many emails may wait for the mail worker before
+// sending more fails.
+const mailQueueSize = 100
+
+// @title                      Mock Bank API
+// @version                    1.0
+// @description                Banking API for accounts and the transfers between them.
+// @host                       localhost:8080
+// @BasePath                   /
+// @securityDefinitions.apikey BearerAuth
+// @in                         header
+// @name                       Authorization
+// @description                Access token from POST /users/login, sent as "bearer <token>".
 func main() {
 	config, err := util.LoadConfig(".")
 	if err != nil {
 		log.Fatal("cannot load config:", err)
 	}
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
+
+	if config.AutoMigrate {
+		err = db.RunMigrations(config.DBSource)
+		if err != nil {
+			log.Fatal("cannot run migrations:", err)
+		}
+	}
+
+	_, store, err := db.Connect(config)
 	if err != nil {
 		log.Fatal("cannot connect to db:", err)
 	}
 
-	store := db.NewStore(conn)
-	server := api.NewServer(store)
+	// like the hold cleanup, a zero interval turns the worker off
+	if config.TransferWorkerInterval > 0 {
+		transferWorker := worker.NewTransferWorker(store, config.TransferWorkerInterval)
+		go transferWorker.Start(context.Background())
+	}
+
+	if config.HoldCleanupInterval > 0 {
+		holdWorker := worker.NewHoldWorker(store, config.HoldCleanupInterval)
+		go holdWorker.Start(context.Background())
+	}
+
+	// there is no mail server yet, emails are printed instead
+	mailWorker := worker.NewMailWorker(mail.NewConsoleSender(os.Stdout), mailQueueSize)
+	go mailWorker.Start(context.Background())
+
+	server, err := api.NewServer(config, store, mailWorker)
+	if err != nil {
+		log.Fatal("cannot create server:", err)
+	}
 
 	err = server.Start(config.ServerAddress)
 	if err != nil {
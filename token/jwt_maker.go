@@ -0,0 +1,100 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const minSecretKeySize = 32
+
+// JWTMaker is a JSON Web Token maker signing with HMAC-SHA256
+type JWTMaker struct {
+	keys   keySet
+	parser *jwt.Parser
+}
+
+// NewJWTMaker creates a new JWTMaker
+func NewJWTMaker(secretKey string) (Maker, error) {
+	return NewJWTMakerWithKeys(Key{Secret: secretKey}, nil)
+}
+
+// NewJWTMakerWithKeys creates a JWTMaker that signs tokens with primary and
+// accepts tokens signed with primary or any of the verification keys.
+func NewJWTMakerWithKeys(primary Key, verification []Key) (Maker, error) {
+	keys, err := newKeySet(primary, verification, validJWTKey)
+	if err != nil {
+		return nil, err
+	}
+
+	maker := &JWTMaker{
+		keys: keys,
+		// only accepting the one algorithm tokens are signed with keeps a
+		// token from choosing "none" or a different algorithm for itself
+		parser: jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})),
+	}
+	return maker, nil
+}
+
+func validJWTKey(secretKey string) error {
+	if len(secretKey) < minSecretKeySize {
+		return fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+	}
+	return nil
+}
+
+// CreateToken creates a new token for a specific username, role, session, type and duration
+func (maker *JWTMaker) CreateToken(username string, role string, sessionID uuid.UUID, tokenType string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, sessionID, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	if maker.keys.primary.ID != "" {
+		jwtToken.Header["kid"] = maker.keys.primary.ID
+	}
+
+	token, err := jwtToken.SignedString([]byte(maker.keys.primary.Secret))
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
+	// the header is only trusted to pick the key: the signature it is then
+	// checked with covers it
+	unverified, _, err := maker.parser.ParseUnverified(token, &Payload{})
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	keyID, _ := unverified.Header["kid"].(string)
+
+	for _, key := range maker.keys.candidates(keyID) {
+		keyFunc := func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, ErrInvalidToken
+			}
+			return []byte(key.Secret), nil
+		}
+
+		jwtToken, err := maker.parser.ParseWithClaims(token, &Payload{}, keyFunc)
+		if err != nil {
+			if errors.Is(err, ErrExpiredToken) {
+				return nil, ErrExpiredToken
+			}
+			continue
+		}
+
+		payload, ok := jwtToken.Claims.(*Payload)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		return payload, nil
+	}
+
+	return nil, ErrInvalidToken
+}
@@ -0,0 +1,71 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Key is a symmetric key and the ID that tokens signed with it carry, so a
+// verifier knows which key to check them against. Keys without an ID sign
+// tokens that name no key.
+type Key struct {
+	ID     string
+	Secret string
+}
+
+// ParseKey reads a key written as ID:SECRET.
+func ParseKey(s string) (Key, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return Key{}, errors.New("token key must be written as ID:SECRET")
+	}
+	return Key{ID: parts[0], Secret: parts[1]}, nil
+}
+
+// keySet holds the primary key new tokens are signed with and the keys that
+// tokens are still accepted from. Rotating keys means making a new primary
+// and keeping the old one for verification until its tokens have expired.
+type keySet struct {
+	primary Key
+	// keys are tried in order, the primary first
+	keys []Key
+}
+
+// newKeySet checks every key with validSecret and that no two keys share an
+// ID.
+func newKeySet(primary Key, verification []Key, validSecret func(secret string) error) (keySet, error) {
+	keys := append([]Key{primary}, verification...)
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if err := validSecret(key.Secret); err != nil {
+			return keySet{}, err
+		}
+		if key.ID == "" {
+			continue
+		}
+		if seen[key.ID] {
+			return keySet{}, fmt.Errorf("token key ID %q is used twice", key.ID)
+		}
+		seen[key.ID] = true
+	}
+
+	return keySet{primary: primary, keys: keys}, nil
+}
+
+// candidates returns the keys a token naming keyID may have been signed with:
+// the key with that ID, or every key when the token names none. A token
+// naming a key that has been removed gets no candidates.
+func (set keySet) candidates(keyID string) []Key {
+	if keyID == "" {
+		return set.keys
+	}
+
+	for _, key := range set.keys {
+		if key.ID == keyID {
+			return []Key{key}
+		}
+	}
+	return nil
+}
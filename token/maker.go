@@ -0,0 +1,42 @@
+package token
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Maker is an interface for managing tokens
+type Maker interface {
+	// CreateToken creates a new token for a specific username, role, session, type and duration
+	CreateToken(username string, role string, sessionID uuid.UUID, tokenType string, duration time.Duration) (string, *Payload, error)
+
+	// VerifyToken checks if the token is valid or not
+	VerifyToken(token string) (*Payload, error)
+}
+
+const (
+	// PasetoType signs tokens as PASETO v2 local tokens
+	PasetoType = "paseto"
+	// JWTType signs tokens as HS256 JSON Web Tokens
+	JWTType = "jwt"
+)
+
+// NewMaker creates the Maker for a token type. An empty type means PASETO.
+func NewMaker(tokenType string, key string) (Maker, error) {
+	return NewMakerWithKeys(tokenType, Key{Secret: key}, nil)
+}
+
+// NewMakerWithKeys creates the Maker for a token type that signs with primary
+// and still accepts tokens signed with any of the verification keys.
+func NewMakerWithKeys(tokenType string, primary Key, verification []Key) (Maker, error) {
+	switch tokenType {
+	case PasetoType, "":
+		return NewPasetoMakerWithKeys(primary, verification)
+	case JWTType:
+		return NewJWTMakerWithKeys(primary, verification)
+	default:
+		return nil, fmt.Errorf("unsupported token type %q: must be %q or %q", tokenType, PasetoType, JWTType)
+	}
+}
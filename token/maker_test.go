@@ -0,0 +1,166 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/qwerqy/mock_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMaker(t *testing.T) {
+	key := util.RandomString(32)
+
+	testCases := []struct {
+		name      string
+		tokenType string
+		check     func(t *testing.T, maker Maker, err error)
+	}{
+		{
+			name:      "Default",
+			tokenType: "",
+			check: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoMaker{}, maker)
+			},
+		},
+		{
+			name:      "Paseto",
+			tokenType: PasetoType,
+			check: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoMaker{}, maker)
+			},
+		},
+		{
+			name:      "JWT",
+			tokenType: JWTType,
+			check: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &JWTMaker{}, maker)
+			},
+		},
+		{
+			name:      "Unsupported",
+			tokenType: "saml",
+			check: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewMaker(tc.tokenType, key)
+			tc.check(t, maker, err)
+		})
+	}
+}
+
+func TestMakersRejectEachOthersTokens(t *testing.T) {
+	key := util.RandomString(32)
+
+	pasetoMaker, err := NewPasetoMaker(key)
+	require.NoError(t, err)
+	jwtMaker, err := NewJWTMaker(key)
+	require.NoError(t, err)
+
+	token, _, err := pasetoMaker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), AccessToken, time.Minute)
+	require.NoError(t, err)
+	_, err = jwtMaker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	token, _, err = jwtMaker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), AccessToken, time.Minute)
+	require.NoError(t, err)
+	_, err = pasetoMaker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestKeyRotation(t *testing.T) {
+	for _, tokenType := range []string{PasetoType, JWTType} {
+		tokenType := tokenType
+
+		t.Run(tokenType, func(t *testing.T) {
+			oldKey := Key{ID: "2021-09", Secret: util.RandomString(32)}
+			newKey := Key{ID: "2021-10", Secret: util.RandomString(32)}
+
+			oldMaker, err := NewMakerWithKeys(tokenType, oldKey, nil)
+			require.NoError(t, err)
+			oldToken, _, err := oldMaker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), AccessToken, time.Minute)
+			require.NoError(t, err)
+
+			// mid rotation: new tokens use the new key, old ones still verify
+			rotatedMaker, err := NewMakerWithKeys(tokenType, newKey, []Key{oldKey})
+			require.NoError(t, err)
+
+			payload, err := rotatedMaker.VerifyToken(oldToken)
+			require.NoError(t, err)
+			require.NotEmpty(t, payload)
+
+			newToken, _, err := rotatedMaker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), AccessToken, time.Minute)
+			require.NoError(t, err)
+			_, err = rotatedMaker.VerifyToken(newToken)
+			require.NoError(t, err)
+
+			// the old key can't verify tokens signed with the new one
+			_, err = oldMaker.VerifyToken(newToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+
+			// once the old key is removed its tokens are rejected
+			newMaker, err := NewMakerWithKeys(tokenType, newKey, nil)
+			require.NoError(t, err)
+
+			_, err = newMaker.VerifyToken(oldToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+			_, err = newMaker.VerifyToken(newToken)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestKeyRotationUnnamedKey(t *testing.T) {
+	for _, tokenType := range []string{PasetoType, JWTType} {
+		tokenType := tokenType
+
+		t.Run(tokenType, func(t *testing.T) {
+			secret := util.RandomString(32)
+
+			// tokens from before keys had IDs name no key
+			legacyMaker, err := NewMaker(tokenType, secret)
+			require.NoError(t, err)
+			token, _, err := legacyMaker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), AccessToken, time.Minute)
+			require.NoError(t, err)
+
+			maker, err := NewMakerWithKeys(tokenType, Key{ID: "2021-10", Secret: util.RandomString(32)}, []Key{{ID: "legacy", Secret: secret}})
+			require.NoError(t, err)
+
+			_, err = maker.VerifyToken(token)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewMakerWithKeysInvalid(t *testing.T) {
+	primary := Key{ID: "a", Secret: util.RandomString(32)}
+
+	_, err := NewMakerWithKeys(PasetoType, primary, []Key{{ID: "a", Secret: util.RandomString(32)}})
+	require.Error(t, err)
+
+	_, err = NewMakerWithKeys(JWTType, primary, []Key{{ID: "b", Secret: "short"}})
+	require.Error(t, err)
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := ParseKey("2021-10:secret:with:colons")
+	require.NoError(t, err)
+	require.Equal(t, Key{ID: "2021-10", Secret: "secret:with:colons"}, key)
+
+	for _, s := range []string{"", "no-separator", ":secret"} {
+		_, err := ParseKey(s)
+		require.Error(t, err, s)
+	}
+}
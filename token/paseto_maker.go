@@ -0,0 +1,91 @@
+package token
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aead/chacha20poly1305"
+	"github.com/google/uuid"
+	"github.com/o1egl/paseto"
+)
+
+// PasetoMaker is a PASETO token maker
+type PasetoMaker struct {
+	paseto *paseto.V2
+	keys   keySet
+}
+
+// pasetoFooter is the unencrypted but authenticated footer of a token. It
+// names the key the token was encrypted with.
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// NewPasetoMaker creates a new PasetoMaker
+func NewPasetoMaker(symmetricKey string) (Maker, error) {
+	return NewPasetoMakerWithKeys(Key{Secret: symmetricKey}, nil)
+}
+
+// NewPasetoMakerWithKeys creates a PasetoMaker that encrypts tokens with
+// primary and decrypts them with primary or any of the verification keys.
+func NewPasetoMakerWithKeys(primary Key, verification []Key) (Maker, error) {
+	keys, err := newKeySet(primary, verification, validPasetoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	maker := &PasetoMaker{
+		paseto: paseto.NewV2(),
+		keys:   keys,
+	}
+	return maker, nil
+}
+
+func validPasetoKey(symmetricKey string) error {
+	if len(symmetricKey) != chacha20poly1305.KeySize {
+		return fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+	}
+	return nil
+}
+
+// CreateToken creates a new token for a specific username, role, session, type and duration
+func (maker *PasetoMaker) CreateToken(username string, role string, sessionID uuid.UUID, tokenType string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, sessionID, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	var footer interface{}
+	if maker.keys.primary.ID != "" {
+		footer = pasetoFooter{KeyID: maker.keys.primary.ID}
+	}
+
+	token, err := maker.paseto.Encrypt([]byte(maker.keys.primary.Secret), payload, footer)
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
+	var footer pasetoFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	for _, key := range maker.keys.candidates(footer.KeyID) {
+		payload := &Payload{}
+
+		err := maker.paseto.Decrypt(token, []byte(key.Secret), payload, nil)
+		if err != nil {
+			continue
+		}
+
+		err = payload.Valid()
+		if err != nil {
+			return nil, err
+		}
+
+		return payload, nil
+	}
+
+	return nil, ErrInvalidToken
+}
@@ -1,11 +1,125 @@
 package util
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type Config struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBSource      string `mapstructure:"DB_SOURCE"`
-	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	DBDriver               string        `mapstructure:"DB_DRIVER"`
+	DBSource               string        `mapstructure:"DB_SOURCE"`
+	AutoMigrate            bool          `mapstructure:"AUTO_MIGRATE"`
+	MaxOpenConns           int           `mapstructure:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns           int           `mapstructure:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime        time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME"`
+	TxMaxAttempts          int           `mapstructure:"TX_MAX_ATTEMPTS"`
+	DailyTransferLimit     int64         `mapstructure:"DAILY_TRANSFER_LIMIT"`
+	DBTimeout              time.Duration `mapstructure:"DB_TIMEOUT"`
+	SlowQueryThreshold     time.Duration `mapstructure:"SLOW_QUERY_THRESHOLD"`
+	ServerAddress          string        `mapstructure:"SERVER_ADDRESS"`
+	MaxRequestBodyBytes    int64         `mapstructure:"MAX_REQUEST_BODY_BYTES"`
+	TokenSymmetricKey      string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenType              string        `mapstructure:"TOKEN_TYPE"`
+	AccessTokenDuration    time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration   time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	TransferWorkerInterval time.Duration `mapstructure:"TRANSFER_WORKER_INTERVAL"`
+	DisabledFeatures       []string      `mapstructure:"DISABLED_FEATURES"`
+
+	// ReplicaDBSource is a read-only replica that GetAccount, ListAccounts,
+	// ListEntry and ListTransfer are sent to. Empty keeps them on DB_SOURCE.
+	ReplicaDBSource string `mapstructure:"REPLICA_DB_SOURCE"`
+
+	// TransferIsolationLevel is the isolation level transfers run at: read
+	// committed (the default), repeatable read or serializable.
+	TransferIsolationLevel string `mapstructure:"TRANSFER_ISOLATION_LEVEL"`
+	// DefaultPageSize is the page size of listings that leave page_size out.
+	// Zero means 20.
+	DefaultPageSize int32 `mapstructure:"DEFAULT_PAGE_SIZE"`
+
+	RequireTransferDescription bool `mapstructure:"REQUIRE_TRANSFER_DESCRIPTION"`
+	// MinTransferAmount and MaxTransferAmount bound a single transfer. Zero
+	// leaves that side unbounded.
+	MinTransferAmount int64 `mapstructure:"MIN_TRANSFER_AMOUNT"`
+	MaxTransferAmount int64 `mapstructure:"MAX_TRANSFER_AMOUNT"`
+	// BalanceCaps is the most an account may hold, per currency, as
+	// CURRENCY:AMOUNT entries; see ParseBalanceCaps. A currency without a
+	// cap is unbounded.
+	BalanceCaps []string `mapstructure:"BALANCE_CAPS"`
+	// DuplicateTransferWindow rejects a transfer with the same accounts and
+	// amount as one made this recently, unless the client forces it. Zero
+	// turns the check off.
+	DuplicateTransferWindow time.Duration `mapstructure:"DUPLICATE_TRANSFER_WINDOW"`
+	// TransferApprovalThreshold holds transfers above this amount for a
+	// second approver. Zero disables approvals.
+	TransferApprovalThreshold int64 `mapstructure:"TRANSFER_APPROVAL_THRESHOLD"`
+	// TransferFeeFlat plus TransferFeeBasisPoints hundredths of a percent of
+	// the amount, rounded to the nearest minor unit, is charged on each
+	// transfer and credited to the fee account for its currency.
+	TransferFeeFlat        int64 `mapstructure:"TRANSFER_FEE_FLAT"`
+	TransferFeeBasisPoints int64 `mapstructure:"TRANSFER_FEE_BASIS_POINTS"`
+	// FeeAccounts are the accounts fees are credited to, one per currency, as
+	// CURRENCY:ACCOUNT_ID entries; see ParseFeeAccounts. A fee needs one for
+	// every supported currency.
+	FeeAccounts []string `mapstructure:"FEE_ACCOUNTS"`
+	// HoldTTL is how long an authorization hold reserves funds before it
+	// lapses.
+	HoldTTL time.Duration `mapstructure:"HOLD_TTL"`
+	// MaxHoldsPerAccount is how many active holds an account may have at
+	// once. Zero means no limit.
+	MaxHoldsPerAccount int64 `mapstructure:"MAX_HOLDS_PER_ACCOUNT"`
+	// HoldCleanupInterval is how often expired holds are voided. Zero turns
+	// the cleanup off.
+	HoldCleanupInterval time.Duration `mapstructure:"HOLD_CLEANUP_INTERVAL"`
+	// PasswordMinLength, PasswordRequireLetter and PasswordRequireDigit are
+	// the rules a password must meet at signup and when it is changed; see
+	// ValidatePassword. A zero length means DefaultPasswordMinLength.
+	PasswordMinLength     int  `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordRequireLetter bool `mapstructure:"PASSWORD_REQUIRE_LETTER"`
+	PasswordRequireDigit  bool `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	// PublicURL is where clients reach the server, such as
+	// "https://bank.example.com". Links in emails are built on it.
+	PublicURL string `mapstructure:"PUBLIC_URL"`
+	// VerificationTokenTTL is how long the email verification token issued
+	// at signup stays valid. Zero means a day.
+	VerificationTokenTTL time.Duration `mapstructure:"VERIFICATION_TOKEN_TTL"`
+	// ReturnVerificationToken puts the verification token in the signup
+	// response. It is meant for development and tests, where no email goes
+	// out; never turn it on in production.
+	ReturnVerificationToken bool `mapstructure:"RETURN_VERIFICATION_TOKEN"`
+	// MaintenanceMode starts the server rejecting writes with 503. Admins
+	// can toggle it at runtime through PUT /admin/maintenance.
+	MaintenanceMode bool `mapstructure:"MAINTENANCE_MODE"`
+	// AutoCreateAccount opens an empty DefaultCurrency account for every
+	// user who signs up, in the same transaction as the user.
+	AutoCreateAccount bool   `mapstructure:"AUTO_CREATE_ACCOUNT"`
+	DefaultCurrency   string `mapstructure:"DEFAULT_CURRENCY"`
+	// GzipMinBytes is the smallest response body compressed for clients
+	// that accept gzip. Zero turns compression off.
+	GzipMinBytes int `mapstructure:"GZIP_MIN_BYTES"`
+	// TokenKeyID names TokenSymmetricKey in the tokens it signs.
+	// TokenVerificationKeys are older keys, as ID:SECRET entries, whose
+	// tokens are still accepted. To rotate keys, move the current key there
+	// and set a new one with a new ID; drop the old key once its tokens have
+	// expired.
+	TokenKeyID            string   `mapstructure:"TOKEN_KEY_ID"`
+	TokenVerificationKeys []string `mapstructure:"TOKEN_VERIFICATION_KEYS"`
+	// AdminAllowedCIDRs restricts the /admin routes to clients in these
+	// ranges. Empty leaves them open to any admin.
+	AdminAllowedCIDRs []string `mapstructure:"ADMIN_ALLOWED_CIDRS"`
+	// AccountIDMode is how the API addresses accounts in paths: "serial"
+	// (the default) by their ID, or "uuid" by their public_id, so account
+	// URLs cannot be guessed by counting. The public_id is there in both
+	// modes, so clients can move to it before the switch.
+	AccountIDMode string `mapstructure:"ACCOUNT_ID_MODE"`
+	// TrustedProxies are the addresses or CIDR ranges of the proxies in
+	// front of the server. Only they are believed about the client address
+	// in X-Forwarded-For. Empty trusts a proxy on loopback only.
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+	// AllowFeatureOverride lets a request toggle feature flags for itself
+	// with the X-Feature-Override header. It is meant for development and
+	// tests; never turn it on in production.
+	AllowFeatureOverride bool `mapstructure:"ALLOW_FEATURE_OVERRIDE"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
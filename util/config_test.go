@@ -0,0 +1,22 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFeatures(t *testing.T) {
+	config, err := LoadConfig("..")
+	require.NoError(t, err)
+	require.Empty(t, config.DisabledFeatures)
+	require.False(t, config.AllowFeatureOverride)
+
+	t.Setenv("DISABLED_FEATURES", "async_transfers")
+	t.Setenv("ALLOW_FEATURE_OVERRIDE", "true")
+
+	config, err = LoadConfig("..")
+	require.NoError(t, err)
+	require.Equal(t, []string{"async_transfers"}, config.DisabledFeatures)
+	require.True(t, config.AllowFeatureOverride)
+}
@@ -0,0 +1,127 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Currencies an account can hold.
+const (
+	USD = "USD"
+	EUR = "EUR"
+	MYR = "MYR"
+	JPY = "JPY"
+)
+
+// Currency describes a currency accounts can be opened in. Amounts are kept
+// in its minor unit, of which Decimals digits make up the major unit.
+type Currency struct {
+	Code     string `json:"code"`
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+}
+
+var supportedCurrencies = []Currency{
+	{Code: USD, Symbol: "$", Decimals: 2},
+	{Code: EUR, Symbol: "€", Decimals: 2},
+	{Code: MYR, Symbol: "RM", Decimals: 2},
+	{Code: JPY, Symbol: "¥", Decimals: 0},
+}
+
+// SupportedCurrencies lists the currencies accounts can be opened in. The
+// slice is the caller's own.
+func SupportedCurrencies() []Currency {
+	return append([]Currency(nil), supportedCurrencies...)
+}
+
+// IsSupportedCurrency reports whether accounts can be opened in currency.
+func IsSupportedCurrency(currency string) bool {
+	for _, supported := range supportedCurrencies {
+		if supported.Code == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrencyDecimals returns how many digits of the minor unit make up the
+// major unit of currency, 2 for USD and 0 for JPY. It reports false when the
+// currency is not supported.
+func CurrencyDecimals(currency string) (int, bool) {
+	for _, supported := range supportedCurrencies {
+		if supported.Code == currency {
+			return supported.Decimals, true
+		}
+	}
+	return 0, false
+}
+
+// FormatAmount writes an amount held in minor units in the major unit of its
+// currency, such as "12.34 USD" for 1234 cents or "1234 JPY" for 1234 yen.
+// Amounts in a currency that is not supported are written as they are.
+func FormatAmount(amount int64, currency string) string {
+	decimals, _ := CurrencyDecimals(currency)
+	if decimals == 0 {
+		return fmt.Sprintf("%d %s", amount, currency)
+	}
+
+	sign := ""
+	magnitude := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		magnitude = -magnitude
+	}
+
+	unit := uint64(1)
+	for i := 0; i < decimals; i++ {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, magnitude/unit, decimals, magnitude%unit, currency)
+}
+
+// ParseBalanceCaps reads per-currency balance caps written as CURRENCY:AMOUNT,
+// one per entry, such as "USD:1000000". Amounts are in minor units and must
+// be positive; a currency may appear only once.
+func ParseBalanceCaps(entries []string) (map[string]int64, error) {
+	return parseCurrencyValues(entries, "balance cap", "AMOUNT")
+}
+
+// ParseFeeAccounts reads the accounts transfer fees are credited to, one per
+// currency, written as CURRENCY:ACCOUNT_ID such as "USD:1". Account IDs must
+// be positive; a currency may appear only once.
+func ParseFeeAccounts(entries []string) (map[string]int64, error) {
+	return parseCurrencyValues(entries, "fee account", "ACCOUNT_ID")
+}
+
+// parseCurrencyValues reads CURRENCY:VALUE entries into a map by currency.
+// what names an entry and value its value in the error messages.
+func parseCurrencyValues(entries []string, what string, value string) (map[string]int64, error) {
+	values := make(map[string]int64, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s %q is not CURRENCY:%s", what, entry, value)
+		}
+
+		currency := strings.TrimSpace(parts[0])
+		if !IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("%s %q: unsupported currency", what, entry)
+		}
+		if _, ok := values[currency]; ok {
+			return nil, fmt.Errorf("%s for %s is set twice", what, currency)
+		}
+
+		n, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%s %q: %s must be a positive integer", what, entry, strings.ToLower(value))
+		}
+		values[currency] = n
+	}
+	return values, nil
+}
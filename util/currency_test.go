@@ -0,0 +1,109 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSupportedCurrency(t *testing.T) {
+	for _, currency := range []string{USD, EUR, MYR, JPY} {
+		require.True(t, IsSupportedCurrency(currency), currency)
+	}
+	for _, currency := range []string{"", "usd", "GBP", "US"} {
+		require.False(t, IsSupportedCurrency(currency), currency)
+	}
+
+	for i := 0; i < 20; i++ {
+		require.True(t, IsSupportedCurrency(RandomCurrency()))
+	}
+}
+
+func TestSupportedCurrencies(t *testing.T) {
+	currencies := SupportedCurrencies()
+
+	codes := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		require.True(t, IsSupportedCurrency(currency.Code), currency.Code)
+		require.NotEmpty(t, currency.Symbol, currency.Code)
+		codes = append(codes, currency.Code)
+	}
+	require.ElementsMatch(t, []string{USD, EUR, MYR, JPY}, codes)
+
+	// callers cannot change the list for everyone else
+	currencies[0].Code = "XXX"
+	require.Equal(t, USD, SupportedCurrencies()[0].Code)
+}
+
+func TestCurrencyDecimals(t *testing.T) {
+	decimals, ok := CurrencyDecimals(USD)
+	require.True(t, ok)
+	require.Equal(t, 2, decimals)
+
+	decimals, ok = CurrencyDecimals(JPY)
+	require.True(t, ok)
+	require.Equal(t, 0, decimals)
+
+	_, ok = CurrencyDecimals("GBP")
+	require.False(t, ok)
+}
+
+func TestFormatAmount(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{amount: 1234, currency: USD, want: "12.34 USD"},
+		{amount: 5, currency: USD, want: "0.05 USD"},
+		{amount: -1205, currency: EUR, want: "-12.05 EUR"},
+		{amount: 0, currency: MYR, want: "0.00 MYR"},
+		{amount: 1234, currency: JPY, want: "1234 JPY"},
+		{amount: -7, currency: JPY, want: "-7 JPY"},
+		{amount: -9223372036854775808, currency: USD, want: "-92233720368547758.08 USD"},
+		{amount: 1234, currency: "GBP", want: "1234 GBP"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, FormatAmount(tc.amount, tc.currency))
+	}
+}
+
+func TestParseBalanceCaps(t *testing.T) {
+	caps, err := ParseBalanceCaps([]string{"USD:1000000", " EUR : 500 ", ""})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{USD: 1000000, EUR: 500}, caps)
+
+	caps, err = ParseBalanceCaps(nil)
+	require.NoError(t, err)
+	require.Empty(t, caps)
+
+	for _, entries := range [][]string{
+		{"USD"},
+		{"GBP:100"},
+		{"USD:0"},
+		{"USD:-5"},
+		{"USD:ten"},
+		{"USD:100", "USD:200"},
+	} {
+		_, err := ParseBalanceCaps(entries)
+		require.Error(t, err, entries)
+	}
+}
+
+func TestParseFeeAccounts(t *testing.T) {
+	accounts, err := ParseFeeAccounts([]string{"USD:1", " JPY : 42 "})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{USD: 1, JPY: 42}, accounts)
+
+	for _, entries := range [][]string{
+		{"1"},
+		{"GBP:1"},
+		{"USD:0"},
+		{"USD:one"},
+		{"USD:1", "USD:2"},
+	} {
+		_, err := ParseFeeAccounts(entries)
+		require.Error(t, err, entries)
+	}
+}
@@ -0,0 +1,28 @@
+package util
+
+// basisPointsPerWhole is how many basis points, hundredths of a percent,
+// make up 100%.
+const basisPointsPerWhole = 10_000
+
+// ApplyPercentage returns basisPoints hundredths of a percent of an amount
+// given in minor units, such as cents.
+//
+// Money cannot be split below a minor unit, so the exact result is rounded
+// half away from zero: a remainder of half a minor unit or more becomes a
+// whole one, anything less is dropped. 1.5 cents is 2, 1.49 cents is 1, and
+// -1.5 cents is -2. Only integers are involved, so the result never depends
+// on floating point, and the amount is split before multiplying so that no
+// amount overflows while basisPoints is at most 10000.
+func ApplyPercentage(amountMinorUnits, basisPoints int64) int64 {
+	whole := amountMinorUnits / basisPointsPerWhole * basisPoints
+	rest := amountMinorUnits % basisPointsPerWhole * basisPoints
+
+	result := whole + rest/basisPointsPerWhole
+	switch remainder := rest % basisPointsPerWhole; {
+	case remainder >= basisPointsPerWhole/2:
+		result++
+	case remainder <= -basisPointsPerWhole/2:
+		result--
+	}
+	return result
+}
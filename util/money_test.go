@@ -0,0 +1,41 @@
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPercentage(t *testing.T) {
+	testCases := []struct {
+		name        string
+		amount      int64
+		basisPoints int64
+		want        int64
+	}{
+		{name: "ZeroAmount", amount: 0, basisPoints: 150, want: 0},
+		{name: "ZeroRate", amount: 1000, basisPoints: 0, want: 0},
+		{name: "Exact", amount: 1000, basisPoints: 150, want: 15},
+		{name: "Whole", amount: 1234, basisPoints: 10_000, want: 1234},
+		{name: "OneCentBelowHalf", amount: 1, basisPoints: 4999, want: 0},
+		{name: "OneCentAtHalf", amount: 1, basisPoints: 5000, want: 1},
+		{name: "OneCentSmallRate", amount: 1, basisPoints: 1, want: 0},
+		{name: "OddBelowHalf", amount: 99, basisPoints: 150, want: 1},   // 1.485
+		{name: "OddAboveHalf", amount: 999, basisPoints: 150, want: 15}, // 14.985
+		{name: "HalfRoundsUp", amount: 100, basisPoints: 150, want: 2},  // 1.5
+		{name: "HalfNotToEven", amount: 500, basisPoints: 50, want: 3},  // 2.5
+		{name: "NegativeAtHalf", amount: -100, basisPoints: 150, want: -2},
+		{name: "NegativeBelowHalf", amount: -99, basisPoints: 150, want: -1},
+		{name: "LargeAmount", amount: math.MaxInt64, basisPoints: 10_000, want: math.MaxInt64},
+		{name: "LargeAmountPartial", amount: math.MaxInt64, basisPoints: 5000, want: math.MaxInt64/2 + 1},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, ApplyPercentage(tc.amount, tc.basisPoints))
+		})
+	}
+}
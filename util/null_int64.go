@@ -0,0 +1,36 @@
+package util
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// NullInt64 is a nullable integer that serializes to JSON as the number or
+// null, rather than as sql.NullInt64's {"Int64":...,"Valid":...} object.
+type NullInt64 struct {
+	sql.NullInt64
+}
+
+func NewNullInt64(n int64) NullInt64 {
+	return NullInt64{sql.NullInt64{Int64: n, Valid: true}}
+}
+
+func (ni NullInt64) MarshalJSON() ([]byte, error) {
+	if !ni.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(ni.Int64)
+}
+
+func (ni *NullInt64) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		ni.Int64, ni.Valid = 0, false
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &ni.Int64); err != nil {
+		return err
+	}
+	ni.Valid = true
+	return nil
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// NullString is a nullable string that serializes to JSON as the string or
+// null, rather than as sql.NullString's {"String":...,"Valid":...} object.
+type NullString struct {
+	sql.NullString
+}
+
+func NewNullString(s string) NullString {
+	return NullString{sql.NullString{String: s, Valid: true}}
+}
+
+func (ns NullString) MarshalJSON() ([]byte, error) {
+	if !ns.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(ns.String)
+}
+
+func (ns *NullString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		ns.String, ns.Valid = "", false
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &ns.String); err != nil {
+		return err
+	}
+	ns.Valid = true
+	return nil
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nullRecord struct {
+	TransferID NullInt64  `json:"transfer_id"`
+	ApprovedBy NullString `json:"approved_by"`
+}
+
+func TestNullMarshalUnset(t *testing.T) {
+	data, err := json.Marshal(nullRecord{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"transfer_id":null,"approved_by":null}`, string(data))
+
+	var record nullRecord
+	err = json.Unmarshal(data, &record)
+	require.NoError(t, err)
+	require.False(t, record.TransferID.Valid)
+	require.False(t, record.ApprovedBy.Valid)
+}
+
+func TestNullMarshalSet(t *testing.T) {
+	data, err := json.Marshal(nullRecord{
+		TransferID: NewNullInt64(42),
+		ApprovedBy: NewNullString("banker"),
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"transfer_id":42,"approved_by":"banker"}`, string(data))
+
+	var record nullRecord
+	err = json.Unmarshal(data, &record)
+	require.NoError(t, err)
+	require.Equal(t, NewNullInt64(42), record.TransferID)
+	require.Equal(t, NewNullString("banker"), record.ApprovedBy)
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// NullTime is a nullable timestamp that serializes to JSON null when it is
+// not set, rather than to Go's zero time.
+type NullTime struct {
+	sql.NullTime
+}
+
+func NewNullTime(t time.Time) NullTime {
+	return NullTime{sql.NullTime{Time: t, Valid: true}}
+}
+
+func (nt NullTime) MarshalJSON() ([]byte, error) {
+	if !nt.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(nt.Time)
+}
+
+func (nt *NullTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		nt.Time, nt.Valid = time.Time{}, false
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &nt.Time); err != nil {
+		return err
+	}
+	nt.Valid = true
+	return nil
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nullTimeRecord struct {
+	ProcessedAt NullTime `json:"processed_at"`
+}
+
+func TestNullTimeMarshalUnset(t *testing.T) {
+	data, err := json.Marshal(nullTimeRecord{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"processed_at":null}`, string(data))
+
+	var record nullTimeRecord
+	err = json.Unmarshal(data, &record)
+	require.NoError(t, err)
+	require.False(t, record.ProcessedAt.Valid)
+}
+
+func TestNullTimeMarshalSet(t *testing.T) {
+	now := time.Date(2021, 9, 1, 10, 30, 0, 0, time.UTC)
+
+	data, err := json.Marshal(nullTimeRecord{ProcessedAt: NewNullTime(now)})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"processed_at":"2021-09-01T10:30:00Z"}`, string(data))
+
+	var record nullTimeRecord
+	err = json.Unmarshal(data, &record)
+	require.NoError(t, err)
+	require.True(t, record.ProcessedAt.Valid)
+	require.True(t, now.Equal(record.ProcessedAt.Time))
+}
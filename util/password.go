@@ -0,0 +1,66 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashPassword returns the bcrypt hash of the password
+func HashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashedPassword), nil
+}
+
+// CheckPassword checks if the provided password is correct or not
+func CheckPassword(password string, hashedPassword string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+}
+
+// DefaultPasswordMinLength is the shortest password a PasswordPolicy without
+// a MinLength accepts.
+const DefaultPasswordMinLength = 6
+
+// PasswordPolicy is what a new password must satisfy.
+type PasswordPolicy struct {
+	// MinLength counts characters, not bytes. Zero means
+	// DefaultPasswordMinLength.
+	MinLength     int
+	RequireLetter bool
+	RequireDigit  bool
+}
+
+// ValidatePassword checks password against policy and describes the first
+// rule it breaks.
+func ValidatePassword(password string, policy PasswordPolicy) error {
+	minLength := policy.MinLength
+	if minLength <= 0 {
+		minLength = DefaultPasswordMinLength
+	}
+	if utf8.RuneCountInString(password) < minLength {
+		return fmt.Errorf("must be at least %d characters long", minLength)
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if policy.RequireLetter && !hasLetter {
+		return errors.New("must contain a letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		return errors.New("must contain a digit")
+	}
+	return nil
+}
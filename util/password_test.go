@@ -0,0 +1,95 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPassword(t *testing.T) {
+	password := RandomString(6)
+
+	hashedPassword1, err := HashPassword(password)
+	require.NoError(t, err)
+	require.NotEmpty(t, hashedPassword1)
+
+	err = CheckPassword(password, hashedPassword1)
+	require.NoError(t, err)
+
+	wrongPassword := RandomString(6)
+	err = CheckPassword(wrongPassword, hashedPassword1)
+	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+
+	// hashing the same password twice yields different salts
+	hashedPassword2, err := HashPassword(password)
+	require.NoError(t, err)
+	require.NotEmpty(t, hashedPassword2)
+	require.NotEqual(t, hashedPassword1, hashedPassword2)
+}
+
+func TestValidatePassword(t *testing.T) {
+	strict := PasswordPolicy{MinLength: 8, RequireLetter: true, RequireDigit: true}
+
+	testCases := []struct {
+		name     string
+		password string
+		policy   PasswordPolicy
+		wantErr  string
+	}{
+		{
+			name:     "Valid",
+			password: "secret123",
+			policy:   strict,
+		},
+		{
+			name:     "TooShort",
+			password: "abc123",
+			policy:   strict,
+			wantErr:  "must be at least 8 characters long",
+		},
+		{
+			name:     "NoDigit",
+			password: "secretsecret",
+			policy:   strict,
+			wantErr:  "must contain a digit",
+		},
+		{
+			name:     "NoLetter",
+			password: "12345678",
+			policy:   strict,
+			wantErr:  "must contain a letter",
+		},
+		{
+			// characters are counted, not bytes
+			name:     "MultibyteTooShort",
+			password: "日本語1",
+			policy:   PasswordPolicy{MinLength: 5},
+			wantErr:  "must be at least 5 characters long",
+		},
+		{
+			name:     "DefaultMinLength",
+			password: "abcde",
+			policy:   PasswordPolicy{},
+			wantErr:  "must be at least 6 characters long",
+		},
+		{
+			name:     "NoComplexityRequired",
+			password: "abcdef",
+			policy:   PasswordPolicy{},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidatePassword(tc.password, tc.policy)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
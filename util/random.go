@@ -3,41 +3,107 @@ package util
 import (
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 )
 
 const alphabet = "abcdefghijklmnopqrstuvwxyz"
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+// Random generates the random test values below from its own source, so a
+// failing test can be replayed by building it with the same seed.
+type Random struct {
+	rand *rand.Rand
 }
 
-func RandomInt(min, max int64) int64 {
-	return min + rand.Int63n(max-min+1)
+// NewRandom returns a Random whose sequence is fully determined by seed.
+func NewRandom(seed int64) *Random {
+	return &Random{rand: rand.New(rand.NewSource(seed))}
+}
+
+// defaultRandom backs the package-level helpers. It is shared by every test
+// goroutine, so its source is locked.
+var defaultRandom = &Random{
+	rand: rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano())}),
+}
+
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
 }
 
-func RandomString(n int) string {
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+func (r *Random) Int(min, max int64) int64 {
+	return min + r.rand.Int63n(max-min+1)
+}
+
+// String returns n characters drawn from charset, or from the lowercase
+// alphabet when no charset is given.
+func (r *Random) String(n int, charset ...string) string {
+	chars := alphabet
+	if len(charset) > 0 {
+		chars = charset[0]
+	}
+
 	var sb strings.Builder
-	k := len(alphabet)
+	k := len(chars)
 
 	for i := 0; i < n; i++ {
-		c := alphabet[rand.Intn(k)]
+		c := chars[r.rand.Intn(k)]
 		sb.WriteByte(c)
 	}
 
 	return sb.String()
 }
 
+func (r *Random) Owner() string {
+	return r.String(6)
+}
+
+func (r *Random) Email() string {
+	return r.String(8) + "@email.com"
+}
+
+func (r *Random) Money() int64 {
+	return r.Int(0, 1000)
+}
+
+func (r *Random) Currency() string {
+	currencies := []string{USD, EUR, MYR, JPY}
+	n := len(currencies)
+	return currencies[r.rand.Intn(n)]
+}
+
+func RandomInt(min, max int64) int64 {
+	return defaultRandom.Int(min, max)
+}
+
+func RandomString(n int, charset ...string) string {
+	return defaultRandom.String(n, charset...)
+}
+
 func RandomOwner() string {
-	return RandomString(6)
+	return defaultRandom.Owner()
+}
+
+func RandomEmail() string {
+	return defaultRandom.Email()
 }
 
 func RandomMoney() int64 {
-	return RandomInt(0, 1000)
+	return defaultRandom.Money()
 }
 
 func RandomCurrency() string {
-	currencies := []string{"USD", "EUR", "MYR"}
-	n := len(currencies)
-	return currencies[rand.Intn(n)]
+	return defaultRandom.Currency()
 }
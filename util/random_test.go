@@ -0,0 +1,59 @@
+package util
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRandomIsReproducible(t *testing.T) {
+	r1 := NewRandom(42)
+	r2 := NewRandom(42)
+
+	for i := 0; i < 10; i++ {
+		require.Equal(t, r1.Int(0, 1_000_000), r2.Int(0, 1_000_000))
+		require.Equal(t, r1.String(12), r2.String(12))
+		require.Equal(t, r1.Owner(), r2.Owner())
+		require.Equal(t, r1.Money(), r2.Money())
+		require.Equal(t, r1.Currency(), r2.Currency())
+	}
+}
+
+func TestNewRandomSeedsDiffer(t *testing.T) {
+	require.NotEqual(t, NewRandom(1).String(32), NewRandom(2).String(32))
+}
+
+func TestRandomEmail(t *testing.T) {
+	email := RandomEmail()
+
+	address, err := mail.ParseAddress(email)
+	require.NoError(t, err)
+	require.Equal(t, email, address.Address)
+	require.True(t, strings.HasSuffix(email, "@email.com"))
+}
+
+func TestRandomString(t *testing.T) {
+	testCases := []struct {
+		name    string
+		charset []string
+		allowed string
+	}{
+		{name: "DefaultCharset", allowed: alphabet},
+		{name: "Digits", charset: []string{"0123456789"}, allowed: "0123456789"},
+		{name: "SingleChar", charset: []string{"x"}, allowed: "x"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			s := RandomString(20, tc.charset...)
+			require.Len(t, s, 20)
+			for _, c := range s {
+				require.Contains(t, tc.allowed, string(c))
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package util
+
+// Roles a user can hold. Every new user starts out as a depositor.
+const (
+	DepositorRole = "depositor"
+	BankerRole    = "banker"
+	AdminRole     = "admin"
+)
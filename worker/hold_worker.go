@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+// HoldWorker voids authorization holds that expired without being captured
+// or voided, releasing the funds they reserved.
+type HoldWorker struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewHoldWorker(store db.Store, interval time.Duration) *HoldWorker {
+	return &HoldWorker{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Start voids expired holds every interval until ctx is cancelled.
+func (worker *HoldWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(worker.interval)
+	defer ticker.Stop()
+
+	for {
+		worker.VoidExpiredHolds(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// VoidExpiredHolds voids every hold that has expired and returns how many it
+// voided. Running it again once they are gone does nothing.
+func (worker *HoldWorker) VoidExpiredHolds(ctx context.Context) int {
+	voided := 0
+
+	for {
+		hold, err := worker.store.VoidExpiredHoldTx(ctx)
+		if err != nil {
+			if !errors.Is(err, db.ErrRecordNotFound) {
+				log.Println("cannot void expired hold:", err)
+			}
+			return voided
+		}
+
+		log.Printf("expired hold %d %s", hold.ID, hold.Status)
+		voided++
+	}
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoidExpiredHolds(t *testing.T) {
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		voided     int
+	}{
+		{
+			name: "VoidsExpired",
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().VoidExpiredHoldTx(gomock.Any()).Times(1).
+						Return(db.Hold{ID: 1, Status: db.HoldStatusVoided}, nil),
+					store.EXPECT().VoidExpiredHoldTx(gomock.Any()).Times(1).
+						Return(db.Hold{ID: 2, Status: db.HoldStatusVoided}, nil),
+					store.EXPECT().VoidExpiredHoldTx(gomock.Any()).Times(1).
+						Return(db.Hold{}, db.ErrRecordNotFound),
+				)
+			},
+			voided: 2,
+		},
+		{
+			name: "NoneExpired",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VoidExpiredHoldTx(gomock.Any()).Times(1).Return(db.Hold{}, db.ErrRecordNotFound)
+			},
+			voided: 0,
+		},
+		{
+			name: "StopsOnError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VoidExpiredHoldTx(gomock.Any()).Times(1).Return(db.Hold{}, sql.ErrConnDone)
+			},
+			voided: 0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			worker := NewHoldWorker(store, 0)
+			require.Equal(t, tc.voided, worker.VoidExpiredHolds(context.Background()))
+		})
+	}
+}
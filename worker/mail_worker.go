@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/qwerqy/mock_bank/mail"
+)
+
+var ErrMailQueueFull = errors.New("mail queue is full")
+
+// MailWorker sends email in the background. It is itself a mail.Sender whose
+// Send only queues the email, so a request that triggers one never waits on
+// the mail server.
+type MailWorker struct {
+	sender mail.Sender
+	queue  chan mail.Email
+}
+
+// NewMailWorker delivers email through sender, holding up to size emails
+// that are waiting to go out.
+func NewMailWorker(sender mail.Sender, size int) *MailWorker {
+	return &MailWorker{
+		sender: sender,
+		queue:  make(chan mail.Email, size),
+	}
+}
+
+// Send queues email for delivery without blocking. It fails with
+// ErrMailQueueFull rather than wait for room.
+func (worker *MailWorker) Send(ctx context.Context, email mail.Email) error {
+	select {
+	case worker.queue <- email:
+		return nil
+	default:
+		return ErrMailQueueFull
+	}
+}
+
+// Start delivers queued email until ctx is cancelled. A failed delivery is
+// logged and dropped.
+func (worker *MailWorker) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case email := <-worker.queue:
+			worker.deliver(ctx, email)
+		}
+	}
+}
+
+func (worker *MailWorker) deliver(ctx context.Context, email mail.Email) {
+	if err := worker.sender.Send(ctx, email); err != nil {
+		log.Printf("cannot send email %q to %s: %v", email.Subject, email.To, err)
+	}
+}
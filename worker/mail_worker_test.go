@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qwerqy/mock_bank/mail"
+	mockmail "github.com/qwerqy/mock_bank/mail/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailWorker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	first := mail.Email{To: "alice@example.com", Subject: "first"}
+	second := mail.Email{To: "bob@example.com", Subject: "second"}
+
+	delivered := make(chan mail.Email, 2)
+	sender := mockmail.NewMockSender(ctrl)
+	gomock.InOrder(
+		// a failed delivery does not hold up the next one
+		sender.EXPECT().Send(gomock.Any(), gomock.Eq(first)).Times(1).DoAndReturn(
+			func(_ context.Context, email mail.Email) error {
+				delivered <- email
+				return errors.New("mail server down")
+			}),
+		sender.EXPECT().Send(gomock.Any(), gomock.Eq(second)).Times(1).DoAndReturn(
+			func(_ context.Context, email mail.Email) error {
+				delivered <- email
+				return nil
+			}),
+	)
+
+	worker := NewMailWorker(sender, 2)
+	require.NoError(t, worker.Send(context.Background(), first))
+	require.NoError(t, worker.Send(context.Background(), second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go worker.Start(ctx)
+
+	for _, want := range []mail.Email{first, second} {
+		select {
+		case got := <-delivered:
+			require.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("email %q was not delivered", want.Subject)
+		}
+	}
+}
+
+func TestMailWorkerQueueFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// nothing is delivered while the worker is not started
+	sender := mockmail.NewMockSender(ctrl)
+	sender.EXPECT().Send(gomock.Any(), gomock.Any()).Times(0)
+
+	worker := NewMailWorker(sender, 1)
+	require.NoError(t, worker.Send(context.Background(), mail.Email{To: "alice@example.com"}))
+	require.ErrorIs(t, worker.Send(context.Background(), mail.Email{To: "bob@example.com"}), ErrMailQueueFull)
+}
@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+)
+
+// TransferWorker executes transfers that were queued through the async
+// transfer endpoint, and scheduled transfers once they fall due.
+type TransferWorker struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewTransferWorker(store db.Store, interval time.Duration) *TransferWorker {
+	return &TransferWorker{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Start polls for pending jobs and due scheduled transfers every interval
+// until ctx is cancelled.
+func (worker *TransferWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(worker.interval)
+	defer ticker.Stop()
+
+	for {
+		worker.ProcessPendingJobs(ctx)
+		worker.ProcessDueScheduledTransfers(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPendingJobs drains the queue and returns how many jobs were processed.
+func (worker *TransferWorker) ProcessPendingJobs(ctx context.Context) int {
+	processed := 0
+
+	for {
+		job, err := worker.store.ProcessTransferJobTx(ctx)
+		if err != nil {
+			if !errors.Is(err, db.ErrRecordNotFound) {
+				log.Println("cannot process transfer job:", err)
+			}
+			return processed
+		}
+
+		log.Printf("transfer job %d %s", job.ID, job.Status)
+		processed++
+	}
+}
+
+// ProcessDueScheduledTransfers executes every scheduled transfer that has
+// fallen due and returns how many were processed, failed ones included.
+func (worker *TransferWorker) ProcessDueScheduledTransfers(ctx context.Context) int {
+	processed := 0
+
+	for {
+		scheduled, err := worker.store.ExecuteScheduledTransferTx(ctx)
+		if err != nil {
+			if !errors.Is(err, db.ErrRecordNotFound) {
+				log.Println("cannot execute scheduled transfer:", err)
+			}
+			return processed
+		}
+
+		log.Printf("scheduled transfer %d %s", scheduled.ID, scheduled.Status)
+		processed++
+	}
+}
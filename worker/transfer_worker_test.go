@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/qwerqy/mock_bank/db/mock"
+	db "github.com/qwerqy/mock_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessPendingJobs(t *testing.T) {
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		processed  int
+	}{
+		{
+			name: "DrainsQueue",
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().ProcessTransferJobTx(gomock.Any()).Times(1).
+						Return(db.TransferJob{ID: 1, Status: db.TransferJobStatusCompleted}, nil),
+					store.EXPECT().ProcessTransferJobTx(gomock.Any()).Times(1).
+						Return(db.TransferJob{ID: 2, Status: db.TransferJobStatusFailed}, nil),
+					store.EXPECT().ProcessTransferJobTx(gomock.Any()).Times(1).
+						Return(db.TransferJob{}, db.ErrRecordNotFound),
+				)
+			},
+			processed: 2,
+		},
+		{
+			name: "EmptyQueue",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ProcessTransferJobTx(gomock.Any()).Times(1).Return(db.TransferJob{}, db.ErrRecordNotFound)
+			},
+			processed: 0,
+		},
+		{
+			name: "StopsOnError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ProcessTransferJobTx(gomock.Any()).Times(1).Return(db.TransferJob{}, sql.ErrConnDone)
+			},
+			processed: 0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			worker := NewTransferWorker(store, 0)
+			require.Equal(t, tc.processed, worker.ProcessPendingJobs(context.Background()))
+		})
+	}
+}
+
+func TestProcessDueScheduledTransfers(t *testing.T) {
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		processed  int
+	}{
+		{
+			name: "ExecutesDue",
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).
+						Return(db.ScheduledTransfer{ID: 1, Status: db.ScheduledTransferStatusExecuted}, nil),
+					store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).
+						Return(db.ScheduledTransfer{ID: 2, Status: db.ScheduledTransferStatusFailed}, nil),
+					store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).
+						Return(db.ScheduledTransfer{}, db.ErrRecordNotFound),
+				)
+			},
+			processed: 2,
+		},
+		{
+			name: "NothingDue",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(db.ScheduledTransfer{}, db.ErrRecordNotFound)
+			},
+			processed: 0,
+		},
+		{
+			name: "StopsOnError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(db.ScheduledTransfer{}, sql.ErrConnDone)
+			},
+			processed: 0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			worker := NewTransferWorker(store, 0)
+			require.Equal(t, tc.processed, worker.ProcessDueScheduledTransfers(context.Background()))
+		})
+	}
+}